@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"github.com/cduggn/ccexplorer/cmd/cli"
 	"log/slog"
 	"os"
+	"os/signal"
 )
 
 func main() {
 	root := cli.RootCommand()
 
-	if err := root.Execute(); err != nil {
+	// Ctrl-C cancels the context of the running command; a second Ctrl-C
+	// exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := root.ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		slog.Error("error", ErrAttr(err))
 		os.Exit(126)
 	}
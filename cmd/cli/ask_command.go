@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/embedding"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
+	"github.com/spf13/cobra"
+)
+
+// askCommand creates the ask command, which answers questions from the cost
+// history previously written with -p vector or -p pinecone
+func askCommand() *cobra.Command {
+	askCmd := &cobra.Command{
+		Use:   "ask <question>",
+		Short: "Ask a question about stored cost history",
+		Long: `Answer a natural-language question from the cost documents stored in a vector
+store by 'ccexplorer get aws -p vector' or '-p pinecone'.
+
+The question is embedded with the model used to store the documents, the most
+similar documents are retrieved, and a chat-completions model answers from
+them, citing the periods and keys it relied on. No Cost Explorer calls are made.`,
+		Example: AskExamples,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    runAsk,
+	}
+
+	askCmd.Flags().Int("topK", assistant.DefaultTopK,
+		"Number of cost documents to retrieve")
+	askCmd.Flags().StringP("startDate", "s", "",
+		"Only use documents whose period starts on or after this date")
+	askCmd.Flags().StringP("endDate", "e", "",
+		"Only use documents whose period starts before this date")
+	askCmd.Flags().String("vectorStore", vectorstore.Local,
+		"Vector store holding the cost documents. Valid values: local, pinecone, qdrant, pgvector")
+	addNamespaceFlag(askCmd.Flags())
+	addEmbeddingFlags(askCmd)
+	addChatFlags(askCmd)
+
+	return askCmd
+}
+
+func runAsk(cmd *cobra.Command, args []string) error {
+	topK, _ := cmd.Flags().GetInt("topK")
+	if topK <= 0 || topK > 50 {
+		return ValidationError{Message: "topK must be between 1 and 50"}
+	}
+
+	filter, err := dateRangeFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	embedder, err := embedding.New(embeddingConfig(cmd))
+	if err != nil {
+		return ValidationError{Message: "Invalid embedding configuration. " + err.Error()}
+	}
+	backend, _ := cmd.Flags().GetString("vectorStore")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	store, err := vectorstore.New(vectorStoreConfig(backend, namespace))
+	if err != nil {
+		return ValidationError{Message: "Invalid vector store configuration. " + err.Error()}
+	}
+	chat, err := assistant.NewChat(chatConfig(cmd))
+	if err != nil {
+		return ValidationError{Message: "Invalid chat configuration. " + err.Error()}
+	}
+
+	a := assistant.New(vectorstore.NewSearcher(embedder, store), chat)
+	answer, err := a.Ask(cmd.Context(), strings.Join(args, " "), topK, filter)
+	if err != nil {
+		return err
+	}
+
+	printAnswer(cmd.OutOrStdout(), answer)
+	return nil
+}
+
+func printAnswer(w io.Writer, answer types.Answer) {
+	fmt.Fprintln(w, strings.TrimSpace(answer.Text))
+	if len(answer.Citations) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Sources:")
+	for _, c := range answer.Citations {
+		fmt.Fprintf(w, "  %s %s (similarity %.2f)\n", c.Label,
+			assistant.Describe(c.Match), c.Match.Score)
+	}
+}
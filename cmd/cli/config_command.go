@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/config"
+	"github.com/cduggn/ccexplorer/internal/mcp"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configCommand creates the config command, which shows, checks and
+// creates configuration files
+func configCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "View, validate and create the configuration file",
+		Long: `View, validate and create the configuration file.
+
+The configuration is read from --config or, by default, from
+$XDG_CONFIG_HOME/ccexplorer/config.yaml, overridden by ./ccexplorer.yaml.
+Environment variables override both files, and flags override everything.
+A context selected with --context, or current_context, is applied on top.`,
+		Example: ConfigExamples,
+	}
+
+	viewCmd := &cobra.Command{
+		Use:   "view",
+		Short: "Print the effective configuration with secrets redacted",
+		Args:  cobra.NoArgs,
+		RunE:  runConfigView,
+	}
+
+	validateCmd := &cobra.Command{
+		Use:         "validate",
+		Short:       "Check the configuration for unknown sections, flags and values",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipConfigAnnotation: "true"},
+		RunE:        runConfigValidate,
+	}
+
+	initCmd := &cobra.Command{
+		Use:         "init",
+		Short:       "Write an annotated configuration file",
+		Args:        cobra.NoArgs,
+		Annotations: map[string]string{skipConfigAnnotation: "true"},
+		RunE:        runConfigInit,
+	}
+	initCmd.Flags().Bool("force", false,
+		"Overwrite an existing configuration file")
+
+	configCmd.AddCommand(viewCmd, validateCmd, initCmd)
+	return configCmd
+}
+
+func runConfigView(cmd *cobra.Command, args []string) error {
+	return printConfig(cmd.OutOrStdout(), loadedConfig(cmd), viper.AllSettings())
+}
+
+func printConfig(out io.Writer, loaded config.Loaded, settings map[string]any) error {
+	files := "(none found)"
+	if len(loaded.Files) > 0 {
+		files = strings.Join(loaded.Files, ", ")
+	}
+	context := loaded.Context
+	if context == "" {
+		context = "(none)"
+	}
+	fmt.Fprintf(out, "# files: %s\n# context: %s\n", files, context)
+
+	// contexts were merged into the settings they override
+	delete(settings, config.ContextsKey)
+	data, err := yaml.Marshal(config.Redacted(settings))
+	if err != nil {
+		return types.Error{Msg: "Error printing configuration: " + err.Error()}
+	}
+	_, err = out.Write(data)
+	return err
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("config")
+	context, _ := cmd.Flags().GetString("context")
+	loaded, err := config.Load(file, context)
+	if err != nil {
+		return ValidationError{Message: "Invalid configuration. " + err.Error()}
+	}
+	if len(loaded.Files) == 0 {
+		return ValidationError{Message: "No configuration file found; create one with 'ccexplorer config init'"}
+	}
+
+	errs := config.Validate()
+	errs = append(errs, validateDefaults(cmd.Root(), config.Defaults())...)
+	if _, err := mcp.ParseMaxSpan(viper.GetString("mcp.max_span")); err != nil {
+		errs = append(errs, fmt.Errorf("mcp.max_span: %w", err))
+	}
+	if len(errs) > 0 {
+		return ValidationError{Message: "Invalid configuration in " +
+			strings.Join(loaded.Files, ", ") + ":\n  " + strings.Join(errorMessages(errs), "\n  ")}
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Configuration in %s is valid\n", strings.Join(loaded.Files, ", "))
+	return nil
+}
+
+// validateDefaults checks that every flag default names a flag of some
+// command of the tree, and that its value parses for each of them. Values
+// are parsed by setting them on commands that are not run.
+func validateDefaults(root *cobra.Command, defaults map[string]string) []error {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		found := false
+		walkCommands(root, func(c *cobra.Command) {
+			c.Flags().VisitAll(func(f *pflag.Flag) {
+				if !strings.EqualFold(f.Name, name) {
+					return
+				}
+				found = true
+				if err := f.Value.Set(defaults[name]); err != nil {
+					errs = append(errs, fmt.Errorf("defaults.%s: invalid value for %s --%s: %w",
+						name, c.CommandPath(), f.Name, err))
+				}
+			})
+		})
+		if !found {
+			errs = append(errs, fmt.Errorf("defaults.%s: no command has a flag named %s", name, name))
+		}
+	}
+	return errs
+}
+
+func walkCommands(c *cobra.Command, fn func(*cobra.Command)) {
+	fn(c)
+	for _, sub := range c.Commands() {
+		walkCommands(sub, fn)
+	}
+}
+
+func errorMessages(errs []error) []string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return messages
+}
+
+func runConfigInit(cmd *cobra.Command, args []string) error {
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return types.Error{Msg: "Error locating configuration directory: " + err.Error()}
+		}
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(path); err == nil && !force {
+		return ValidationError{Message: path + " already exists; use --force to overwrite it"}
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return types.Error{Msg: "Error writing configuration: " + err.Error()}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return types.Error{Msg: "Error writing configuration: " + err.Error()}
+	}
+	// the file holds API keys
+	if err := os.WriteFile(path, []byte(config.Template), 0600); err != nil {
+		return types.Error{Msg: "Error writing configuration: " + err.Error()}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Configuration written to %s\n", path)
+	return nil
+}
@@ -2,15 +2,23 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/cduggn/ccexplorer/internal/assistant"
 	"github.com/cduggn/ccexplorer/internal/flags"
 	awsservice "github.com/cduggn/ccexplorer/internal/awsservice"
 	"github.com/cduggn/ccexplorer/internal/ports"
 	"github.com/cduggn/ccexplorer/internal/types"
 	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
 	"github.com/cduggn/ccexplorer/internal/writer"
 	"github.com/common-nighthawk/go-figure"
 	"github.com/spf13/cobra"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,6 +35,7 @@ var (
 	costUsageWithoutDiscounts       bool
 	costAndUsagePrintFormat         string
 	costAndUsageMetric              string
+	costAndUsageVectorStore         string
 	costUsageSortByDate             bool
 	forecastStartDate               string
 	forecastEndDate                 string
@@ -47,12 +56,18 @@ type ForecastCommandType struct {
 	Cmd *cobra.Command
 }
 
-func Initialize() {
-	var err error
-	srv, err = configureServices()
+// Initialize configures the AWS services once the configuration is loaded.
+// It is a no-op when they are already configured.
+func Initialize() error {
+	if srv != nil {
+		return nil
+	}
+	s, err := configureServices()
 	if err != nil {
-		panic(err.Error())
+		return types.Error{Msg: "Error configuring AWS services: " + err.Error()}
 	}
+	srv = s
+	return nil
 }
 
 func configureServices() (*service, error) {
@@ -133,7 +148,22 @@ func (c *CostCommandType) DefineFlags() {
 		"End date *(defaults to the present day)")
 
 	c.Cmd.Flags().StringVarP(&costAndUsagePrintFormat, "printFormat", "p", "stdout",
-		"Valid values: stdout, csv, chart, pinecone (default: stdout)")
+		"Valid values: stdout, csv, chart, json, ndjson, markdown, xlsx, pinecone, vector, or several separated by commas, "+
+			"e.g. stdout,csv,chart (default: stdout)")
+	addOutputFlags(c.Cmd)
+	addChartFlags(c.Cmd)
+	c.Cmd.Flags().String("layout", writer.LayoutRows,
+		"Layout of stdout, csv, markdown and xlsx output. Valid values: rows (one row per key per period), "+
+			"pivot (one row per key, one column per period, with totals, share and change)")
+
+	c.Cmd.Flags().StringVar(&costAndUsageVectorStore, "vectorStore", vectorstore.Local,
+		"Vector store used by --printFormat vector. Valid values: local, pinecone, qdrant, pgvector (default: local)")
+	addNamespaceFlag(c.Cmd.Flags())
+	addEmbeddingFlags(c.Cmd)
+	addIngestFlags(c.Cmd)
+	addDocumentFlags(c.Cmd)
+	addBinningFlags(c.Cmd)
+	addSummaryFlags(c.Cmd)
 
 	c.Cmd.Flags().StringVarP(&costAndUsageMetric, "metric", "i", "UnblendedCost",
 		"Valid values: AmortizedCost, BlendedCost, NetAmortizedCost, "+
@@ -159,7 +189,16 @@ func (f *ForecastCommandType) DefineFlags() {
 		"Valid values: DAILY, MONTHLY, HOURLY (default: MONTHLY)")
 
 	f.Cmd.Flags().Int32VarP(&forecastPredictionIntervalLevel, "predictionIntervalLevel",
-		"p", 95, "Prediction interval level (default: 95)")
+		"l", 95, "Prediction interval level (default: 95)")
+
+	f.Cmd.Flags().StringP("printFormat", "p", "stdout",
+		"Valid values: stdout, csv, chart, json, ndjson (default: stdout)")
+	addOutputFlags(f.Cmd)
+
+	f.Cmd.Flags().Float64("budget", 0,
+		"Budget per period drawn on the forecast chart (default: none)")
+
+	addSummaryFlags(f.Cmd)
 }
 
 func paintHeader() string {
@@ -175,7 +214,7 @@ func (c *CostCommandType) RunE(cmd *cobra.Command,
 	}
 
 	req := c.SynthesizeRequest(userInput)
-	err = c.Execute(req)
+	err = c.Execute(cmd.Context(), req)
 	if err != nil {
 		return err
 	}
@@ -215,6 +254,15 @@ func (c *CostCommandType) InputHandler(validatorFn func(input types.CommandLineI
 		OpenAIAPIKey:        printOptions.OpenAIKey,
 		PineconeAPIKey:      printOptions.PineconeAPIKey,
 		PineconeIndex:       printOptions.PineconeIndex,
+		VectorStore:         printOptions.VectorStore,
+		Embedding:           printOptions.Embedding,
+		Ingest:              printOptions.Ingest,
+		Documents:           printOptions.Documents,
+		Binning:             printOptions.Binning,
+		Summary:             printOptions.Summary,
+		Output:              printOptions.Output,
+		Layout:              printOptions.Layout,
+		Chart:               printOptions.Chart,
 	}
 
 	err = validatorFn(input)
@@ -246,38 +294,70 @@ func (c *CostCommandType) SynthesizeRequest(input types.CommandLineInput) types.
 		OpenAIAPIKey:               input.OpenAIAPIKey,
 		PineconeAPIKey:             input.PineconeAPIKey,
 		PineconeIndex:              input.PineconeIndex,
+		VectorStore:                input.VectorStore,
+		Embedding:                  input.Embedding,
+		Ingest:                     input.Ingest,
+		Documents:                  input.Documents,
+		Binning:                    input.Binning,
+		Summary:                    input.Summary,
+		Output:                     input.Output,
+		Layout:                     input.Layout,
+		Chart:                      input.Chart,
 	}
 }
 
-func (c *CostCommandType) Execute(req types.CostAndUsageRequestType) error {
+// Execute fetches the report of req and writes it; ctx cancels the Cost
+// Explorer requests
+func (c *CostCommandType) Execute(ctx context.Context, req types.CostAndUsageRequestType) error {
+	if err := Initialize(); err != nil {
+		return err
+	}
 
-	costAndUsageResponse, err := srv.aws.GetCostAndUsage(
-		context.Background(), req)
+	costAndUsageResponse, err := srv.aws.GetCostAndUsage(ctx, req)
 	if err != nil {
 		return err
 	}
 
 	report := utils.ToCostAndUsageOutputType(costAndUsageResponse, req)
 
-	w := writer.NewPrintWriter(utils.ToPrintWriterType(req.PrintFormat),
-		"costAndUsage")
-
-	err = w.Write(utils.SortByFn(req.SortByDate), report)
+	files, err := writer.WriteCostAndUsage(utils.PrintFormats(req.PrintFormat),
+		utils.SortByFn(req.SortByDate), report)
+	for _, file := range files {
+		fmt.Fprintf(c.Cmd.ErrOrStderr(), "Wrote %s\n", file)
+	}
 	if err != nil {
 		return err
 	}
+
+	if req.Summary.Enabled {
+		title := fmt.Sprintf("AWS %s from %s to %s", req.Metrics[0], req.Time.Start, req.Time.End)
+		return writeSummary(ctx, summaryWriter(req.PrintFormat, req.Output), req.Summary, title,
+			assistant.CostFacts(report))
+	}
 	return nil
 }
 
 func (f *ForecastCommandType) RunE(cmd *cobra.Command, args []string) error {
 
 	userInput := f.InputHandler()
+	if !IsValidForecastPrintFormat(userInput.PrintFormat) {
+		message := "Invalid print format. Please use one of the following: stdout, csv, chart, json, ndjson"
+		if _, err := strconv.Atoi(userInput.PrintFormat); err == nil {
+			message += ". The prediction interval level is now set with -l or --predictionIntervalLevel"
+		}
+		return ValidationError{Message: message}
+	}
 	req, err := f.SynthesizeRequest(userInput)
 	if err != nil {
 		return err
 	}
+	if userInput.Summary.Enabled {
+		if err := assistant.ValidateChat(userInput.Summary.Chat); err != nil {
+			return ValidationError{Message: "Invalid chat configuration. " + err.Error()}
+		}
+	}
 
-	res, err := f.Execute(req)
+	res, err := f.Execute(cmd.Context(), req)
 	if err != nil {
 		return err
 	}
@@ -285,14 +365,30 @@ func (f *ForecastCommandType) RunE(cmd *cobra.Command, args []string) error {
 	printData := prepareResponseForRendering(res)
 	filters := filterList(req)
 	printData.Filters = filters
+	printData.Request = req
+	printData.Output = userInput.Output
+	printData.Budget = userInput.Budget
+	if userInput.PrintFormat == "chart" {
+		if printData.Actuals, err = forecastActuals(cmd.Context(), req); err != nil {
+			return err
+		}
+	}
 
-	p := writer.NewPrintWriter(utils.ToPrintWriterType("stdout"),
+	p, err := writer.NewPrintWriter(utils.ToPrintWriterType(userInput.PrintFormat),
 		"forecast")
+	if err != nil {
+		return err
+	}
 	err = p.Write(printData, filters)
 	if err != nil {
 		return err
 	}
 
+	if userInput.Summary.Enabled {
+		title := fmt.Sprintf("AWS cost forecast from %s to %s", req.Time.Start, req.Time.End)
+		return writeSummary(cmd.Context(), summaryWriter(userInput.PrintFormat, userInput.Output), userInput.Summary, title,
+			assistant.ForecastFacts(printData))
+	}
 	return nil
 }
 
@@ -301,6 +397,8 @@ func (f *ForecastCommandType) InputHandler() types.ForecastCommandLineInput {
 	granularity, _ := f.Cmd.Flags().GetString("granularity")
 	predictionIntervalLevel, _ := f.Cmd.Flags().GetInt32(
 		"predictionIntervalLevel")
+	printFormat, _ := f.Cmd.Flags().GetString("printFormat")
+	budget, _ := f.Cmd.Flags().GetFloat64("budget")
 
 	filterFlag := filterByValues.(*flags.DimensionFilterFlag)
 	filterData := filterFlag.Value()
@@ -312,6 +410,10 @@ func (f *ForecastCommandType) InputHandler() types.ForecastCommandLineInput {
 		PredictionIntervalLevel: predictionIntervalLevel,
 		Start:                   f.Cmd.Flags().Lookup("start").Value.String(),
 		End:                     f.Cmd.Flags().Lookup("end").Value.String(),
+		Summary:                 summaryConfig(f.Cmd),
+		PrintFormat:             strings.ToLower(printFormat),
+		Output:                  outputConfig(f.Cmd),
+		Budget:                  budget,
 	}
 }
 
@@ -329,10 +431,13 @@ func (f *ForecastCommandType) SynthesizeRequest(input types.ForecastCommandLineI
 	}, nil
 }
 
-func (f *ForecastCommandType) Execute(r types.GetCostForecastRequest) (
+func (f *ForecastCommandType) Execute(ctx context.Context, r types.GetCostForecastRequest) (
 	*costexplorer.GetCostForecastOutput, error) {
+	if err := Initialize(); err != nil {
+		return nil, err
+	}
 
-	res, err := srv.aws.GetCostForecast(context.TODO(), r)
+	res, err := srv.aws.GetCostForecast(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -346,6 +451,55 @@ func prepareResponseForRendering(res *costexplorer.
 	}
 }
 
+// forecastActuals fetches the costs of the month up to the forecast start,
+// with the filters of the forecast
+func forecastActuals(ctx context.Context, r types.GetCostForecastRequest) ([]types.ForecastActual, error) {
+	start, err := time.Parse("2006-01-02", r.Time.Start)
+	if err != nil {
+		return nil, ValidationError{Message: "Invalid forecast start date " + r.Time.Start}
+	}
+	monthStart := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !monthStart.Before(start) {
+		return nil, nil
+	}
+
+	granularity := "DAILY"
+	if r.Granularity == "MONTHLY" {
+		granularity = "MONTHLY"
+	}
+	allowList := map[string][]string{}
+	for _, d := range r.Filter.Dimensions {
+		allowList[d.Key] = d.Value
+	}
+
+	res, err := srv.aws.GetCostAndUsage(ctx, types.CostAndUsageRequestType{
+		Granularity:        granularity,
+		Time:               types.Time{Start: utils.Format(monthStart), End: r.Time.Start},
+		Metrics:            []string{"UnblendedCost"},
+		DimensionAllowList: allowList,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var actuals []types.ForecastActual
+	for _, result := range res.ResultsByTime {
+		cost, ok := result.Total["UnblendedCost"]
+		if !ok || result.TimePeriod == nil {
+			continue
+		}
+		actuals = append(actuals, types.ForecastActual{
+			TimePeriod: types.DateInterval{
+				Start: aws.ToString(result.TimePeriod.Start),
+				End:   aws.ToString(result.TimePeriod.End),
+			},
+			Amount: utils.ConvertToFloat(aws.ToString(cost.Amount)),
+			Unit:   aws.ToString(cost.Unit),
+		})
+	}
+	return actuals, nil
+}
+
 func filterList(r types.GetCostForecastRequest) []string {
 	var dimensions []string
 	for _, d := range r.Filter.Dimensions {
@@ -353,3 +507,20 @@ func filterList(r types.GetCostForecastRequest) []string {
 	}
 	return dimensions
 }
+
+// summaryWriter returns stderr when a csv or json report is written to
+// stdout, so that the summary does not break it
+func summaryWriter(printFormat string, output types.OutputConfig) io.Writer {
+	formats := utils.PrintFormats(printFormat)
+	if len(formats) > 1 {
+		// only the table is written to stdout
+		return os.Stdout
+	}
+	for _, format := range formats {
+		printType := utils.ToPrintWriterType(format)
+		if printType != types.Stdout && writer.WritesToStdout(printType, output) {
+			return os.Stderr
+		}
+	}
+	return os.Stdout
+}
@@ -3,10 +3,14 @@ package cli
 import (
 	"fmt"
 	"log/slog"
+	"os"
 
+	"github.com/cduggn/ccexplorer/internal/embedding"
 	"github.com/cduggn/ccexplorer/internal/mcp"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // mcpCommand creates the MCP command structure
@@ -19,6 +23,11 @@ to AI systems through a standardized stdio interface.
 
 The MCP server provides:
 - get_cost_and_usage tool for AWS Cost Explorer queries
+- compare_periods tool for period-over-period cost deltas
+- semantic_search_costs tool over stored cost documents, when
+  the --embeddingProvider and --vectorStore backend are configured
+- Policy guardrails: account and service allow-lists, date span limits,
+  call budgets, denied group-by dimensions and an audit log
 - Stdio transport for VSCode and other MCP clients`,
 	}
 
@@ -38,21 +47,127 @@ The server will run until the MCP client disconnects or the process is terminate
 		RunE: runMCPServe,
 	}
 
+	serveCmd.Flags().Int("maxResponseBytes", mcp.DefaultResponseByteBudget,
+		"Maximum size in bytes of a single tool response")
+	serveCmd.Flags().StringSlice("allowedAccounts", nil,
+		"Restrict queries to these linked account IDs")
+	serveCmd.Flags().StringSlice("allowedServices", nil,
+		"Restrict queries to these services")
+	serveCmd.Flags().String("maxSpan", "HOURLY=14",
+		"Maximum date span in days per granularity, e.g. HOURLY=14,DAILY=93,MONTHLY=366")
+	serveCmd.Flags().Int("maxCallsPerSession", 0,
+		"Maximum billable Cost Explorer calls, one per result page, per client session (0: unlimited)")
+	serveCmd.Flags().Int("maxCallsPerMinute", 0,
+		"Maximum billable Cost Explorer calls, one per result page, per minute (0: unlimited)")
+	serveCmd.Flags().StringSlice("deniedGroupBy", nil,
+		"Dimensions, or TAG:<key>, that may never be grouped by")
+	serveCmd.Flags().String("auditLog", "",
+		"Append a JSON audit record of every tool invocation to this file (default: stderr log)")
+	serveCmd.Flags().String("vectorStore", vectorstore.Pinecone,
+		"Vector store searched by semantic_search_costs. Valid values: local, pinecone, qdrant, pgvector")
+	addNamespaceFlag(serveCmd.Flags())
+	addEmbeddingFlags(serveCmd)
+
+	for key, flag := range mcpPolicyFlags {
+		_ = viper.BindPFlag(key, serveCmd.Flags().Lookup(flag))
+	}
+
 	mcpCmd.AddCommand(serveCmd)
 	return mcpCmd
 }
 
+// mcpPolicyFlags maps configuration keys to the serve flags that set them
+var mcpPolicyFlags = map[string]string{
+	"mcp.allowed_accounts":      "allowedAccounts",
+	"mcp.allowed_services":      "allowedServices",
+	"mcp.max_span":              "maxSpan",
+	"mcp.max_calls_per_session": "maxCallsPerSession",
+	"mcp.max_calls_per_minute":  "maxCallsPerMinute",
+	"mcp.denied_group_by":       "deniedGroupBy",
+	"mcp.audit_log":             "auditLog",
+}
+
+// mcpPolicy builds the server policy from flags or configuration
+func mcpPolicy() (mcp.Policy, error) {
+	maxSpan, err := mcp.ParseMaxSpan(viper.GetString("mcp.max_span"))
+	if err != nil {
+		return mcp.Policy{}, err
+	}
+
+	return mcp.Policy{
+		AllowedAccounts:    viper.GetStringSlice("mcp.allowed_accounts"),
+		AllowedServices:    viper.GetStringSlice("mcp.allowed_services"),
+		MaxSpan:            maxSpan,
+		MaxCallsPerSession: viper.GetInt("mcp.max_calls_per_session"),
+		MaxCallsPerMinute:  viper.GetInt("mcp.max_calls_per_minute"),
+		DeniedGroupBy:      viper.GetStringSlice("mcp.denied_group_by"),
+	}, nil
+}
+
+// mcpAuditLogger returns a JSON logger appending to path, or the default
+// logger when no path is configured
+func mcpAuditLogger(path string) (*slog.Logger, func(), error) {
+	if path == "" {
+		return slog.Default(), func() {}, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return slog.New(slog.NewJSONHandler(f, nil)), func() { _ = f.Close() }, nil
+}
+
+// mcpSearchOptions enables semantic search when the selected embedding
+// provider and vector store are configured
+func mcpSearchOptions(cmd *cobra.Command, backend, namespace string) []mcp.ServerOption {
+	embedder, err := embedding.New(embeddingConfig(cmd))
+	if err != nil {
+		slog.Info("semantic_search_costs disabled", "reason", err)
+		return nil
+	}
+
+	store, err := vectorstore.New(vectorStoreConfig(backend, namespace))
+	if err != nil {
+		slog.Info("semantic_search_costs disabled", "reason", err)
+		return nil
+	}
+
+	searcher := vectorstore.NewSearcher(embedder, store)
+	return []mcp.ServerOption{mcp.WithCostSearcher(searcher)}
+}
+
 // runMCPServe handles the MCP serve command
 func runMCPServe(cmd *cobra.Command, args []string) error {
 	slog.Info("Starting ccExplorer MCP server with stdio transport")
 
 	// Configure AWS service (reuse existing service initialization)
-	if srv == nil {
-		Initialize()
+	if err := Initialize(); err != nil {
+		return err
+	}
+
+	maxResponseBytes, _ := cmd.Flags().GetInt("maxResponseBytes")
+	backend, _ := cmd.Flags().GetString("vectorStore")
+	namespace, _ := cmd.Flags().GetString("namespace")
+
+	policy, err := mcpPolicy()
+	if err != nil {
+		return err
+	}
+
+	auditLogger, closeAuditLog, err := mcpAuditLogger(viper.GetString("mcp.audit_log"))
+	if err != nil {
+		return err
 	}
+	defer closeAuditLog()
 
 	// Create MCP server
-	mcpServer := mcp.NewServer(srv.aws)
+	opts := append([]mcp.ServerOption{
+		mcp.WithResponseByteBudget(maxResponseBytes),
+		mcp.WithPolicy(policy),
+		mcp.WithAuditLogger(auditLogger),
+	}, mcpSearchOptions(cmd, backend, namespace)...)
+	mcpServer := mcp.NewServer(srv.aws, opts...)
 
 	// Register tools
 	if err := mcpServer.RegisterTools(); err != nil {
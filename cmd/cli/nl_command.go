@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// nlCommand creates the nl command, which translates a natural-language
+// request into a get aws query
+func nlCommand() *cobra.Command {
+	nlCmd := &cobra.Command{
+		Use:   "nl <request>",
+		Short: "Run a cost query described in plain English",
+		Long: `Translate a natural-language request into a 'ccexplorer get aws' query with a
+chat-completions model, show the equivalent command line, and run it after
+confirmation.
+
+The query is validated with the same rules as the get aws flags, so a request
+the model cannot express as a valid query is rejected before Cost Explorer is
+called.`,
+		Example: NLExamples,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    runNL,
+	}
+
+	nlCmd.Flags().BoolP("yes", "y", false,
+		"Run the translated query without asking for confirmation")
+	nlCmd.Flags().Bool("dryRun", false,
+		"Only show the translated command line")
+	addChatFlags(nlCmd)
+
+	return nlCmd
+}
+
+func runNL(cmd *cobra.Command, args []string) error {
+	caller, err := assistant.NewFunctionCaller(chatConfig(cmd))
+	if err != nil {
+		return ValidationError{Message: "Invalid chat configuration. " + err.Error()}
+	}
+
+	query, err := assistant.NewTranslator(caller).Translate(cmd.Context(), strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+
+	flagArgs, err := costQueryFlags(query)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "Equivalent command:")
+	fmt.Fprintf(out, "  ccexplorer get aws %s\n", shellJoin(flagArgs))
+
+	costCommand := CostCommandType{Cmd: &cobra.Command{Use: "aws"}}
+	costCommand.DefineFlags()
+	if err := costCommand.Cmd.ParseFlags(flagArgs); err != nil {
+		return ValidationError{Message: "The translated query is invalid: " + err.Error()}
+	}
+	input, err := costCommand.InputHandler(ValidateInput)
+	if err != nil {
+		return ValidationError{Message: "The translated query is invalid: " + err.Error()}
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dryRun")
+	if dryRun {
+		return nil
+	}
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes && !confirm(cmd.InOrStdin(), out, "Run this query?") {
+		fmt.Fprintln(out, "Cancelled.")
+		return nil
+	}
+
+	return costCommand.Execute(cmd.Context(), costCommand.SynthesizeRequest(input))
+}
+
+// costQueryFlags converts translated query arguments into get aws flags
+func costQueryFlags(q types.CostQueryArguments) ([]string, error) {
+	var groupBy []string
+	for _, d := range q.GroupBy {
+		groupBy = append(groupBy, "DIMENSION="+strings.ToUpper(d))
+	}
+	for _, t := range q.GroupByTag {
+		groupBy = append(groupBy, "TAG="+t)
+	}
+	if len(groupBy) == 0 {
+		return nil, ValidationError{Message: "The translated query has no group by dimension or tag"}
+	}
+
+	var filterBy []string
+	dimensions := make([]string, 0, len(q.DimensionFilter))
+	for d := range q.DimensionFilter {
+		dimensions = append(dimensions, d)
+	}
+	sort.Strings(dimensions)
+	for _, d := range dimensions {
+		filterBy = append(filterBy, strings.ToUpper(d)+"="+q.DimensionFilter[d])
+	}
+	if q.TagFilterValue != "" {
+		filterBy = append(filterBy, "TAG="+q.TagFilterValue)
+	}
+
+	for _, v := range append(append([]string(nil), groupBy...), filterBy...) {
+		if strings.Count(v, "=") != 1 || strings.Contains(v, ",") {
+			return nil, ValidationError{Message: fmt.Sprintf(
+				"The translated query contains %q, which cannot be expressed as a flag value", v)}
+		}
+	}
+
+	args := []string{"-g", strings.Join(groupBy, ",")}
+	if len(filterBy) > 0 {
+		args = append(args, "-f", strings.Join(filterBy, ","))
+	}
+	args = append(args,
+		"-s", q.StartDate,
+		"-e", q.EndDate,
+		"-m", q.Granularity,
+		"-i", q.Metric,
+		"-p", q.PrintFormat)
+	if q.ExcludeDiscounts {
+		args = append(args, "-l")
+	}
+	if q.SortByDate {
+		args = append(args, "-d")
+	}
+	return args, nil
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9=,_./:-]+$`)
+
+// shellJoin quotes args so the printed command line can be pasted into a
+// POSIX shell
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if shellSafe.MatchString(a) {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
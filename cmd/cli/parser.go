@@ -1,10 +1,22 @@
 package cli
 
 import (
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/codec"
+	"github.com/cduggn/ccexplorer/internal/embedding"
 	"github.com/cduggn/ccexplorer/internal/flags"
 	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
+	"github.com/cduggn/ccexplorer/internal/writer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 )
 
 func (c *CostCommandType) ExtractGroupBySelections() ([]string, []string) {
@@ -87,15 +99,27 @@ func (c *CostCommandType) ExtractPrintPreferences() types.PrintOptions {
 	sortByDate, _ := c.Cmd.Flags().GetBool("sortByDate")
 	printOptions.IsSortByDate = sortByDate
 
-	openAIKey := viper.GetString("openai_api_key")
+	openAIKey := viper.GetString("openai.api_key")
 	printOptions.OpenAIKey = openAIKey
 
-	pineconeAPIKey := viper.GetString("PINECONE_API_KEY")
+	pineconeAPIKey := viper.GetString("pinecone.api_key")
 	printOptions.PineconeAPIKey = pineconeAPIKey
 
-	pineconeIndex := viper.GetString("PINECONE_INDEX")
+	pineconeIndex := viper.GetString("pinecone.index")
 	printOptions.PineconeIndex = pineconeIndex
 
+	backend := c.Cmd.Flags().Lookup("vectorStore").Value.String()
+	if slices.Contains(utils.PrintFormats(printOptions.Format), "pinecone") {
+		backend = vectorstore.Pinecone
+	}
+	namespace := c.Cmd.Flags().Lookup("namespace").Value.String()
+	printOptions.VectorStore = vectorStoreConfig(backend, namespace)
+	printOptions.Embedding = embeddingConfig(c.Cmd)
+	printOptions.Ingest = ingestConfig(c.Cmd)
+	printOptions.Documents = documentConfig(c.Cmd)
+	printOptions.Binning = binningConfig(c.Cmd)
+	printOptions.Summary = summaryConfig(c.Cmd)
+
 	excludeDiscounts, _ := c.Cmd.Flags().GetBool("excludeDiscounts")
 	printOptions.ExcludeDiscounts = excludeDiscounts
 
@@ -106,5 +130,269 @@ func (c *CostCommandType) ExtractPrintPreferences() types.PrintOptions {
 	metric := c.Cmd.Flags().Lookup("metric").Value.String()
 	printOptions.Metric = metric
 
+	printOptions.Output = outputConfig(c.Cmd)
+
+	layout, _ := c.Cmd.Flags().GetString("layout")
+	printOptions.Layout = strings.ToLower(layout)
+	printOptions.Chart = chartConfig(c.Cmd)
+
 	return printOptions
 }
+
+// vectorStoreConfig reads the settings of every vector store backend from
+// the environment or configuration file
+func vectorStoreConfig(backend, namespace string) types.VectorStoreConfig {
+	return types.VectorStoreConfig{
+		Backend:          strings.ToLower(backend),
+		Namespace:        namespace,
+		LocalPath:        stringOrDefault("vector_store.path", vectorstore.DefaultLocalPath),
+		PineconeIndex:    viper.GetString("pinecone.index"),
+		PineconeAPIKey:   viper.GetString("pinecone.api_key"),
+		QdrantURL:        viper.GetString("qdrant.url"),
+		QdrantAPIKey:     viper.GetString("qdrant.api_key"),
+		QdrantCollection: stringOrDefault("qdrant.collection", vectorstore.DefaultQdrantCollection),
+		PGVectorDSN:      viper.GetString("pgvector.dsn"),
+		PGVectorTable:    stringOrDefault("pgvector.table", vectorstore.DefaultPGVectorTable),
+	}
+}
+
+// addNamespaceFlag defines the flag selecting the vector store namespace
+func addNamespaceFlag(flags *pflag.FlagSet) {
+	flags.String("namespace", "",
+		"Vector store namespace, e.g. an account ID or a granularity (default: the default namespace)")
+}
+
+// addEmbeddingFlags defines the flags selecting the embedding provider and
+// model. Searches must use the model the stored vectors were created with.
+func addEmbeddingFlags(cmd *cobra.Command) {
+	cmd.Flags().String("embeddingProvider", embedding.OpenAI,
+		"Embedding provider. Valid values: openai, azure, local, hash")
+	cmd.Flags().String("embeddingModel", "",
+		"Embedding model (default: "+embedding.DefaultOpenAIModel+" for openai and azure)")
+	cmd.Flags().Int("embeddingDimensions", 0,
+		"Length of the embedding vectors, for models that support shortening them")
+}
+
+// embeddingConfig reads the embedding provider and model from the flags, and
+// the endpoint and credentials of the provider from the environment or
+// configuration file
+func embeddingConfig(cmd *cobra.Command) types.EmbeddingConfig {
+	provider, _ := cmd.Flags().GetString("embeddingProvider")
+	model, _ := cmd.Flags().GetString("embeddingModel")
+	dimensions, _ := cmd.Flags().GetInt("embeddingDimensions")
+
+	cfg := types.EmbeddingConfig{
+		Provider:   strings.ToLower(provider),
+		Model:      model,
+		Dimensions: dimensions,
+	}
+	switch cfg.Provider {
+	case embedding.Azure:
+		cfg.APIKey = viper.GetString("azure_openai.api_key")
+		cfg.BaseURL = viper.GetString("azure_openai.endpoint")
+		cfg.AzureDeployment = viper.GetString("azure_openai.embedding_deployment")
+		cfg.AzureAPIVersion = viper.GetString("azure_openai.api_version")
+	case embedding.Local:
+		cfg.APIKey = viper.GetString("embedding.api_key")
+		cfg.BaseURL = viper.GetString("embedding.base_url")
+	default:
+		cfg.APIKey = viper.GetString("openai.api_key")
+		cfg.BaseURL = viper.GetString("openai.base_url")
+	}
+	return cfg
+}
+
+// addIngestFlags defines the flags tuning how documents are embedded and
+// written to a vector store
+func addIngestFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("maxBatchTokens", vectorstore.DefaultMaxBatchTokens,
+		"Maximum estimated tokens per embedding request")
+	cmd.Flags().Int("embedConcurrency", vectorstore.DefaultEmbedConcurrency,
+		"Number of concurrent embedding requests")
+	cmd.Flags().Int("upsertConcurrency", vectorstore.DefaultUpsertConcurrency,
+		"Number of concurrent vector store upserts")
+	cmd.Flags().Int("upsertBatchSize", vectorstore.DefaultUpsertBatchSize,
+		"Documents per vector store upsert")
+	cmd.Flags().String("checkpointDir", defaultCheckpointDir(),
+		"Directory of the checkpoints used to resume failed loads (empty: no checkpoints)")
+	cmd.Flags().Bool("progress", true,
+		"Show a progress bar while loading documents into a vector store")
+}
+
+func ingestConfig(cmd *cobra.Command) types.IngestConfig {
+	maxBatchTokens, _ := cmd.Flags().GetInt("maxBatchTokens")
+	embedConcurrency, _ := cmd.Flags().GetInt("embedConcurrency")
+	upsertConcurrency, _ := cmd.Flags().GetInt("upsertConcurrency")
+	upsertBatchSize, _ := cmd.Flags().GetInt("upsertBatchSize")
+	checkpointDir, _ := cmd.Flags().GetString("checkpointDir")
+	progress, _ := cmd.Flags().GetBool("progress")
+
+	return types.IngestConfig{
+		MaxBatchTokens:    maxBatchTokens,
+		EmbedConcurrency:  embedConcurrency,
+		UpsertConcurrency: upsertConcurrency,
+		UpsertBatchSize:   upsertBatchSize,
+		CheckpointDir:     checkpointDir,
+		Progress:          progress,
+	}
+}
+
+// addDocumentFlags defines the flags selecting how cost results are chunked
+// into documents and how their text is rendered
+func addDocumentFlags(cmd *cobra.Command) {
+	cmd.Flags().String("documentStrategy", vectorstore.RowDocuments,
+		"How results are chunked into documents. Valid values: row (one per key per period), "+
+			"key (one per key per month), summary (one per period with its top contributors)")
+	cmd.Flags().String("documentTemplate", "",
+		"Go text/template rendering the embedding text of each document, or @file to read it from a file")
+	cmd.Flags().Int("topContributors", vectorstore.DefaultTopContributors,
+		"Number of top contributors described by summary documents")
+}
+
+func documentConfig(cmd *cobra.Command) types.DocumentConfig {
+	strategy, _ := cmd.Flags().GetString("documentStrategy")
+	template, _ := cmd.Flags().GetString("documentTemplate")
+	topContributors, _ := cmd.Flags().GetInt("topContributors")
+
+	return types.DocumentConfig{
+		Strategy:        strings.ToLower(strategy),
+		Template:        template,
+		TopContributors: topContributors,
+	}
+}
+
+// addBinningFlags defines the flags selecting how costs are sorted into the
+// bands that label cost documents and the Cost Band column
+func addBinningFlags(cmd *cobra.Command) {
+	cmd.Flags().String("binning", codec.FixedBins,
+		"How cost bands are computed. Valid values: fixed (--binEdges), log (powers of ten), "+
+			"quantile (--bins bands of about equal size)")
+	cmd.Flags().Float64Slice("binEdges", nil,
+		"Upper bounds of the fixed cost bands, e.g. 1,10,100,1000 (default 1,10,50,100,500,1000)")
+	cmd.Flags().Int("bins", codec.DefaultQuantileBins,
+		"Number of quantile cost bands")
+	cmd.Flags().String("currency", "",
+		"Currency of the cost band labels (defaults to the unit of the results)")
+	cmd.Flags().Bool("costBand", false,
+		"Add a Cost Band column to stdout and csv output")
+}
+
+func binningConfig(cmd *cobra.Command) types.BinningConfig {
+	strategy, _ := cmd.Flags().GetString("binning")
+	edges, _ := cmd.Flags().GetFloat64Slice("binEdges")
+	bins, _ := cmd.Flags().GetInt("bins")
+	currency, _ := cmd.Flags().GetString("currency")
+	showColumn, _ := cmd.Flags().GetBool("costBand")
+
+	return types.BinningConfig{
+		Strategy:   strings.ToLower(strategy),
+		Edges:      edges,
+		Bins:       bins,
+		Currency:   currency,
+		ShowColumn: showColumn,
+	}
+}
+
+// addOutputFlags defines the flags selecting the file a report is written to
+func addOutputFlags(cmd *cobra.Command) {
+	cmd.Flags().String("output", "",
+		"File the report is written to, or - for stdout. May contain {start}, {end}, {groupBy} "+
+			"and {timestamp} (default: a file named after the query in --outputDir; stdout for json)")
+	cmd.Flags().String("outputDir", "",
+		"Directory of generated file names and of a relative --output (default: ./writer)")
+}
+
+func outputConfig(cmd *cobra.Command) types.OutputConfig {
+	path, _ := cmd.Flags().GetString("output")
+	dir, _ := cmd.Flags().GetString("outputDir")
+	return types.OutputConfig{Path: path, Dir: dir}
+}
+
+// addChartFlags defines the flags selecting the charts of chart output
+func addChartFlags(cmd *cobra.Command) {
+	cmd.Flags().String("chartType", writer.ChartPie,
+		"Chart of --printFormat chart. Valid values: "+strings.Join(writer.ChartTypes, ", "))
+	cmd.Flags().Int("topN", writer.DefaultTopN,
+		"Number of keys charted, of keys charted under each key of treemap, sunburst and sankey charts, or of keys splitting the regions of map charts; the others are summed as Other")
+	cmd.Flags().String("assetsDir", "",
+		"Copy of the go-echarts assets host whose scripts are inlined in the chart, so that it opens offline (default: map charts inline the bundled scripts, other charts load them from the assets host)")
+}
+
+func chartConfig(cmd *cobra.Command) types.ChartConfig {
+	chartType, _ := cmd.Flags().GetString("chartType")
+	topN, _ := cmd.Flags().GetInt("topN")
+	assetsDir, _ := cmd.Flags().GetString("assetsDir")
+	return types.ChartConfig{Type: strings.ToLower(chartType), TopN: topN, AssetsDir: assetsDir}
+}
+
+// defaultCheckpointDir keeps checkpoints in the user cache directory
+func defaultCheckpointDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ccexplorer", "checkpoints")
+}
+
+// addChatFlags defines the flags selecting the chat-completions provider
+// and model
+func addChatFlags(cmd *cobra.Command) {
+	cmd.Flags().String("chatProvider", assistant.OpenAI,
+		"Chat-completions provider. Valid values: openai, azure, local")
+	cmd.Flags().String("chatModel", "",
+		"Chat model (default: "+assistant.DefaultChatModel+" for openai and azure)")
+}
+
+// chatConfig reads the chat provider and model from the flags, and the
+// endpoint and credentials of the provider from the environment or
+// configuration file
+func chatConfig(cmd *cobra.Command) types.ChatConfig {
+	provider, _ := cmd.Flags().GetString("chatProvider")
+	model, _ := cmd.Flags().GetString("chatModel")
+
+	cfg := types.ChatConfig{
+		Provider: strings.ToLower(provider),
+		Model:    model,
+	}
+	switch cfg.Provider {
+	case assistant.Azure:
+		cfg.APIKey = viper.GetString("azure_openai.api_key")
+		cfg.BaseURL = viper.GetString("azure_openai.endpoint")
+		cfg.AzureDeployment = viper.GetString("azure_openai.chat_deployment")
+		cfg.AzureAPIVersion = viper.GetString("azure_openai.api_version")
+	case assistant.Local:
+		cfg.APIKey = viper.GetString("chat.api_key")
+		cfg.BaseURL = viper.GetString("chat.base_url")
+	default:
+		cfg.APIKey = viper.GetString("openai.api_key")
+		cfg.BaseURL = viper.GetString("openai.base_url")
+	}
+	return cfg
+}
+
+// dateRangeFilter reads the optional startDate and endDate flags of commands
+// that search stored cost documents
+func dateRangeFilter(cmd *cobra.Command) (types.VectorQueryFilter, error) {
+	start, _ := cmd.Flags().GetString("startDate")
+	end, _ := cmd.Flags().GetString("endDate")
+	if (start == "") != (end == "") {
+		return types.VectorQueryFilter{}, ValidationError{Message: "startDate and endDate must be set together"}
+	}
+	if start != "" {
+		s, startErr := time.Parse("2006-01-02", start)
+		e, endErr := time.Parse("2006-01-02", end)
+		if startErr != nil || endErr != nil || !e.After(s) {
+			return types.VectorQueryFilter{}, ValidationError{
+				Message: "startDate and endDate must be YYYY-MM-DD dates, with endDate after startDate",
+			}
+		}
+	}
+	return types.VectorQueryFilter{Start: start, End: end}, nil
+}
+
+func stringOrDefault(key, fallback string) string {
+	if v := viper.GetString(key); v != "" {
+		return v
+	}
+	return fallback
+}
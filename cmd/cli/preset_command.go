@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/config"
+	"github.com/cduggn/ccexplorer/internal/preset"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// presetCommand creates the preset command, which lists, runs and saves
+// named get aws queries
+func presetCommand() *cobra.Command {
+	presetCmd := &cobra.Command{
+		Use:   "preset",
+		Short: "List, run and save named cost queries",
+		Long: `List, run and save named 'ccexplorer get aws' queries.
+
+Built-in presets cover common reports. User presets are kept in the presets
+section of the configuration file and take precedence over built-in presets
+of the same name. Flag values of a preset may refer to parameters as {name},
+set with --param name=value, and start and end dates may be expressions such
+as -30d, start-of-month or start-of-month-3m.`,
+		Example: PresetExamples,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the built-in and user presets",
+		Args:  cobra.NoArgs,
+		RunE:  runPresetList,
+	}
+
+	showCmd := &cobra.Command{
+		Use:   "show <name>",
+		Short: "Show the flags and parameters of a preset",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runPresetShow,
+	}
+
+	runCmd := &cobra.Command{
+		Use:   "run <name> [-- get aws flags]",
+		Short: "Run a preset; get aws flags after -- override those of the preset",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runPresetRun,
+	}
+	runCmd.Flags().StringArray("param", nil,
+		"Parameter of the preset as name=value; may be repeated")
+	runCmd.Flags().Bool("dryRun", false,
+		"Only show the resolved command line")
+
+	saveCmd := &cobra.Command{
+		Use:   "save <name> -- <get aws flags>",
+		Short: "Save get aws flags as a user preset in the configuration file",
+		Long: `Save get aws flags as a user preset in the presets section of --config or,
+by default, of $XDG_CONFIG_HOME/ccexplorer/config.yaml. The flags are stored
+as written, so parameters such as {service} and date expressions such as -30d
+are resolved each time the preset runs.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: runPresetSave,
+	}
+	saveCmd.Flags().String("description", "",
+		"Description shown by preset list")
+	saveCmd.Flags().StringArray("param", nil,
+		"Default value of a parameter as name=value; may be repeated")
+	saveCmd.Flags().Bool("force", false,
+		"Replace a user preset of the same name")
+
+	presetCmd.AddCommand(listCmd, showCmd, runCmd, saveCmd)
+	return presetCmd
+}
+
+// presets returns the user presets followed by the built-in presets
+func presets() []types.Preset {
+	all := config.Presets()
+	for _, p := range PresetList() {
+		all = append(all, preset.FromParams(p))
+	}
+	return all
+}
+
+func findPreset(name string) (types.Preset, error) {
+	p, ok := preset.Find(presets(), name)
+	if !ok {
+		return p, ValidationError{Message: fmt.Sprintf(
+			"Unknown preset %q; run 'ccexplorer preset list' to see the presets", name)}
+	}
+	return p, nil
+}
+
+func runPresetList(cmd *cobra.Command, args []string) error {
+	all := presets()
+	width := len("NAME")
+	for _, p := range all {
+		width = max(width, len(p.Name))
+	}
+
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "%-*s  %-8s  %s\n", width, "NAME", "SOURCE", "DESCRIPTION")
+	shadowed := map[string]bool{}
+	for _, p := range all {
+		if shadowed[p.Name] {
+			continue
+		}
+		shadowed[p.Name] = true
+		fmt.Fprintf(w, "%-*s  %-8s  %s\n", width, p.Name, presetSource(p), p.Description)
+	}
+	return nil
+}
+
+func presetSource(p types.Preset) string {
+	if p.BuiltIn {
+		return "built-in"
+	}
+	return "user"
+}
+
+func runPresetShow(cmd *cobra.Command, args []string) error {
+	p, err := findPreset(args[0])
+	if err != nil {
+		return err
+	}
+	flagArgs, err := presetFlagArgs(newCostCommand().Cmd.Flags(), p.Flags)
+	if err != nil {
+		return err
+	}
+	printPreset(cmd.OutOrStdout(), p, flagArgs)
+	return nil
+}
+
+func printPreset(w io.Writer, p types.Preset, flagArgs []string) {
+	fmt.Fprintf(w, "Name:         %s\n", p.Name)
+	fmt.Fprintf(w, "Source:       %s\n", presetSource(p))
+	fmt.Fprintf(w, "Description:  %s\n", p.Description)
+	if params := preset.Params(p); len(params) > 0 {
+		fmt.Fprintln(w, "Parameters:")
+		for _, name := range params {
+			value, ok := p.Params[name]
+			if !ok {
+				value = "(required)"
+			}
+			fmt.Fprintf(w, "  %-20s %s\n", name, value)
+		}
+	}
+	fmt.Fprintf(w, "Command:      ccexplorer get aws %s\n", shellJoin(flagArgs))
+}
+
+func runPresetRun(cmd *cobra.Command, args []string) error {
+	p, err := findPreset(args[0])
+	if err != nil {
+		return err
+	}
+	overrides, err := preset.Capture(newCostCommand().Cmd.Flags(), args[1:])
+	if err != nil {
+		return ValidationError{Message: "Invalid get aws flags: " + err.Error()}
+	}
+	pairs, _ := cmd.Flags().GetStringArray("param")
+	params, err := preset.ParseParams(pairs)
+	if err != nil {
+		return ValidationError{Message: err.Error()}
+	}
+	values, err := preset.Resolve(preset.Override(p, overrides), params, time.Now())
+	if err != nil {
+		return ValidationError{Message: err.Error()}
+	}
+
+	costCommand := newCostCommand()
+	fs := costCommand.Cmd.Flags()
+	flagArgs, err := presetFlagArgs(fs, values)
+	if err != nil {
+		return err
+	}
+	if err := costCommand.Cmd.ParseFlags(flagArgs); err != nil {
+		return ValidationError{Message: "Invalid preset " + p.Name + ": " + err.Error()}
+	}
+	if err := applyDefaults(fs, config.Defaults()); err != nil {
+		return err
+	}
+	if err := costCommand.Cmd.ValidateRequiredFlags(); err != nil {
+		return ValidationError{Message: "Invalid preset " + p.Name + ": " + err.Error()}
+	}
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Running: ccexplorer get aws %s\n", shellJoin(flagArgs))
+	input, err := costCommand.InputHandler(ValidateInput)
+	if err != nil {
+		return err
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dryRun")
+	if dryRun {
+		return nil
+	}
+	return costCommand.Execute(cmd.Context(), costCommand.SynthesizeRequest(input))
+}
+
+func runPresetSave(cmd *cobra.Command, args []string) error {
+	name := preset.Slug(args[0])
+	if name == "" {
+		return ValidationError{Message: fmt.Sprintf("Invalid preset name %q", args[0])}
+	}
+
+	flags, err := preset.Capture(newCostCommand().Cmd.Flags(), args[1:])
+	if err != nil {
+		return ValidationError{Message: "Invalid get aws flags: " + err.Error()}
+	}
+	if _, ok := flags["groupBy"]; !ok {
+		return ValidationError{Message: "A preset needs --groupBy"}
+	}
+
+	pairs, _ := cmd.Flags().GetStringArray("param")
+	defaults, err := preset.ParseParams(pairs)
+	if err != nil {
+		return ValidationError{Message: err.Error()}
+	}
+	description, _ := cmd.Flags().GetString("description")
+	p := types.Preset{Name: name, Description: description, Flags: flags, Params: defaults}
+
+	var unknown []string
+	for param := range defaults {
+		if !slices.Contains(preset.Params(p), param) {
+			unknown = append(unknown, param)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return ValidationError{Message: "The flags do not refer to the parameters " +
+			strings.Join(unknown, ", ") + "; write them as {name} in flag values"}
+	}
+
+	path, _ := cmd.Flags().GetString("config")
+	if path == "" {
+		if path, err = config.DefaultPath(); err != nil {
+			return types.Error{Msg: "Error locating configuration directory: " + err.Error()}
+		}
+	}
+	force, _ := cmd.Flags().GetBool("force")
+	if err := config.SavePreset(path, p, force); err != nil {
+		return ValidationError{Message: "Error saving preset: " + err.Error()}
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Preset %s saved to %s\n", name, path)
+	return nil
+}
+
+// newCostCommand returns a get aws command that is not part of the command
+// tree, for parsing the flags of a preset
+func newCostCommand() CostCommandType {
+	costCommand := CostCommandType{Cmd: &cobra.Command{Use: "aws"}}
+	costCommand.DefineFlags()
+	return costCommand
+}
+
+// presetFlagArgs converts preset flags, whose names may differ in case from
+// the get aws flags, into --name=value arguments sorted by name
+func presetFlagArgs(fs *pflag.FlagSet, values map[string]string) ([]string, error) {
+	names := map[string]string{}
+	fs.VisitAll(func(f *pflag.Flag) {
+		names[strings.ToLower(f.Name)] = f.Name
+	})
+
+	args := make([]string, 0, len(values))
+	for name, value := range values {
+		flagName, ok := names[strings.ToLower(name)]
+		if !ok {
+			return nil, ValidationError{Message: "get aws has no flag --" + name}
+		}
+		args = append(args, "--"+flagName+"="+value)
+	}
+	sort.Strings(args)
+	return args, nil
+}
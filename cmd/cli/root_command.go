@@ -1,38 +1,122 @@
 package cli
 
 import (
+	"context"
+	"strings"
+
 	"github.com/cduggn/ccexplorer/internal/config"
 	"github.com/common-nighthawk/go-figure"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+// skipConfigAnnotation marks commands that must run without loading the
+// configuration, e.g. because it may be missing or invalid
+const skipConfigAnnotation = "ccexplorer/skip-config"
+
 var (
 	rootCmd = &cobra.Command{
-		Use:     "ccexplorer",
-		Version: "0.7.5",
-		Short:   "A CLI tool to explore cloud costs and usage",
-		Long:    paintRootHeader(),
+		Use:               "ccexplorer",
+		Version:           "0.7.5",
+		Short:             "A CLI tool to explore cloud costs and usage",
+		Long:              paintRootHeader(),
+		PersistentPreRunE: loadConfig,
 	}
 )
 
+// rootConfigFlags maps configuration keys to the persistent flags that
+// override them
+var rootConfigFlags = map[string]string{
+	"aws.profile":      "awsProfile",
+	"aws.region":       "awsRegion",
+	"openai.api_key":   "openaiAPIKey",
+	"pinecone.index":   "pineconeIndex",
+	"pinecone.api_key": "pineconeAPIKey",
+}
+
 func RootCommand() *cobra.Command {
-	config.LoadConfigFunc(".")()
-	Initialize()
 	return rootCmd
 }
 
 func init() {
 	rootCmd.AddCommand(CostAndForecast())
 	rootCmd.AddCommand(mcpCommand())
-	_ = viper.BindPFlag("openai_api_key", rootCmd.PersistentFlags().Lookup(
-		"OPENAI_API_KEY"))
-	_ = viper.BindPFlag("aws_profile", rootCmd.PersistentFlags().Lookup(
-		"AWS_PROFILE"))
-	_ = viper.BindPFlag("PINECONE_INDEX", rootCmd.PersistentFlags().Lookup(
-		"PINECONE_INDEX"))
-	_ = viper.BindPFlag("PINECONE_API_KEY", rootCmd.PersistentFlags().Lookup(
-		"PINECONE_API_KEY"))
+	rootCmd.AddCommand(askCommand())
+	rootCmd.AddCommand(nlCommand())
+	rootCmd.AddCommand(vectorCommand())
+	rootCmd.AddCommand(configCommand())
+	rootCmd.AddCommand(presetCommand())
+
+	flags := rootCmd.PersistentFlags()
+	flags.String("config", "",
+		"Configuration file (default: $XDG_CONFIG_HOME/ccexplorer/config.yaml, then ./ccexplorer.yaml)")
+	flags.String("context", "",
+		"Named context of the configuration file to apply (default: current_context)")
+	flags.String("awsProfile", "", "AWS shared config profile")
+	flags.String("awsRegion", "", "AWS region")
+	flags.String("pineconeIndex", "", "Pinecone index URL")
+	flags.String("openaiAPIKey", "",
+		"OpenAI API key; prefer OPENAI_API_KEY or the configuration file, flags are visible to other processes")
+	flags.String("pineconeAPIKey", "",
+		"Pinecone API key; prefer PINECONE_API_KEY or the configuration file, flags are visible to other processes")
+
+	for key, flag := range rootConfigFlags {
+		_ = viper.BindPFlag(key, flags.Lookup(flag))
+	}
+}
+
+// loadedConfigKey is the context key of the configuration resolved by
+// loadConfig
+type loadedConfigKey struct{}
+
+// loadConfig reads the configuration files and applies their flag defaults
+// to the flags of cmd that are not set on the command line. The resolved
+// configuration is kept in the context of cmd.
+func loadConfig(cmd *cobra.Command, args []string) error {
+	if _, ok := cmd.Annotations[skipConfigAnnotation]; ok {
+		return nil
+	}
+
+	file, _ := cmd.Flags().GetString("config")
+	name, _ := cmd.Flags().GetString("context")
+	loaded, err := config.Load(file, name)
+	if err != nil {
+		return ValidationError{Message: "Invalid configuration. " + err.Error()}
+	}
+	cmd.SetContext(context.WithValue(cmd.Context(), loadedConfigKey{}, loaded))
+	return applyDefaults(cmd.Flags(), config.Defaults())
+}
+
+// loadedConfig returns the configuration loadConfig resolved for cmd
+func loadedConfig(cmd *cobra.Command) config.Loaded {
+	loaded, _ := cmd.Context().Value(loadedConfigKey{}).(config.Loaded)
+	return loaded
+}
+
+// applyDefaults makes the values of defaults, ignoring case, the defaults of
+// the flags of fs they name, unless they were set on the command line. The
+// flags are not marked as changed, so they still read as not given; a
+// default satisfies a required flag.
+func applyDefaults(fs *pflag.FlagSet, defaults map[string]string) error {
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		value, ok := defaults[strings.ToLower(f.Name)]
+		if !ok || f.Changed || err != nil {
+			return
+		}
+		if setErr := f.Value.Set(value); setErr != nil {
+			err = ValidationError{
+				Message: "Invalid default for --" + f.Name + " in configuration. " + setErr.Error(),
+			}
+			return
+		}
+		f.DefValue = f.Value.String()
+		if _, required := f.Annotations[cobra.BashCompOneRequiredFlag]; required {
+			f.Annotations[cobra.BashCompOneRequiredFlag] = []string{"false"}
+		}
+	})
+	return err
 }
 
 func paintRootHeader() string {
@@ -39,26 +39,134 @@ const (
   # Costs grouped by DAY by SERVICE and OPERATION and printed to CSV
   ccexplorer get aws -g DIMENSION=SERVICE,DIMENSION=OPERATION -l -e 2023-01-27 -s 2023-01-26 -m DAILY -p csv
 
+  # Costs grouped by SERVICE as CSV on stdout
+  ccexplorer get aws -g DIMENSION=SERVICE -p csv --output -
+
+  # Costs grouped by SERVICE as CSV, in a file named after the start date
+  ccexplorer get aws -g DIMENSION=SERVICE -p csv --outputDir reports --output 'services_{start}.csv'
+
+  # Costs grouped by SERVICE printed, and written as CSV, chart and JSON from a single query
+  ccexplorer get aws -g DIMENSION=SERVICE -p stdout,csv,chart,json --outputDir reports
+
+  # Monthly costs from January to June by SERVICE, one column per month
+  ccexplorer get aws -g DIMENSION=SERVICE -s 2024-01-01 -e 2024-07-01 -m MONTHLY --layout pivot
+
+  # The same report as an Excel workbook
+  ccexplorer get aws -g DIMENSION=SERVICE -s 2024-01-01 -e 2024-07-01 -m MONTHLY --layout pivot -p xlsx --output costs.xlsx
+
   # Costs grouped by MONTH by SERVICE and OPERATION and printed to chart
   ccexplorer get aws -g DIMENSION=SERVICE, DIMENSION=OPERATION -l -e 2023-01-27 -s 2023-01-26 -m MONTHLY -p chart
  
   # Costs grouped by MONTH by OPERATION and USAGE_TYPE and printed to chart
   ccexplorer get aws -g DIMENSION=OPERATION,DIMENSION=USAGE_TYPE -l -e 2023-01-27 -s 2023-01-26 -m MONTHLY -p chart
 
+  # Monthly costs of the 5 largest services as stacked bars, the others summed as Other
+  ccexplorer get aws -g DIMENSION=SERVICE -s 2024-01-01 -e 2024-07-01 -m MONTHLY -p chart --chartType bar --topN 5
+
+  # Stacked bar, cumulative, pareto and pie charts on one page
+  ccexplorer get aws -g DIMENSION=SERVICE -s 2024-01-01 -e 2024-07-01 -m MONTHLY -p chart --chartType dashboard
+
+  # Flows from accounts to services; click a key to see only its flows
+  ccexplorer get aws -g DIMENSION=LINKED_ACCOUNT,DIMENSION=SERVICE -s 2024-06-01 -e 2024-07-01 -p chart --chartType sankey
+
+  # Services and their usage types as a sunburst
+  ccexplorer get aws -g DIMENSION=SERVICE,DIMENSION=USAGE_TYPE -s 2024-06-01 -e 2024-07-01 -p chart --chartType sunburst
+
+  # Daily spend of 2024 as a calendar, with the top services of each day, and a calendar for each of the 3 largest
+  ccexplorer get aws -g DIMENSION=SERVICE -s 2024-01-01 -e 2025-01-01 -m DAILY -p chart --chartType calendar --topN 3
+
+  # Regions on a world map, split by the 5 largest services; the HTML opens offline
+  ccexplorer get aws -g DIMENSION=REGION,DIMENSION=SERVICE -s 2024-06-01 -e 2024-07-01 -p chart --chartType map --topN 5
+
   # All service costs grouped by SERVICE and OPERATION and sorted in descending order by date
   ccexplorer get aws -g DIMENSION=SERVICE,DIMENSION=OPERATION -s 2023-01-01 -e 2023-02-10 -l -d
 
+  # Costs grouped by SERVICE as JSON, filtered with jq
+  ccexplorer get aws -g DIMENSION=SERVICE -p json | jq '.services[0]'
+
+  # Costs grouped by SERVICE as one JSON row per line, written to a file
+  ccexplorer get aws -g DIMENSION=SERVICE -m DAILY -p ndjson --output costs.ndjson
+
 `
 	ForecastExamples = `
   # Service forecast for the next 30 days
-  ccexplorer get aws forecast -p 95 -g MONTHLY
+  ccexplorer get aws forecast -l 95 -g MONTHLY
 
   # S3 cost forecast for the next 30 days
-  ccexplorer get aws forecast -f SERVICE="Amazon Simple Storage Service"  -p 95 -g MONTHLY
+  ccexplorer get aws forecast -f SERVICE="Amazon Simple Storage Service"  -l 95 -g MONTHLY
   
   # DynamoDB cost forecast for PutObject operations for the next 30 days
-  ccexplorer get aws forecast -f SERVICE="Amazon DynamoDB",OPERATION="CommittedThroughput"  -p 95 -g MONTHLY
+  ccexplorer get aws forecast -f SERVICE="Amazon DynamoDB",OPERATION="CommittedThroughput"  -l 95 -g MONTHLY
+
+  # Service forecast as JSON
+  ccexplorer get aws forecast -g MONTHLY -p json
+
+  # Chart of the month to date and the forecast, with a monthly budget line
+  ccexplorer get aws forecast -g MONTHLY -p chart --budget 5000
   
+`
+	AskExamples = `
+  # Load monthly service costs into the local vector store, then ask about them
+  ccexplorer get aws -g DIMENSION=SERVICE,DIMENSION=USAGE_TYPE -s 2024-01-01 -p vector
+  ccexplorer ask "why did S3 go up in March?"
+
+  # Only consider documents from the first quarter
+  ccexplorer ask "which service grew fastest?" -s 2024-01-01 -e 2024-04-01 --topK 10
+
+  # Answer with a local model served by Ollama
+  ccexplorer ask "what drove EC2 spend?" --chatProvider local --chatModel llama3.1 \
+    --embeddingProvider local --embeddingModel nomic-embed-text
+`
+	NLExamples = `
+  # Translate, confirm and run
+  ccexplorer nl "daily EC2 spend by region for the last two weeks excluding credits"
+
+  # Only show the equivalent get aws command
+  ccexplorer nl "monthly S3 costs by usage type this year" --dryRun
+
+  # Run without confirmation, e.g. in scripts
+  ccexplorer nl "top services last month as csv" -y
+`
+	VectorExamples = `
+  # Load daily costs into their own namespace of the local vector store
+  ccexplorer get aws -g DIMENSION=SERVICE -m DAILY -s 2024-03-01 -p vector --namespace DAILY
+
+  # Show the documents per namespace
+  ccexplorer vector stats
+
+  # Find the documents most similar to a text
+  ccexplorer vector query "S3 request charges" --namespace DAILY --granularity DAILY --topK 5
+
+  # Purge a stale month from Pinecone before re-ingesting it
+  ccexplorer vector delete --vectorStore pinecone -s 2024-01-01 -e 2024-02-01
+
+  # Delete documents by ID, or a whole namespace without confirmation
+  ccexplorer vector delete --ids 2024-01-01-ec2,2024-01-01-s3
+  ccexplorer vector delete --namespace 123456789012 --all -y
+`
+	ConfigExamples = `
+  # Write an annotated configuration file to $XDG_CONFIG_HOME/ccexplorer/config.yaml
+  ccexplorer config init
+
+  # Check it, then show the effective settings of the prod context
+  ccexplorer config validate
+  ccexplorer config view --context prod
+
+  # Use the AWS profile, region and defaults of the prod context
+  ccexplorer get aws --context prod -s 2024-01-01
+`
+	PresetExamples = `
+  # Show the presets, then run a built-in one
+  ccexplorer preset list
+  ccexplorer preset run s3-costs-grouped-by-operation
+
+  # Save a parameterized report of the last 30 days, then run it for another service
+  ccexplorer preset save ops-by-service --description "Operations of a service" \
+    --param service="Amazon EC2" -- -g DIMENSION=OPERATION -f SERVICE={service} -s -30d -m DAILY
+  ccexplorer preset run ops-by-service --param service="Amazon DynamoDB"
+
+  # Override flags of a preset
+  ccexplorer preset run ops-by-service -- -p csv -s start-of-month
 `
 )
 
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/cobra"
+)
+
+// addSummaryFlags defines the flags requesting an executive summary of a
+// report, and the chat flags selecting the model that writes it
+func addSummaryFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("summarize", false,
+		"Add a short narrative of the report written by a chat model; all figures are computed locally")
+	cmd.Flags().String("summaryFile", "",
+		"Write the summary to this Markdown file instead of below the report")
+	addChatFlags(cmd)
+}
+
+func summaryConfig(cmd *cobra.Command) types.SummaryConfig {
+	enabled, _ := cmd.Flags().GetBool("summarize")
+	file, _ := cmd.Flags().GetString("summaryFile")
+
+	cfg := types.SummaryConfig{Enabled: enabled || file != "", File: file}
+	if cfg.Enabled {
+		cfg.Chat = chatConfig(cmd)
+	}
+	return cfg
+}
+
+// writeSummary asks the chat model of cfg for a narrative of facts and
+// prints it to out, or writes it to cfg.File
+func writeSummary(ctx context.Context, out io.Writer, cfg types.SummaryConfig,
+	title string, facts []types.Fact) error {
+
+	chat, err := assistant.NewChat(cfg.Chat)
+	if err != nil {
+		return ValidationError{Message: "Invalid chat configuration. " + err.Error()}
+	}
+
+	summary, err := assistant.NewSummarizer(chat).Summarize(ctx, title, facts)
+	if err != nil {
+		return types.Error{Msg: "Error summarizing report: " + err.Error()}
+	}
+
+	if cfg.File == "" {
+		fmt.Fprintf(out, "\nSummary\n\n%s\n", summary.Text)
+		return nil
+	}
+	if err := os.WriteFile(cfg.File, []byte(assistant.SummaryMarkdown(summary)), 0644); err != nil {
+		return types.Error{Msg: "Error writing summary: " + err.Error()}
+	}
+	fmt.Fprintf(out, "\nSummary written to %s\n", cfg.File)
+	return nil
+}
@@ -1,7 +1,15 @@
 package cli
 
 import (
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/codec"
+	"github.com/cduggn/ccexplorer/internal/embedding"
 	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
+	"github.com/cduggn/ccexplorer/internal/writer"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -22,28 +30,89 @@ func ValidateInput(input types.CommandLineInput) error {
 		}
 	}
 
-	isValidPrintFormat := IsValidPrintFormat(input.PrintFormat)
-	if !isValidPrintFormat {
+	formats := utils.PrintFormats(input.PrintFormat)
+	if err := ValidatePrintFormats(formats, input.Output); err != nil {
+		return err
+	}
+
+	if !IsValidLayout(input.Layout) {
+		return ValidationError{
+			Message: "Invalid layout. Valid values are: rows, pivot",
+		}
+	}
+
+	if input.Chart.Type != "" && !slices.Contains(writer.ChartTypes, input.Chart.Type) {
 		return ValidationError{
-			Message: "Invalid print format. " +
-				"Please use one of the following: stdout, csv, chart, pinecone",
+			Message: "Invalid chart type. Valid values are: " + strings.Join(writer.ChartTypes, ", "),
+		}
+	}
+
+	if input.Chart.Type == writer.ChartSankey && len(input.GroupByDimension)+len(input.GroupByTag) < 2 {
+		return ValidationError{
+			Message: "The sankey chart needs at least two group by keys, such as -g DIMENSION=SERVICE,DIMENSION=USAGE_TYPE",
+		}
+	}
+
+	if input.Chart.Type == writer.ChartMap && !slices.Contains(input.GroupByDimension, writer.RegionLabel) {
+		return ValidationError{
+			Message: "The map chart needs the regions as a group by key, such as -g DIMENSION=REGION,DIMENSION=SERVICE",
+		}
+	}
+
+	if input.Chart.Type == writer.ChartCalendar {
+		if err := validateCalendar(input); err != nil {
+			return err
 		}
 	}
 
-	if input.PrintFormat == "pinecone" && input.OpenAIAPIKey == "" {
+	if err := codec.Validate(input.Binning); err != nil {
 		return ValidationError{
-			Message: "OpenAI API key not set. " +
-				"Please set the OPENAI_API_KEY in the config file or environment variable",
+			Message: "Invalid cost band configuration. " + err.Error(),
+		}
+	}
+
+	if input.Summary.Enabled {
+		if err := assistant.ValidateChat(input.Summary.Chat); err != nil {
+			return ValidationError{
+				Message: "Invalid chat configuration. " + err.Error(),
+			}
 		}
 	}
 
-	if input.PrintFormat == "pinecone" && input.OpenAIAPIKey != "" {
+	isVectorFormat := slices.Contains(formats, "pinecone") || slices.Contains(formats, "vector")
+
+	if isVectorFormat {
+		if err := embedding.Validate(input.Embedding); err != nil {
+			return ValidationError{
+				Message: "Invalid embedding configuration. " + err.Error(),
+			}
+		}
 		if HasAccountInformation(input.GroupByDimension) {
 			return ValidationError{
-				Message: "Cannot use Pinecone with account information. " +
+				Message: "Cannot use a vector store with account information. " +
 					"Please remove the account dimension",
 			}
 		}
+		if err := vectorstore.Validate(input.VectorStore); err != nil {
+			return ValidationError{
+				Message: "Invalid vector store configuration. " + err.Error(),
+			}
+		}
+		ingest := input.Ingest
+		if ingest.MaxBatchTokens <= 0 || ingest.EmbedConcurrency <= 0 ||
+			ingest.UpsertConcurrency <= 0 || ingest.UpsertBatchSize <= 0 {
+			return ValidationError{
+				Message: "maxBatchTokens, embedConcurrency, upsertConcurrency and upsertBatchSize must be positive",
+			}
+		}
+		if input.Documents.TopContributors <= 0 {
+			return ValidationError{Message: "topContributors must be positive"}
+		}
+		if err := vectorstore.ValidateDocuments(input.Documents); err != nil {
+			return ValidationError{
+				Message: "Invalid document configuration. " + err.Error(),
+			}
+		}
 	}
 
 	IsValid := IsValidMetric(input.Metrics[0])
@@ -57,6 +126,26 @@ func ValidateInput(input types.CommandLineInput) error {
 	return nil
 }
 
+// validateCalendar checks that a calendar chart has one value per day over
+// at most a year
+func validateCalendar(input types.CommandLineInput) error {
+	if input.Interval != "DAILY" {
+		return ValidationError{Message: "The calendar chart needs DAILY granularity; add -m DAILY"}
+	}
+	start, err := time.Parse("2006-01-02", input.Start)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse("2006-01-02", input.End)
+	if err != nil {
+		return nil
+	}
+	if end.Sub(start) > writer.MaxCalendarDays*24*time.Hour {
+		return ValidationError{Message: "The calendar chart covers at most a year; use an earlier end date"}
+	}
+	return nil
+}
+
 func ValidateStartDate(startDate string) error {
 	if startDate == "" {
 		return ValidationError{
@@ -100,8 +189,50 @@ func ValidateEndDate(endDate, startDate string) error {
 	return nil
 }
 
+// ValidatePrintFormats checks the formats of a comma-separated --printFormat.
+// A report is written once per format, so formats may not repeat, and
+// --output, which names a single file, needs a single format.
+func ValidatePrintFormats(formats []string, output types.OutputConfig) error {
+	if len(formats) == 0 {
+		return ValidationError{Message: "Invalid print format. Please give at least one format"}
+	}
+	seen := map[types.PrintWriterType]string{}
+	for _, f := range formats {
+		if !IsValidPrintFormat(f) {
+			return ValidationError{
+				Message: "Invalid print format " + f + ". " +
+					"Please use one of the following: stdout, csv, chart, json, ndjson, markdown, xlsx, pinecone, vector",
+			}
+		}
+		printType := utils.ToPrintWriterType(f)
+		if f == "vector" {
+			// pinecone is the pinecone vector store
+			printType = types.Pinecone
+		}
+		if previous, ok := seen[printType]; ok {
+			return ValidationError{Message: "Print formats " + previous + " and " + f + " write the same report"}
+		}
+		seen[printType] = f
+	}
+	if len(formats) > 1 && output.Path != "" {
+		return ValidationError{
+			Message: "--output names a single file; use --outputDir with several print formats",
+		}
+	}
+	return nil
+}
+
 func IsValidPrintFormat(f string) bool {
-	return f == "stdout" || f == "csv" || f == "chart" || f == "pinecone"
+	return f == "stdout" || f == "csv" || f == "chart" || f == "json" ||
+		f == "ndjson" || f == "markdown" || f == "xlsx" || f == "pinecone" || f == "vector"
+}
+
+func IsValidLayout(l string) bool {
+	return l == writer.LayoutRows || l == writer.LayoutPivot
+}
+
+func IsValidForecastPrintFormat(f string) bool {
+	return f == "stdout" || f == "csv" || f == "chart" || f == "json" || f == "ndjson"
 }
 
 func IsValidGranularity(g string) bool {
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/assistant"
+	"github.com/cduggn/ccexplorer/internal/embedding"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
+	"github.com/spf13/cobra"
+)
+
+const defaultVectorQueryTopK = 10
+
+// vectorCommand creates the vector command, which inspects and maintains
+// the cost documents written with -p vector or -p pinecone
+func vectorCommand() *cobra.Command {
+	vectorCmd := &cobra.Command{
+		Use:   "vector",
+		Short: "Query, delete and inspect stored cost documents",
+		Long: `Query, delete and inspect the cost documents stored in a vector store by
+'ccexplorer get aws -p vector' or '-p pinecone'.
+
+Every subcommand works on a single namespace of the store, selected with
+--namespace. Namespaces partition a store, e.g. per account or per
+granularity; without --namespace the default namespace is used.`,
+		Example: VectorExamples,
+	}
+
+	vectorCmd.PersistentFlags().String("vectorStore", vectorstore.Local,
+		"Vector store holding the cost documents. Valid values: local, pinecone, qdrant, pgvector")
+	addNamespaceFlag(vectorCmd.PersistentFlags())
+
+	queryCmd := &cobra.Command{
+		Use:   "query <text>",
+		Short: "Find the stored cost documents most similar to a text",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runVectorQuery,
+	}
+	queryCmd.Flags().Int("topK", defaultVectorQueryTopK,
+		"Number of documents to return")
+	addVectorFilterFlags(queryCmd)
+	addEmbeddingFlags(queryCmd)
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete",
+		Short: "Delete stored cost documents by ID, filter or date range",
+		Long: `Delete stored cost documents of the selected namespace. Documents are selected
+by --ids, by a filter on their period, group by dimensions and granularity,
+or with --all. On Pinecone, which only deletes by metadata filter on pod-based
+indexes, the documents matching a filter are looked up and deleted by ID, so
+filtered deletes also work on serverless indexes.`,
+		RunE: runVectorDelete,
+	}
+	deleteCmd.Flags().StringSlice("ids", nil,
+		"IDs of the documents to delete")
+	deleteCmd.Flags().Bool("all", false,
+		"Delete every document of the namespace")
+	deleteCmd.Flags().BoolP("yes", "y", false,
+		"Delete without asking for confirmation")
+	addVectorFilterFlags(deleteCmd)
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show the number and dimension of stored cost documents",
+		Args:  cobra.NoArgs,
+		RunE:  runVectorStats,
+	}
+
+	vectorCmd.AddCommand(queryCmd, deleteCmd, statsCmd)
+	return vectorCmd
+}
+
+// addVectorFilterFlags defines the flags that narrow the selected documents
+func addVectorFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP("startDate", "s", "",
+		"Only select documents whose period starts on or after this date")
+	cmd.Flags().StringP("endDate", "e", "",
+		"Only select documents whose period starts before this date")
+	cmd.Flags().StringSlice("dimensions", nil,
+		"Only select documents grouped by exactly these dimensions, e.g. SERVICE,USAGE_TYPE")
+	cmd.Flags().String("granularity", "",
+		"Only select documents of this granularity. Valid values: DAILY, MONTHLY, HOURLY")
+	cmd.Flags().Float64("minCost", 0,
+		"Only select documents costing at least this amount")
+}
+
+// vectorFilter reads the filter flags defined by addVectorFilterFlags
+func vectorFilter(cmd *cobra.Command) (types.VectorQueryFilter, error) {
+	filter, err := dateRangeFilter(cmd)
+	if err != nil {
+		return filter, err
+	}
+
+	dimensions, _ := cmd.Flags().GetStringSlice("dimensions")
+	for _, d := range dimensions {
+		filter.Dimensions = append(filter.Dimensions, strings.ToUpper(d))
+	}
+
+	filter.MinCost, _ = cmd.Flags().GetFloat64("minCost")
+	if filter.MinCost < 0 {
+		return filter, ValidationError{Message: "minCost must not be negative"}
+	}
+
+	granularity, _ := cmd.Flags().GetString("granularity")
+	filter.Granularity = strings.ToUpper(granularity)
+	switch filter.Granularity {
+	case "", "DAILY", "MONTHLY", "HOURLY":
+	default:
+		return filter, ValidationError{Message: "granularity must be one of DAILY, MONTHLY, HOURLY"}
+	}
+	return filter, nil
+}
+
+// vectorStore opens the vector store and namespace selected by the
+// persistent flags of the vector command
+func vectorStore(cmd *cobra.Command) (ports.VectorStore, types.VectorStoreConfig, error) {
+	backend, _ := cmd.Flags().GetString("vectorStore")
+	namespace, _ := cmd.Flags().GetString("namespace")
+	cfg := vectorStoreConfig(backend, namespace)
+
+	store, err := vectorstore.New(cfg)
+	if err != nil {
+		return nil, cfg, ValidationError{Message: "Invalid vector store configuration. " + err.Error()}
+	}
+	return store, cfg, nil
+}
+
+func runVectorQuery(cmd *cobra.Command, args []string) error {
+	topK, _ := cmd.Flags().GetInt("topK")
+	if topK <= 0 || topK > 100 {
+		return ValidationError{Message: "topK must be between 1 and 100"}
+	}
+	filter, err := vectorFilter(cmd)
+	if err != nil {
+		return err
+	}
+
+	embedder, err := embedding.New(embeddingConfig(cmd))
+	if err != nil {
+		return ValidationError{Message: "Invalid embedding configuration. " + err.Error()}
+	}
+	store, _, err := vectorStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	matches, err := vectorstore.NewSearcher(embedder, store).
+		Search(cmd.Context(), strings.Join(args, " "), topK, filter)
+	if err != nil {
+		return err
+	}
+
+	printMatches(cmd.OutOrStdout(), matches)
+	return nil
+}
+
+func runVectorDelete(cmd *cobra.Command, args []string) error {
+	filter, err := vectorFilter(cmd)
+	if err != nil {
+		return err
+	}
+	ids, _ := cmd.Flags().GetStringSlice("ids")
+	all, _ := cmd.Flags().GetBool("all")
+
+	req := types.VectorDeleteRequest{IDs: ids, Filter: filter, All: all}
+	selections := 0
+	for _, set := range []bool{len(ids) > 0, !req.Filter.IsEmpty(), all} {
+		if set {
+			selections++
+		}
+	}
+	if selections != 1 {
+		return ValidationError{
+			Message: "Select the documents to delete with exactly one of --ids, a filter or --all",
+		}
+	}
+
+	store, cfg, err := vectorStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	yes, _ := cmd.Flags().GetBool("yes")
+	prompt := fmt.Sprintf("Delete the selected documents from namespace %s of the %s store?",
+		namespaceName(cfg.Namespace), cfg.Backend)
+	if !yes && !confirm(cmd.InOrStdin(), out, prompt) {
+		fmt.Fprintln(out, "Cancelled.")
+		return nil
+	}
+
+	if err := store.Delete(cmd.Context(), req); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Deleted.")
+	return nil
+}
+
+func runVectorStats(cmd *cobra.Command, args []string) error {
+	store, _, err := vectorStore(cmd)
+	if err != nil {
+		return err
+	}
+
+	stats, err := store.Stats(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	printStats(cmd.OutOrStdout(), stats)
+	return nil
+}
+
+func printMatches(w io.Writer, matches []types.VectorQueryMatch) {
+	if len(matches) == 0 {
+		fmt.Fprintln(w, "No stored cost documents match.")
+		return
+	}
+	for _, m := range matches {
+		fmt.Fprintf(w, "%.4f  %s\n        %s\n", m.Score, m.ID, assistant.Describe(m))
+	}
+}
+
+func printStats(w io.Writer, stats types.VectorStoreStats) {
+	fmt.Fprintf(w, "Backend:    %s\n", stats.Backend)
+	fmt.Fprintf(w, "Namespace:  %s\n", namespaceName(stats.Namespace))
+	fmt.Fprintf(w, "Documents:  %d\n", stats.Count)
+	fmt.Fprintf(w, "Dimension:  %d\n", stats.Dimension)
+	if len(stats.Namespaces) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(stats.Namespaces))
+	for name := range stats.Namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Namespaces:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %d\n", namespaceName(name), stats.Namespaces[name])
+	}
+}
+
+func namespaceName(namespace string) string {
+	if namespace == "" {
+		return "(default)"
+	}
+	return namespace
+}
@@ -0,0 +1,135 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const DefaultTopK = 5
+
+const systemPrompt = `You are a cloud cost analyst answering questions about AWS spend.
+Answer only from the cost documents provided by the user. Each document is
+labelled [n]; cite the documents that support every statement, for example
+"S3 rose to 412.10 USD in March [2]". Mention the periods and the services or
+other keys you are comparing. If the documents do not contain the answer, say
+so and describe what data would be needed instead of guessing.`
+
+// Assistant answers questions about cost history stored in a vector store
+type Assistant struct {
+	Searcher ports.CostSearcher
+	Chat     ports.ChatModel
+}
+
+func New(searcher ports.CostSearcher, chat ports.ChatModel) *Assistant {
+	return &Assistant{
+		Searcher: searcher,
+		Chat:     chat,
+	}
+}
+
+// Ask retrieves the topK cost documents most similar to question and asks
+// the chat model to answer from them
+func (a *Assistant) Ask(ctx context.Context, question string, topK int,
+	filter types.VectorQueryFilter) (types.Answer, error) {
+
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return types.Answer{}, fmt.Errorf("question must not be empty")
+	}
+	if topK <= 0 {
+		topK = DefaultTopK
+	}
+
+	matches, err := a.Searcher.Search(ctx, question, topK, filter)
+	if err != nil {
+		return types.Answer{}, fmt.Errorf("failed to retrieve cost documents: %w", err)
+	}
+
+	answer := types.Answer{
+		Question:  question,
+		Model:     a.Chat.Model(),
+		Citations: Cite(matches),
+	}
+	if len(matches) == 0 {
+		answer.Text = "No stored cost documents match the question. " +
+			"Load cost history with `ccexplorer get aws -p vector` first, " +
+			"or widen the date range."
+		return answer, nil
+	}
+
+	answer.Text, err = a.Chat.Complete(ctx, BuildPrompt(question, answer.Citations))
+	if err != nil {
+		return types.Answer{}, err
+	}
+	return answer, nil
+}
+
+// Cite labels matches [1], [2], ... in retrieval order
+func Cite(matches []types.VectorQueryMatch) []types.Citation {
+	citations := make([]types.Citation, len(matches))
+	for i, m := range matches {
+		citations[i] = types.Citation{
+			Label: fmt.Sprintf("[%d]", i+1),
+			Match: m,
+		}
+	}
+	return citations
+}
+
+// BuildPrompt grounds question in the cited cost documents
+func BuildPrompt(question string, citations []types.Citation) []types.ChatMessage {
+	var b strings.Builder
+	b.WriteString("Cost documents:\n\n")
+	for _, c := range citations {
+		fmt.Fprintf(&b, "%s %s\n%s\n\n", c.Label, Describe(c.Match), c.Match.PageContent)
+	}
+	fmt.Fprintf(&b, "Question: %s", question)
+
+	return []types.ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: b.String()},
+	}
+}
+
+// Describe summarizes the period, keys and cost of a stored cost document in
+// one line
+func Describe(m types.VectorQueryMatch) string {
+	parts := []string{fmt.Sprintf("period %s to %s", m.Metadata.StartDate, m.Metadata.EndDate)}
+	if keys := groupKeys(m.Metadata); keys != "" {
+		parts = append(parts, keys)
+	}
+	if m.Metadata.Cost != "" {
+		parts = append(parts, "cost "+m.Metadata.Cost)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// groupKeys pairs the group-by names with the key values of a document, e.g.
+// SERVICE=Amazon S3. Unpaired keys are listed as they are.
+func groupKeys(m types.VectorStoreItemMetadata) string {
+	keys := splitList(m.Keys)
+	names := append(splitList(m.Dimensions), splitList(m.Tags)...)
+	if len(keys) == 0 {
+		return ""
+	}
+	if len(names) != len(keys) {
+		return strings.Join(keys, ", ")
+	}
+
+	pairs := make([]string, len(keys))
+	for i := range keys {
+		pairs[i] = names[i] + "=" + keys[i]
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,133 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSearcher struct {
+	matches []types.VectorQueryMatch
+	topK    int
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, text string, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+	f.topK = topK
+	return f.matches, nil
+}
+
+type fakeChat struct {
+	messages []types.ChatMessage
+}
+
+func (f *fakeChat) Complete(ctx context.Context, messages []types.ChatMessage) (string, error) {
+	f.messages = messages
+	return "S3 rose in March [2].", nil
+}
+
+func (f *fakeChat) Model() string {
+	return "fake-chat"
+}
+
+func s3Match(start, end, cost string) types.VectorQueryMatch {
+	return types.VectorQueryMatch{
+		ID:          "s3-" + start,
+		PageContent: "Amazon S3 costs for " + start,
+		Metadata: types.VectorStoreItemMetadata{
+			StartDate:  start,
+			EndDate:    end,
+			Dimensions: "SERVICE,USAGE_TYPE",
+			Keys:       "Amazon S3,Requests-Tier1",
+			Cost:       cost,
+		},
+	}
+}
+
+func TestAsk(t *testing.T) {
+	searcher := &fakeSearcher{matches: []types.VectorQueryMatch{
+		s3Match("2024-02-01", "2024-03-01", "100.00"),
+		s3Match("2024-03-01", "2024-04-01", "412.10"),
+	}}
+	chat := &fakeChat{}
+
+	answer, err := New(searcher, chat).Ask(context.Background(),
+		" why did S3 go up in March? ", 0, types.VectorQueryFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultTopK, searcher.topK)
+	assert.Equal(t, "why did S3 go up in March?", answer.Question)
+	assert.Equal(t, "S3 rose in March [2].", answer.Text)
+	assert.Equal(t, "fake-chat", answer.Model)
+	require.Len(t, answer.Citations, 2)
+	assert.Equal(t, "[2]", answer.Citations[1].Label)
+
+	require.Len(t, chat.messages, 2)
+	assert.Equal(t, "system", chat.messages[0].Role)
+	prompt := chat.messages[1].Content
+	assert.Contains(t, prompt, "[2] period 2024-03-01 to 2024-04-01, "+
+		"SERVICE=Amazon S3, USAGE_TYPE=Requests-Tier1, cost 412.10\nAmazon S3 costs for 2024-03-01")
+	assert.Contains(t, prompt, "Question: why did S3 go up in March?")
+}
+
+func TestAskWithoutDocuments(t *testing.T) {
+	chat := &fakeChat{}
+	answer, err := New(&fakeSearcher{}, chat).Ask(context.Background(),
+		"why did S3 go up?", 3, types.VectorQueryFilter{})
+	require.NoError(t, err)
+
+	assert.Contains(t, answer.Text, "No stored cost documents")
+	assert.Nil(t, chat.messages, "the model is not called without documents")
+
+	_, err = New(&fakeSearcher{}, chat).Ask(context.Background(), "  ", 3, types.VectorQueryFilter{})
+	assert.Error(t, err)
+}
+
+func TestDescribe(t *testing.T) {
+	m := s3Match("2024-03-01", "2024-04-01", "1.00")
+	m.Metadata.Dimensions = "SERVICE"
+	assert.Equal(t, "period 2024-03-01 to 2024-04-01, Amazon S3, Requests-Tier1, cost 1.00", Describe(m))
+}
+
+func TestLocalChat(t *testing.T) {
+	var got struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = io.WriteString(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"grounded answer"}}]}`)
+	}))
+	defer srv.Close()
+
+	chat, err := NewChat(types.ChatConfig{Provider: "local", Model: "llama3.1", BaseURL: srv.URL + "/v1"})
+	require.NoError(t, err)
+
+	text, err := chat.Complete(context.Background(), []types.ChatMessage{{Role: "user", Content: "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, "grounded answer", text)
+	assert.Equal(t, "/v1/chat/completions", path)
+	assert.Equal(t, "llama3.1", got.Model)
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "hi", got.Messages[0].Content)
+}
+
+func TestValidateChat(t *testing.T) {
+	assert.NoError(t, ValidateChat(types.ChatConfig{Provider: "openai", APIKey: "sk"}))
+	assert.Error(t, ValidateChat(types.ChatConfig{Provider: "openai"}))
+	assert.Error(t, ValidateChat(types.ChatConfig{Provider: "local"}))
+	assert.Error(t, ValidateChat(types.ChatConfig{Provider: "azure", APIKey: "k"}))
+	assert.Error(t, ValidateChat(types.ChatConfig{Provider: "anthropic"}))
+}
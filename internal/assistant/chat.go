@@ -0,0 +1,70 @@
+package assistant
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/openai"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const (
+	OpenAI = openai.OpenAI
+	Azure  = openai.Azure
+	Local  = openai.Local
+
+	DefaultChatModel    = "gpt-4o-mini"
+	DefaultLocalBaseURL = openai.DefaultLocalBaseURL
+)
+
+// Providers lists the supported chat-completions providers
+var Providers = []string{OpenAI, Azure, Local}
+
+// ValidateChat checks that the settings required by the selected chat
+// provider are present
+func ValidateChat(cfg types.ChatConfig) error {
+	switch strings.ToLower(cfg.Provider) {
+	case OpenAI:
+		if cfg.APIKey == "" {
+			return fmt.Errorf("openai chat requires OPENAI_API_KEY")
+		}
+	case Azure:
+		if cfg.APIKey == "" || cfg.BaseURL == "" {
+			return fmt.Errorf("azure chat requires AZURE_OPENAI_API_KEY and AZURE_OPENAI_ENDPOINT")
+		}
+	case Local:
+		if cfg.Model == "" {
+			return fmt.Errorf("local chat requires a model name, e.g. --chatModel llama3.1")
+		}
+	default:
+		return fmt.Errorf("unknown chat provider %q, expected one of %s",
+			cfg.Provider, strings.Join(Providers, ", "))
+	}
+	return nil
+}
+
+// NewChat returns the chat model selected by cfg.Provider
+func NewChat(cfg types.ChatConfig) (ports.ChatModel, error) {
+	return newChatClient(cfg)
+}
+
+// NewFunctionCaller returns the chat model selected by cfg.Provider for
+// function calling. The model must support tools.
+func NewFunctionCaller(cfg types.ChatConfig) (ports.FunctionCaller, error) {
+	return newChatClient(cfg)
+}
+
+func newChatClient(cfg types.ChatConfig) (*openai.ChatClient, error) {
+	if err := ValidateChat(cfg); err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(cfg.Provider, Local) && cfg.BaseURL == "" {
+		cfg.BaseURL = DefaultLocalBaseURL
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultChatModel
+	}
+	return openai.NewChatClient(cfg), nil
+}
@@ -0,0 +1,336 @@
+package assistant
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const (
+	summaryTopKeys   = 5
+	summaryTopMovers = 3
+	// summaryAttempts bounds the requests for a summary that only uses
+	// placeholders for numbers
+	summaryAttempts = 2
+)
+
+const summaryPrompt = `You write short executive summaries of AWS cost reports for managers.
+The user gives you facts computed from the report, each labelled with a
+placeholder such as {F1}. Write a single paragraph of at most five sentences
+that explains where the money goes and what changed. Whenever you mention an
+amount, percentage, count or date, write the placeholder of the fact instead,
+e.g. "Spend reached {F2}, led by Amazon EC2 ({F4})". Never write digits
+yourself and do not state anything that the facts do not support.`
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{F\d+\}`)
+	// numberPattern matches numbers that stand on their own, but not the
+	// digits of names such as EC2 or S3
+	numberPattern = regexp.MustCompile(`\b\d[\d.,]*\b`)
+)
+
+// Summarizer asks a chat model for a narrative of a report's facts
+type Summarizer struct {
+	Chat ports.ChatModel
+}
+
+func NewSummarizer(chat ports.ChatModel) *Summarizer {
+	return &Summarizer{
+		Chat: chat,
+	}
+}
+
+// Summarize asks the chat model for a narrative of facts and replaces its
+// placeholders with the values of the facts. A narrative containing numbers
+// of its own is sent back once for correction before it is rejected.
+func (s *Summarizer) Summarize(ctx context.Context, title string,
+	facts []types.Fact) (types.Summary, error) {
+
+	summary := types.Summary{Title: title, Model: s.Chat.Model(), Facts: facts}
+	if len(facts) == 0 {
+		return summary, fmt.Errorf("the report has no data to summarize")
+	}
+
+	messages := BuildSummaryPrompt(title, facts)
+	for attempt := 1; ; attempt++ {
+		text, err := s.Chat.Complete(ctx, messages)
+		if err != nil {
+			return summary, err
+		}
+
+		summary.Text, err = RenderSummary(text, facts)
+		if err == nil || attempt == summaryAttempts {
+			return summary, err
+		}
+		messages = append(messages,
+			types.ChatMessage{Role: "assistant", Content: text},
+			types.ChatMessage{Role: "user", Content: err.Error() +
+				". Rewrite the summary using placeholders for every number."})
+	}
+}
+
+// BuildSummaryPrompt lists facts with their placeholders
+func BuildSummaryPrompt(title string, facts []types.Fact) []types.ChatMessage {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Report: %s\n\nFacts:\n", title)
+	for _, f := range facts {
+		fmt.Fprintf(&b, "{%s} %s: %s\n", f.ID, f.Label, f.Value)
+	}
+
+	return []types.ChatMessage{
+		{Role: "system", Content: summaryPrompt},
+		{Role: "user", Content: b.String()},
+	}
+}
+
+// RenderSummary replaces the placeholders of text with the values of facts.
+// It fails if text refers to an unknown fact or contains a number that is
+// not a placeholder.
+func RenderSummary(text string, facts []types.Fact) (string, error) {
+	values := make(map[string]string, len(facts))
+	var names []string
+	for _, f := range facts {
+		values["{"+f.ID+"}"] = f.Value
+		names = append(names, f.Names...)
+	}
+
+	var unknown []string
+	for _, p := range placeholderPattern.FindAllString(text, -1) {
+		if _, ok := values[p]; !ok {
+			unknown = append(unknown, p)
+		}
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("the summary refers to unknown facts %s", strings.Join(unknown, ", "))
+	}
+
+	// names may contain numbers of their own, e.g. a tag value of 2024
+	unplaced := placeholderPattern.ReplaceAllString(text, "")
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		unplaced = strings.ReplaceAll(unplaced, name, "")
+	}
+	if numbers := numberPattern.FindAllString(unplaced, -1); len(numbers) > 0 {
+		return "", fmt.Errorf("the summary contains numbers that are not facts: %s",
+			strings.Join(numbers, ", "))
+	}
+
+	return strings.TrimSpace(placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		return values[p]
+	})), nil
+}
+
+// SummaryMarkdown renders summary as a Markdown document listing the facts
+// its figures come from
+func SummaryMarkdown(summary types.Summary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n## Facts\n\n| Fact | Value |\n| --- | --- |\n",
+		summary.Title, summary.Text)
+	for _, f := range summary.Facts {
+		fmt.Fprintf(&b, "| %s | %s |\n", markdownCell(f.Label), markdownCell(f.Value))
+	}
+	fmt.Fprintf(&b, "\nNarrative written by %s; every figure is computed from the report.\n",
+		summary.Model)
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// CostFacts computes the total, the largest keys and the biggest movers
+// between the first and last period of a cost and usage report. Only the
+// first metric of each row is used.
+func CostFacts(r types.CostAndUsageOutputType) []types.Fact {
+	type key struct {
+		name        string
+		total       float64
+		first, last float64
+	}
+	var firstStart, lastStart, unit string
+	periods := map[string]bool{}
+	keys := map[string]*key{}
+	var order []string
+	total := 0.0
+
+	for _, s := range r.Services {
+		if len(s.Metrics) == 0 {
+			continue
+		}
+		if firstStart == "" || s.Start < firstStart {
+			firstStart = s.Start
+		}
+		lastStart = max(lastStart, s.Start)
+	}
+
+	indexes := make([]int, 0, len(r.Services))
+	for i := range r.Services {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	for _, i := range indexes {
+		s := r.Services[i]
+		if len(s.Metrics) == 0 {
+			continue
+		}
+		amount := s.Metrics[0].NumericAmount
+		if unit == "" {
+			unit = s.Metrics[0].Unit
+		}
+		periods[s.Start] = true
+		total += amount
+
+		name := strings.Join(s.Keys, ", ")
+		k, ok := keys[name]
+		if !ok {
+			k = &key{name: name}
+			keys[name] = k
+			order = append(order, name)
+		}
+		k.total += amount
+		if s.Start == firstStart {
+			k.first += amount
+		}
+		if s.Start == lastStart {
+			k.last += amount
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	var facts []types.Fact
+	add := func(label, value string, names ...string) {
+		facts = append(facts, types.Fact{
+			ID: "F" + strconv.Itoa(len(facts)+1), Label: label, Value: value, Names: names,
+		})
+	}
+
+	add("Period", r.Start+" to "+r.End)
+	add("Total cost", money(total, unit))
+	add("Number of "+groupName(r), strconv.Itoa(len(keys)))
+	add("Number of periods", strconv.Itoa(len(periods)))
+
+	ranked := make([]*key, 0, len(keys))
+	for _, name := range order {
+		ranked = append(ranked, keys[name])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].total > ranked[j].total })
+	for i, k := range ranked[:min(summaryTopKeys, len(ranked))] {
+		value := money(k.total, unit)
+		if total != 0 {
+			value += fmt.Sprintf(" (%.1f%% of total)", 100*k.total/total)
+		}
+		add(fmt.Sprintf("Largest %s #%d: %s", groupName(r), i+1, k.name), value, k.name)
+	}
+
+	if len(periods) < 2 {
+		return facts
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].last-ranked[i].first > ranked[j].last-ranked[j].first
+	})
+	movers := func(label string, ks []*key) {
+		for _, k := range ks {
+			value := fmt.Sprintf("%s to %s (%s)", money(k.first, unit), money(k.last, unit),
+				signed(k.last-k.first))
+			if k.first != 0 {
+				value += fmt.Sprintf(", %+.1f%%", 100*(k.last-k.first)/math.Abs(k.first))
+			}
+			add(fmt.Sprintf("%s from %s to %s: %s", label, firstStart, lastStart, k.name), value, k.name)
+		}
+	}
+	var increases, decreases []*key
+	for _, k := range ranked {
+		if k.last > k.first && len(increases) < summaryTopMovers {
+			increases = append(increases, k)
+		}
+	}
+	for i := len(ranked) - 1; i >= 0 && len(decreases) < summaryTopMovers; i-- {
+		if ranked[i].last < ranked[i].first {
+			decreases = append(decreases, ranked[i])
+		}
+	}
+	movers("Biggest increase", increases)
+	movers("Biggest decrease", decreases)
+	return facts
+}
+
+// ForecastFacts computes the total, the prediction interval and the largest
+// period of a forecast
+func ForecastFacts(d types.ForecastPrintData) []types.Fact {
+	if d.Forecast == nil || len(d.Forecast.ForecastResultsByTime) == 0 {
+		return nil
+	}
+
+	var facts []types.Fact
+	add := func(label, value string) {
+		facts = append(facts, types.Fact{ID: "F" + strconv.Itoa(len(facts)+1), Label: label, Value: value})
+	}
+
+	unit := ""
+	if d.Forecast.Total != nil && d.Forecast.Total.Unit != nil {
+		unit = *d.Forecast.Total.Unit
+	}
+	results := d.Forecast.ForecastResultsByTime
+	first, last := results[0], results[len(results)-1]
+	add("Forecast period", value(first.TimePeriod.Start)+" to "+value(last.TimePeriod.End))
+	if d.Forecast.Total != nil {
+		add("Forecast total", money(amount(d.Forecast.Total.Amount), unit))
+	}
+
+	var lower, upper float64
+	largest := results[0]
+	for _, r := range results {
+		lower += amount(r.PredictionIntervalLowerBound)
+		upper += amount(r.PredictionIntervalUpperBound)
+		if amount(r.MeanValue) > amount(largest.MeanValue) {
+			largest = r
+		}
+	}
+	add("Prediction interval of the total", money(lower, unit)+" to "+money(upper, unit))
+	add("Number of periods", strconv.Itoa(len(results)))
+	if len(results) > 1 {
+		add("Largest period", fmt.Sprintf("%s to %s, %s", value(largest.TimePeriod.Start),
+			value(largest.TimePeriod.End), money(amount(largest.MeanValue), unit)))
+	}
+	if len(d.Filters) > 0 {
+		add("Filtered by", strings.Join(d.Filters, ", "))
+	}
+	return facts
+}
+
+func groupName(r types.CostAndUsageOutputType) string {
+	names := append(append([]string(nil), r.Dimensions...), r.Tags...)
+	if len(names) == 0 {
+		return "keys"
+	}
+	return strings.Join(names, "/")
+}
+
+func money(amount float64, unit string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, unit))
+}
+
+func signed(amount float64) string {
+	return fmt.Sprintf("%+.2f", amount)
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func amount(s *string) float64 {
+	f, _ := strconv.ParseFloat(value(s), 64)
+	return f
+}
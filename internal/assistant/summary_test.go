@@ -0,0 +1,136 @@
+package assistant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	costexplorertypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat replies with the next of its replies
+type scriptedChat struct {
+	replies  []string
+	messages []types.ChatMessage
+}
+
+func (s *scriptedChat) Complete(ctx context.Context, messages []types.ChatMessage) (string, error) {
+	s.messages = messages
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, nil
+}
+
+func (s *scriptedChat) Model() string {
+	return "scripted-chat"
+}
+
+func costReport() types.CostAndUsageOutputType {
+	row := func(key, start string, amount float64) types.Service {
+		return types.Service{
+			Keys:    []string{key},
+			Start:   start,
+			Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: amount, Unit: "USD"}},
+		}
+	}
+	return types.CostAndUsageOutputType{
+		Start:      "2024-01-01",
+		End:        "2024-03-01",
+		Dimensions: []string{"SERVICE"},
+		Services: map[int]types.Service{
+			0: row("Amazon EC2", "2024-01-01", 100),
+			1: row("Amazon S3", "2024-01-01", 50),
+			2: row("Amazon EC2", "2024-02-01", 180),
+			3: row("Amazon S3", "2024-02-01", 20),
+		},
+	}
+}
+
+func TestCostFacts(t *testing.T) {
+	facts := CostFacts(costReport())
+
+	values := map[string]string{}
+	for _, f := range facts {
+		values[f.Label] = f.Value
+	}
+	assert.Equal(t, "F1", facts[0].ID)
+	assert.Equal(t, "350.00 USD", values["Total cost"])
+	assert.Equal(t, "2", values["Number of SERVICE"])
+	assert.Equal(t, "280.00 USD (80.0% of total)", values["Largest SERVICE #1: Amazon EC2"])
+	assert.Equal(t, "100.00 USD to 180.00 USD (+80.00), +80.0%",
+		values["Biggest increase from 2024-01-01 to 2024-02-01: Amazon EC2"])
+	assert.Equal(t, "50.00 USD to 20.00 USD (-30.00), -60.0%",
+		values["Biggest decrease from 2024-01-01 to 2024-02-01: Amazon S3"])
+
+	assert.Nil(t, CostFacts(types.CostAndUsageOutputType{}))
+}
+
+func TestForecastFacts(t *testing.T) {
+	period := func(start, end, mean, lower, upper string) costexplorertypes.ForecastResult {
+		return costexplorertypes.ForecastResult{
+			TimePeriod:                   &costexplorertypes.DateInterval{Start: aws.String(start), End: aws.String(end)},
+			MeanValue:                    aws.String(mean),
+			PredictionIntervalLowerBound: aws.String(lower),
+			PredictionIntervalUpperBound: aws.String(upper),
+		}
+	}
+	facts := ForecastFacts(types.ForecastPrintData{Forecast: &costexplorer.GetCostForecastOutput{
+		Total: &costexplorertypes.MetricValue{Amount: aws.String("300"), Unit: aws.String("USD")},
+		ForecastResultsByTime: []costexplorertypes.ForecastResult{
+			period("2024-03-01", "2024-04-01", "120", "100", "140"),
+			period("2024-04-01", "2024-05-01", "180", "150", "210"),
+		},
+	}})
+
+	values := map[string]string{}
+	for _, f := range facts {
+		values[f.Label] = f.Value
+	}
+	assert.Equal(t, "2024-03-01 to 2024-05-01", values["Forecast period"])
+	assert.Equal(t, "300.00 USD", values["Forecast total"])
+	assert.Equal(t, "250.00 USD to 350.00 USD", values["Prediction interval of the total"])
+	assert.Equal(t, "2024-04-01 to 2024-05-01, 180.00 USD", values["Largest period"])
+}
+
+func TestRenderSummary(t *testing.T) {
+	facts := []types.Fact{
+		{ID: "F1", Label: "Total cost", Value: "350.00 USD"},
+		{ID: "F2", Label: "Largest TAG #1: 2024 migration", Value: "280.00 USD", Names: []string{"2024 migration"}},
+	}
+
+	text, err := RenderSummary("Spend reached {F1}, mostly EC2 and S3 for the 2024 migration ({F2}).", facts)
+	require.NoError(t, err)
+	assert.Equal(t, "Spend reached 350.00 USD, mostly EC2 and S3 for the 2024 migration (280.00 USD).", text)
+
+	_, err = RenderSummary("Spend reached 351 USD.", facts)
+	assert.ErrorContains(t, err, "numbers that are not facts: 351")
+	_, err = RenderSummary("Spend reached {F9}.", facts)
+	assert.ErrorContains(t, err, "unknown facts {F9}")
+}
+
+func TestSummarize(t *testing.T) {
+	chat := &scriptedChat{replies: []string{
+		"Spend reached 350 USD.",
+		"Spend reached {F2}, led by Amazon EC2 ({F5}).",
+	}}
+
+	summary, err := NewSummarizer(chat).Summarize(context.Background(), "AWS costs", CostFacts(costReport()))
+	require.NoError(t, err)
+	assert.Equal(t, "Spend reached 350.00 USD, led by Amazon EC2 (280.00 USD (80.0% of total)).", summary.Text)
+	assert.Equal(t, "scripted-chat", summary.Model)
+	require.Len(t, chat.messages, 4, "a summary with numbers of its own is sent back for correction")
+	assert.Contains(t, chat.messages[1].Content, "{F2} Total cost: 350.00 USD")
+	assert.Contains(t, chat.messages[3].Content, "not facts: 350")
+
+	chat = &scriptedChat{replies: []string{"It cost 1 USD.", "It cost 2 USD."}}
+	_, err = NewSummarizer(chat).Summarize(context.Background(), "AWS costs", CostFacts(costReport()))
+	assert.Error(t, err)
+
+	markdown := SummaryMarkdown(summary)
+	assert.Contains(t, markdown, "# AWS costs\n\nSpend reached 350.00 USD")
+	assert.Contains(t, markdown, "| Total cost | 350.00 USD |")
+}
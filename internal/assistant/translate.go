@@ -0,0 +1,142 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/flags"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const costQueryFunctionName = "query_cost_and_usage"
+
+const translatePrompt = `You translate requests about AWS spend into a single AWS Cost Explorer
+GetCostAndUsage query by calling %s. Today is %s.
+
+Rules:
+- end_date is exclusive and may not be after today. "Last two weeks" ends today
+  and starts 14 days earlier; "last month" is the previous calendar month.
+- Without a period, use the current month to date.
+- Use DAILY for ranges of a few weeks or when the user asks for daily figures,
+  HOURLY only for explicitly hourly requests within the last 14 days, and
+  MONTHLY otherwise.
+- Always group by at least one dimension; use SERVICE when unsure. EC2 compute
+  is the service "Amazon Elastic Compute Cloud - Compute", S3 is
+  "Amazon Simple Storage Service".
+- "Excluding credits", refunds or discounts means exclude_discounts.
+- Cost allocation tags go in group_by_tag; tag_filter_value filters on the value
+  of the first of them.`
+
+// CostQueryFunction describes the function a chat model calls to translate
+// a natural-language request into cost query arguments
+func CostQueryFunction() types.ChatFunction {
+	dimensions := append([]string(nil), flags.DimensionNames...)
+	return types.ChatFunction{
+		Name:        costQueryFunctionName,
+		Description: "Query AWS Cost Explorer for cost and usage grouped by dimensions and tags",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"granularity": map[string]any{
+					"type": "string",
+					"enum": []string{"DAILY", "MONTHLY", "HOURLY"},
+				},
+				"start_date": map[string]any{
+					"type":        "string",
+					"description": "Inclusive start date, YYYY-MM-DD",
+				},
+				"end_date": map[string]any{
+					"type":        "string",
+					"description": "Exclusive end date, YYYY-MM-DD",
+				},
+				"group_by": map[string]any{
+					"type":        "array",
+					"description": "Dimensions to group by, at most two in total with group_by_tag",
+					"items":       map[string]any{"type": "string", "enum": dimensions},
+				},
+				"group_by_tag": map[string]any{
+					"type":        "array",
+					"description": "Cost allocation tag keys to group by",
+					"items":       map[string]any{"type": "string"},
+				},
+				"dimension_filter": map[string]any{
+					"type":                 "object",
+					"description":          "At most two dimensions mapped to the exact value to keep, e.g. {\"REGION\": \"us-east-1\"}",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+				"tag_filter_value": map[string]any{
+					"type":        "string",
+					"description": "Keep only this value of the first group_by_tag key",
+				},
+				"exclude_discounts": map[string]any{
+					"type":        "boolean",
+					"description": "Exclude credits, refunds, discounts and savings plan charges",
+				},
+				"metric": map[string]any{
+					"type": "string",
+					"enum": []string{"AmortizedCost", "BlendedCost", "NetAmortizedCost",
+						"NetUnblendedCost", "NormalizedUsageAmount", "UnblendedCost", "UsageQuantity"},
+				},
+				"sort_by_date": map[string]any{
+					"type":        "boolean",
+					"description": "Sort by date instead of by cost",
+				},
+				"print_format": map[string]any{
+					"type": "string",
+					"enum": []string{"stdout", "csv", "chart", "json"},
+				},
+			},
+			"required": []string{"granularity", "start_date", "end_date", "group_by"},
+		},
+	}
+}
+
+// Translator turns natural-language cost questions into cost query arguments
+type Translator struct {
+	Chat ports.FunctionCaller
+	Now  func() time.Time
+}
+
+func NewTranslator(chat ports.FunctionCaller) *Translator {
+	return &Translator{
+		Chat: chat,
+		Now:  time.Now,
+	}
+}
+
+// Translate asks the chat model to call the cost query function for request
+// and returns its arguments, with defaults applied
+func (t *Translator) Translate(ctx context.Context, request string) (types.CostQueryArguments, error) {
+	request = strings.TrimSpace(request)
+	if request == "" {
+		return types.CostQueryArguments{}, fmt.Errorf("request must not be empty")
+	}
+
+	messages := []types.ChatMessage{
+		{Role: "system", Content: fmt.Sprintf(translatePrompt, costQueryFunctionName,
+			t.Now().Format("2006-01-02 (Monday)"))},
+		{Role: "user", Content: request},
+	}
+	raw, err := t.Chat.CallFunction(ctx, messages, CostQueryFunction())
+	if err != nil {
+		return types.CostQueryArguments{}, err
+	}
+
+	var args types.CostQueryArguments
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return types.CostQueryArguments{}, fmt.Errorf("model returned invalid arguments %s: %w", raw, err)
+	}
+
+	args.Granularity = strings.ToUpper(args.Granularity)
+	if args.Metric == "" {
+		args.Metric = "UnblendedCost"
+	}
+	if args.PrintFormat == "" {
+		args.PrintFormat = "stdout"
+	}
+	return args, nil
+}
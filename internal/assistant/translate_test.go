@@ -0,0 +1,95 @@
+package assistant
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCaller struct {
+	arguments string
+	messages  []types.ChatMessage
+	fn        types.ChatFunction
+}
+
+func (f *fakeCaller) CallFunction(ctx context.Context, messages []types.ChatMessage,
+	fn types.ChatFunction) (string, error) {
+	f.messages, f.fn = messages, fn
+	return f.arguments, nil
+}
+
+func TestTranslate(t *testing.T) {
+	caller := &fakeCaller{arguments: `{
+		"granularity": "daily",
+		"start_date": "2024-03-01",
+		"end_date": "2024-03-15",
+		"group_by": ["REGION"],
+		"dimension_filter": {"SERVICE": "Amazon Elastic Compute Cloud - Compute"},
+		"exclude_discounts": true}`}
+	translator := NewTranslator(caller)
+	translator.Now = func() time.Time { return time.Date(2024, 3, 15, 9, 0, 0, 0, time.UTC) }
+
+	q, err := translator.Translate(context.Background(),
+		"daily EC2 spend by region for the last two weeks excluding credits")
+	require.NoError(t, err)
+
+	assert.Equal(t, types.CostQueryArguments{
+		Granularity:      "DAILY",
+		StartDate:        "2024-03-01",
+		EndDate:          "2024-03-15",
+		GroupBy:          []string{"REGION"},
+		DimensionFilter:  map[string]string{"SERVICE": "Amazon Elastic Compute Cloud - Compute"},
+		ExcludeDiscounts: true,
+		Metric:           "UnblendedCost",
+		PrintFormat:      "stdout",
+	}, q)
+	assert.Contains(t, caller.messages[0].Content, "Today is 2024-03-15 (Friday)")
+	assert.Equal(t, "query_cost_and_usage", caller.fn.Name)
+
+	caller.arguments = `{"granularity": `
+	_, err = translator.Translate(context.Background(), "costs")
+	assert.ErrorContains(t, err, "invalid arguments")
+}
+
+func TestCostQueryFunctionMirrorsArguments(t *testing.T) {
+	properties := CostQueryFunction().Parameters["properties"].(map[string]any)
+
+	b, err := json.Marshal(types.CostQueryArguments{})
+	require.NoError(t, err)
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(b, &fields))
+
+	assert.Len(t, properties, len(fields))
+	for name := range properties {
+		assert.Contains(t, fields, name)
+	}
+}
+
+func TestCallFunction(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = io.WriteString(w, `{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"query_cost_and_usage","arguments":"{\"granularity\":\"MONTHLY\"}"}}]}}]}`)
+	}))
+	defer srv.Close()
+
+	caller, err := NewFunctionCaller(types.ChatConfig{Provider: "openai", APIKey: "sk", BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	args, err := caller.CallFunction(context.Background(),
+		[]types.ChatMessage{{Role: "user", Content: "monthly costs"}}, CostQueryFunction())
+	require.NoError(t, err)
+	assert.Equal(t, `{"granularity":"MONTHLY"}`, args)
+	assert.Equal(t, DefaultChatModel, got["model"])
+	assert.Equal(t, map[string]any{"type": "function", "function": map[string]any{"name": "query_cost_and_usage"}},
+		got["tool_choice"])
+}
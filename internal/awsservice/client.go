@@ -17,12 +17,14 @@ func New() (*Service, error) {
 	var err error
 	var cfg aws.Config
 
-	if profile := Profile(); profile == "not-provided" {
-		cfg, err = config.LoadDefaultConfig(context.TODO())
-	} else {
-		cfg, err = config.LoadDefaultConfig(context.TODO(),
-			config.WithSharedConfigProfile(profile))
+	var opts []func(*config.LoadOptions) error
+	if profile := Profile(); profile != "not-provided" {
+		opts = append(opts, config.WithSharedConfigProfile(profile))
 	}
+	if region := Region(); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+	cfg, err = config.LoadDefaultConfig(context.TODO(), opts...)
 
 	if err != nil {
 		return nil, types.APIError{
@@ -3,10 +3,16 @@ package awsservice
 import "github.com/spf13/viper"
 
 func Profile() string {
-	awsProfile := viper.GetString("aws_profile")
+	awsProfile := viper.GetString("aws.profile")
 	if awsProfile == "" {
 		awsProfile = "not-provided"
 	}
 
 	return awsProfile
 }
+
+// Region returns the configured AWS region, or an empty string to use the
+// region of the profile or environment
+func Region() string {
+	return viper.GetString("aws.region")
+}
@@ -552,3 +552,29 @@ func TestCostAndUsageGroupByGenerator_ByTagAndDimesion(t *testing.T) {
 		}
 	}
 }
+
+func TestCostAndUsageFilterGenerator_DimensionAllowList(t *testing.T) {
+	req := types2.CostAndUsageRequestType{
+		Granularity: "MONTHLY",
+		GroupBy:     []string{"SERVICE"},
+		DimensionAllowList: map[string][]string{
+			"SERVICE":        {"Amazon S3"},
+			"LINKED_ACCOUNT": {"111111111111", "222222222222"},
+		},
+	}
+
+	result := CostAndUsageFilterGenerator(req)
+	if len(result.And) != 2 {
+		t.Fatalf("CostAndUsageFilterGenerator(%v) returned %d expressions, want 2",
+			req, len(result.And))
+	}
+	if result.And[0].Dimensions.Key != "LINKED_ACCOUNT" ||
+		len(result.And[0].Dimensions.Values) != 2 {
+		t.Errorf("CostAndUsageFilterGenerator(%v) == %v, want LINKED_ACCOUNT allow-list",
+			req, result.And[0].Dimensions)
+	}
+	if result.And[1].Dimensions.Key != "SERVICE" {
+		t.Errorf("CostAndUsageFilterGenerator(%v) == %v, want SERVICE allow-list",
+			req, result.And[1].Dimensions.Key)
+	}
+}
@@ -2,6 +2,8 @@ package awsservice
 
 import (
 	"context"
+	"sort"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
@@ -65,31 +67,81 @@ var (
 			},
 		}
 	}
+	filterByDimensionValues = func(dimension string, values []string) *types.Expression {
+		return &types.Expression{
+			Dimensions: &types.DimensionValues{
+				Key:    types.Dimension(dimension),
+				Values: values,
+			},
+		}
+	}
 )
 
+// GetCostAndUsage follows NextPageToken until every page has been fetched and
+// merges the pages into a single output. The page gate attached to ctx, if
+// any, is checked before each page is requested, and the page observer is
+// notified after each page. When ctx is cancelled or the gate fails after at
+// least one page has been fetched, the pages collected so far are returned
+// together with the error.
 func (srv *Service) GetCostAndUsage(ctx context.Context,
 	req types2.CostAndUsageRequestType) (
 	*costexplorer.GetCostAndUsageOutput,
 	error) {
 
-	result, err := srv.Client.GetCostAndUsage(context.TODO(),
-		&costexplorer.GetCostAndUsageInput{
-			Granularity: types.Granularity(req.Granularity), //todo: add option to pass HOURLY granularity as well
-			Metrics:     req.Metrics,
-			TimePeriod: &types.DateInterval{
-				Start: aws.String(req.Time.Start),
-				End:   aws.String(req.Time.End),
-			},
-			GroupBy: CostAndUsageGroupByGenerator(req),
-			Filter:  CostAndUsageFilterGenerator(req),
-		})
+	input := &costexplorer.GetCostAndUsageInput{
+		Granularity: types.Granularity(req.Granularity), //todo: add option to pass HOURLY granularity as well
+		Metrics:     req.Metrics,
+		TimePeriod: &types.DateInterval{
+			Start: aws.String(req.Time.Start),
+			End:   aws.String(req.Time.End),
+		},
+		GroupBy: CostAndUsageGroupByGenerator(req),
+		Filter:  CostAndUsageFilterGenerator(req),
+	}
+
+	return collectPages(ctx, func(token *string) (*costexplorer.GetCostAndUsageOutput, error) {
+		input.NextPageToken = token
+		return srv.Client.GetCostAndUsage(ctx, input)
+	})
+}
 
-	if err != nil {
-		return nil, types2.APIError{
-			Msg: err.Error(),
+// collectPages calls fetch until no NextPageToken is returned and appends
+// the ResultsByTime of every page to the first one
+func collectPages(ctx context.Context,
+	fetch func(token *string) (*costexplorer.GetCostAndUsageOutput, error)) (
+	*costexplorer.GetCostAndUsageOutput, error) {
+
+	var merged *costexplorer.GetCostAndUsageOutput
+	var token *string
+
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return merged, types2.APIError{Msg: err.Error()}
+		}
+		if err := CheckPage(ctx, page); err != nil {
+			return merged, err
+		}
+
+		result, err := fetch(token)
+		if err != nil {
+			return merged, types2.APIError{Msg: err.Error()}
+		}
+
+		if merged == nil {
+			merged = result
+		} else {
+			merged.ResultsByTime = append(merged.ResultsByTime, result.ResultsByTime...)
+			merged.DimensionValueAttributes = append(merged.DimensionValueAttributes,
+				result.DimensionValueAttributes...)
+		}
+		notifyPage(ctx, page)
+
+		token = result.NextPageToken
+		if token == nil || *token == "" {
+			merged.NextPageToken = nil
+			return merged, nil
 		}
 	}
-	return result, nil
 }
 
 // ToSlice converts dimension values to string slice using generic transformation
@@ -117,6 +169,15 @@ func CostAndUsageFilterGenerator(req types2.CostAndUsageRequestType) *types.
 		//filters = append(filters, *filterByDimension(req.DimensionFilterName, req.DimensionFilterValue))
 	}
 
+	allowListKeys := make([]string, 0, len(req.DimensionAllowList))
+	for key := range req.DimensionAllowList {
+		allowListKeys = append(allowListKeys, key)
+	}
+	sort.Strings(allowListKeys)
+	for _, key := range allowListKeys {
+		filters = append(filters, *filterByDimensionValues(key, req.DimensionAllowList[key]))
+	}
+
 	if len(filters) == 0 {
 		return nil
 	} else if len(filters) == 1 {
@@ -0,0 +1,89 @@
+package awsservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pagedFetcher(pages []string, onFetch func(page int)) func(token *string) (*costexplorer.GetCostAndUsageOutput, error) {
+	return func(token *string) (*costexplorer.GetCostAndUsageOutput, error) {
+		i := 0
+		if token != nil {
+			for j, p := range pages {
+				if p == *token {
+					i = j
+				}
+			}
+		}
+		if onFetch != nil {
+			onFetch(i + 1)
+		}
+		out := &costexplorer.GetCostAndUsageOutput{
+			ResultsByTime: []types.ResultByTime{{
+				TimePeriod: &types.DateInterval{Start: aws.String(pages[i])},
+			}},
+		}
+		if i+1 < len(pages) {
+			out.NextPageToken = aws.String(pages[i+1])
+		}
+		return out, nil
+	}
+}
+
+func TestCollectPages(t *testing.T) {
+	pages := []string{"2024-01-01", "2024-01-02", "2024-01-03"}
+
+	t.Run("merges every page and notifies the observer", func(t *testing.T) {
+		var seen []int
+		ctx := WithPageObserver(context.Background(), func(page int) {
+			seen = append(seen, page)
+		})
+
+		out, err := collectPages(ctx, pagedFetcher(pages, nil))
+		require.NoError(t, err)
+		require.Len(t, out.ResultsByTime, 3)
+		assert.Equal(t, "2024-01-03", *out.ResultsByTime[2].TimePeriod.Start)
+		assert.Nil(t, out.NextPageToken)
+		assert.Equal(t, []int{1, 2, 3}, seen)
+	})
+
+	t.Run("returns the pages fetched before cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		out, err := collectPages(ctx, pagedFetcher(pages, func(page int) {
+			if page == 2 {
+				cancel()
+			}
+		}))
+		assert.Error(t, err)
+		require.NotNil(t, out)
+		assert.Len(t, out.ResultsByTime, 2)
+	})
+
+	t.Run("stops before the page the gate refuses", func(t *testing.T) {
+		var fetched []int
+		refused := errors.New("budget exhausted")
+		ctx := WithPageGate(context.Background(), func(page int) error {
+			if page == 3 {
+				return refused
+			}
+			return nil
+		})
+
+		out, err := collectPages(ctx, pagedFetcher(pages, func(page int) {
+			fetched = append(fetched, page)
+		}))
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, []int{1, 2}, fetched, "the refused page is not requested")
+		require.NotNil(t, out)
+		assert.Len(t, out.ResultsByTime, 2)
+	})
+}
@@ -0,0 +1,41 @@
+package awsservice
+
+import "context"
+
+// PageObserver is notified after each page of a paginated Cost Explorer
+// response has been fetched
+type PageObserver func(page int)
+
+type pageObserverKey struct{}
+
+// WithPageObserver returns a copy of ctx that carries fn
+func WithPageObserver(ctx context.Context, fn PageObserver) context.Context {
+	return context.WithValue(ctx, pageObserverKey{}, fn)
+}
+
+func notifyPage(ctx context.Context, page int) {
+	if fn, ok := ctx.Value(pageObserverKey{}).(PageObserver); ok && fn != nil {
+		fn(page)
+	}
+}
+
+// PageGate is called before each page of a paginated Cost Explorer request
+// is fetched. An error stops the pagination before the page is requested.
+type PageGate func(page int) error
+
+type pageGateKey struct{}
+
+// WithPageGate returns a copy of ctx that carries fn
+func WithPageGate(ctx context.Context, fn PageGate) context.Context {
+	return context.WithValue(ctx, pageGateKey{}, fn)
+}
+
+// CheckPage calls the page gate attached to ctx, if any, before page is
+// requested. Implementations of paginated Cost Explorer calls call it before
+// every request, since each page is billed.
+func CheckPage(ctx context.Context, page int) error {
+	if fn, ok := ctx.Value(pageGateKey{}).(PageGate); ok && fn != nil {
+		return fn(page)
+	}
+	return nil
+}
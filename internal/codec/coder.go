@@ -3,38 +3,210 @@ package codec
 import (
 	"fmt"
 	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// Binning strategies
+const (
+	// FixedBins uses the configured edges, or DefaultEdges
+	FixedBins = "fixed"
+	// LogBins uses powers of ten spanning the costs of the dataset
+	LogBins = "log"
+	// QuantileBins splits the costs of the dataset into bins of about the
+	// same number of costs
+	QuantileBins = "quantile"
+
+	DefaultQuantileBins = 5
+	DefaultCurrency     = "USD"
 )
 
+// Strategies lists the supported binning strategies
+var Strategies = []string{FixedBins, LogBins, QuantileBins}
+
+// DefaultEdges are the upper bounds of the bins used before binning was
+// configurable
+var DefaultEdges = []float64{1, 10, 50, 100, 500, 1000}
+
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"CNY": "¥",
+	"INR": "₹",
+}
+
 type Encode interface {
 	CategorizeCostsWithBinning(cost float64) string
+	// Band returns the name of the bin of cost
+	Band(cost float64) string
 }
 
+// Encoder sorts costs into a bin per range of amounts. Costs of zero or less
+// are Zero, costs up to the first edge are Between zero and the first edge,
+// and costs above the last edge are Over the last edge.
 type Encoder struct {
+	edges    []float64
+	currency string
 }
 
+// NewEncoder returns an encoder with the default edges
 func NewEncoder() Encode {
-	return &Encoder{}
+	return &Encoder{edges: DefaultEdges, currency: DefaultCurrency}
+}
+
+// Validate checks the strategy, edges and number of bins of cfg
+func Validate(cfg types.BinningConfig) error {
+	switch strategy(cfg) {
+	case FixedBins:
+		for i, edge := range cfg.Edges {
+			if edge <= 0 || (i > 0 && edge <= cfg.Edges[i-1]) {
+				return fmt.Errorf("bin edges must be positive and increasing")
+			}
+		}
+	case LogBins:
+	case QuantileBins:
+		if cfg.Bins < 0 || cfg.Bins == 1 {
+			return fmt.Errorf("quantile binning requires at least 2 bins")
+		}
+	default:
+		return fmt.Errorf("unknown binning strategy %q, expected one of %s",
+			cfg.Strategy, strings.Join(Strategies, ", "))
+	}
+	return nil
+}
+
+// New returns an encoder for cfg. The log and quantile strategies compute
+// their edges from costs.
+func New(cfg types.BinningConfig, costs []float64) (Encode, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	e := &Encoder{edges: cfg.Edges, currency: cfg.Currency}
+	if e.currency == "" {
+		e.currency = DefaultCurrency
+	}
+	switch strategy(cfg) {
+	case FixedBins:
+		if len(e.edges) == 0 {
+			e.edges = DefaultEdges
+		}
+	case LogBins:
+		e.edges = logEdges(costs)
+	case QuantileBins:
+		bins := cfg.Bins
+		if bins == 0 {
+			bins = DefaultQuantileBins
+		}
+		e.edges = quantileEdges(costs, bins)
+	}
+	return e, nil
+}
+
+// ForResults returns an encoder for cfg fitted to the first metric of every
+// row of r. Without a configured currency the unit of the results is used.
+func ForResults(cfg types.BinningConfig, r types.CostAndUsageOutputType) (Encode, error) {
+	var costs []float64
+	for _, s := range r.Services {
+		if len(s.Metrics) == 0 {
+			continue
+		}
+		costs = append(costs, s.Metrics[0].NumericAmount)
+		if cfg.Currency == "" {
+			cfg.Currency = s.Metrics[0].Unit
+		}
+	}
+	return New(cfg, costs)
 }
 
 func (e *Encoder) CategorizeCostsWithBinning(cost float64) string {
-	binRanges := []float64{0, 0.01, 1, 10, 50, 100, 500, 1000, math.MaxFloat32}
-	binNames := []string{"Zero", "Between zero and 1", "Between 1 and 10", "Between 10 and 50", "Between 50 and 100",
-		"Between 100 and 500", "Between 500 and 1000", "Over 1000"}
+	return fmt.Sprintf("%s (%s)", e.Band(cost), e.amount(cost))
+}
+
+func (e *Encoder) Band(cost float64) string {
+	if cost <= 0 {
+		return "Zero"
+	}
+	if len(e.edges) == 0 {
+		return "Over zero"
+	}
+
+	i := sort.SearchFloat64s(e.edges, cost)
+	switch {
+	case i == 0:
+		return "Between zero and " + formatEdge(e.edges[0])
+	case i == len(e.edges):
+		return "Over " + formatEdge(e.edges[i-1])
+	default:
+		return "Between " + formatEdge(e.edges[i-1]) + " and " + formatEdge(e.edges[i])
+	}
+}
+
+// amount formats cost with the symbol of the currency, or its code when it
+// has no known symbol
+func (e *Encoder) amount(cost float64) string {
+	if symbol, ok := currencySymbols[strings.ToUpper(e.currency)]; ok {
+		return fmt.Sprintf("%s%.2f", symbol, cost)
+	}
+	return fmt.Sprintf("%s %.2f", e.currency, cost)
+}
+
+func strategy(cfg types.BinningConfig) string {
+	if cfg.Strategy == "" {
+		return FixedBins
+	}
+	return strings.ToLower(cfg.Strategy)
+}
+
+// logEdges returns the powers of ten from the one at or below the smallest
+// positive cost to the one at or above the largest
+func logEdges(costs []float64) []float64 {
+	low, high := math.Inf(1), 0.0
+	for _, c := range costs {
+		if c > 0 {
+			low, high = min(low, c), max(high, c)
+		}
+	}
+	if high == 0 {
+		return nil
+	}
 
-	binIndex := -1
+	var edges []float64
+	for exp := math.Floor(math.Log10(low)); exp <= math.Ceil(math.Log10(high)); exp++ {
+		edges = append(edges, math.Pow10(int(exp)))
+	}
+	return edges
+}
 
-	for idx, upperBound := range binRanges {
-		if cost <= upperBound {
-			binIndex = idx
-			break
+// quantileEdges returns the bins-1 quantiles of the positive costs, rounded
+// to cents. Quantiles that round to the same amount are merged.
+func quantileEdges(costs []float64, bins int) []float64 {
+	var positive []float64
+	for _, c := range costs {
+		if c > 0 {
+			positive = append(positive, c)
 		}
 	}
+	if len(positive) == 0 {
+		return nil
+	}
+	sort.Float64s(positive)
 
-	var costCategory string
-	if binIndex == 0 {
-		costCategory = fmt.Sprintf("%s ($%.2f)", binNames[0], cost)
-	} else {
-		costCategory = fmt.Sprintf("%s ($%.2f)", binNames[binIndex-1], cost)
+	var edges []float64
+	for i := 1; i < bins; i++ {
+		edge := math.Round(positive[(len(positive)-1)*i/bins]*100) / 100
+		if edge > 0 && (len(edges) == 0 || edge > edges[len(edges)-1]) {
+			edges = append(edges, edge)
+		}
 	}
-	return costCategory
+	return edges
+}
+
+func formatEdge(edge float64) string {
+	return strconv.FormatFloat(edge, 'f', -1, 64)
 }
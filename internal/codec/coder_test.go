@@ -0,0 +1,68 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategorizeCostsWithBinning(t *testing.T) {
+	tests := []struct {
+		cost float64
+		want string
+	}{
+		{cost: 0, want: "Zero ($0.00)"},
+		{cost: -3, want: "Zero ($-3.00)"},
+		{cost: 0.005, want: "Between zero and 1 ($0.01)"},
+		{cost: 1, want: "Between zero and 1 ($1.00)"},
+		{cost: 5, want: "Between 1 and 10 ($5.00)"},
+		{cost: 1000, want: "Between 500 and 1000 ($1000.00)"},
+		{cost: 1500, want: "Over 1000 ($1500.00)"},
+	}
+
+	encoder := NewEncoder()
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, encoder.CategorizeCostsWithBinning(tt.cost))
+	}
+}
+
+func TestNew(t *testing.T) {
+	costs := []float64{0, 0.4, 3, 7, 12, 40, 250, 900}
+
+	fixed, err := New(types.BinningConfig{Edges: []float64{5, 50}, Currency: "EUR"}, costs)
+	require.NoError(t, err)
+	assert.Equal(t, "Between 5 and 50 (€12.00)", fixed.CategorizeCostsWithBinning(12))
+	assert.Equal(t, "Over 50", fixed.Band(900))
+
+	log, err := New(types.BinningConfig{Strategy: LogBins, Currency: "CHF"}, costs)
+	require.NoError(t, err)
+	assert.Equal(t, "Between zero and 0.1", log.Band(0.05))
+	assert.Equal(t, "Between 0.1 and 1", log.Band(0.4))
+	assert.Equal(t, "Between 100 and 1000", log.Band(900))
+	assert.Equal(t, "Between 10 and 100 (CHF 40.00)", log.CategorizeCostsWithBinning(40))
+
+	quantile, err := New(types.BinningConfig{Strategy: QuantileBins, Bins: 2}, costs)
+	require.NoError(t, err)
+	assert.Equal(t, "Between zero and 12", quantile.Band(7))
+	assert.Equal(t, "Over 12", quantile.Band(40))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(types.BinningConfig{}))
+	assert.Error(t, Validate(types.BinningConfig{Strategy: "linear"}))
+	assert.Error(t, Validate(types.BinningConfig{Edges: []float64{10, 5}}))
+	assert.Error(t, Validate(types.BinningConfig{Edges: []float64{0, 5}}))
+	assert.Error(t, Validate(types.BinningConfig{Strategy: QuantileBins, Bins: 1}))
+}
+
+func TestForResults(t *testing.T) {
+	encoder, err := ForResults(types.BinningConfig{}, types.CostAndUsageOutputType{
+		Services: map[int]types.Service{
+			0: {Metrics: []types.Metrics{{NumericAmount: 3, Unit: "GBP"}}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Between 1 and 10 (£3.00)", encoder.CategorizeCostsWithBinning(3))
+}
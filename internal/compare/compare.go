@@ -0,0 +1,155 @@
+package compare
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// keySeparator joins group keys into a map key. It cannot appear in Cost
+// Explorer group keys.
+const keySeparator = "\x00"
+
+type aggregate struct {
+	keys   []string
+	amount float64
+}
+
+// Periods compares two cost and usage reports grouped by the same keys. Costs
+// of each group are summed across all time periods of a report, so the
+// reports may use any granularity. Results are sorted by descending absolute
+// delta.
+func Periods(baseline, current types.CostAndUsageOutputType,
+	metric string) types.PeriodComparison {
+
+	base, baseOrder, baseUnit := aggregateByKey(baseline.Services, metric)
+	curr, currOrder, currUnit := aggregateByKey(current.Services, metric)
+
+	unit := currUnit
+	if unit == "" {
+		unit = baseUnit
+	}
+
+	c := types.PeriodComparison{
+		Metric:          metric,
+		GroupBy:         groupBy(current),
+		Baseline:        periodTotal(baseline, base, unit),
+		Current:         periodTotal(current, curr, unit),
+		Changed:         []types.KeyDelta{},
+		NewKeys:         []types.KeyDelta{},
+		DisappearedKeys: []types.KeyDelta{},
+	}
+	c.AbsoluteDelta = round(c.Current.Total - c.Baseline.Total)
+	c.PercentDelta = percent(c.Baseline.Total, c.Current.Total)
+
+	for _, k := range baseOrder {
+		b := base[k]
+		if cur, ok := curr[k]; ok {
+			c.Changed = append(c.Changed, delta(b.keys, b.amount, cur.amount))
+		} else {
+			c.DisappearedKeys = append(c.DisappearedKeys, delta(b.keys, b.amount, 0))
+		}
+	}
+	for _, k := range currOrder {
+		if _, ok := base[k]; !ok {
+			cur := curr[k]
+			c.NewKeys = append(c.NewKeys, delta(cur.keys, 0, cur.amount))
+		}
+	}
+
+	SortByAbsoluteDelta(c.Changed)
+	SortByAbsoluteDelta(c.NewKeys)
+	SortByAbsoluteDelta(c.DisappearedKeys)
+	return c
+}
+
+// SortByAbsoluteDelta orders deltas by descending magnitude of change, using
+// the keys as a tie-breaker so output is stable
+func SortByAbsoluteDelta(d []types.KeyDelta) {
+	sort.SliceStable(d, func(i, j int) bool {
+		ai, aj := math.Abs(d[i].AbsoluteDelta), math.Abs(d[j].AbsoluteDelta)
+		if ai != aj {
+			return ai > aj
+		}
+		return strings.Join(d[i].Keys, keySeparator) <
+			strings.Join(d[j].Keys, keySeparator)
+	})
+}
+
+func aggregateByKey(services map[int]types.Service, metric string) (
+	map[string]*aggregate, []string, string) {
+
+	totals := make(map[string]*aggregate)
+	var order []string
+	var unit string
+
+	// iterate in index order so first-seen ordering is deterministic; the
+	// indexes need not be contiguous
+	for _, i := range slices.Sorted(maps.Keys(services)) {
+		s := services[i]
+		k := strings.Join(s.Keys, keySeparator)
+		a, ok := totals[k]
+		if !ok {
+			a = &aggregate{keys: append([]string(nil), s.Keys...)}
+			totals[k] = a
+			order = append(order, k)
+		}
+		for _, m := range s.Metrics {
+			if m.Name == metric {
+				a.amount += m.NumericAmount
+				if unit == "" {
+					unit = m.Unit
+				}
+			}
+		}
+	}
+	return totals, order, unit
+}
+
+func periodTotal(r types.CostAndUsageOutputType, totals map[string]*aggregate,
+	unit string) types.PeriodTotal {
+	var total float64
+	for _, a := range totals {
+		total += a.amount
+	}
+	return types.PeriodTotal{
+		Start: r.Start,
+		End:   r.End,
+		Total: round(total),
+		Unit:  unit,
+	}
+}
+
+func delta(keys []string, baseline, current float64) types.KeyDelta {
+	return types.KeyDelta{
+		Keys:          keys,
+		BaselineCost:  round(baseline),
+		CurrentCost:   round(current),
+		AbsoluteDelta: round(current - baseline),
+		PercentDelta:  percent(baseline, current),
+	}
+}
+
+func percent(baseline, current float64) *float64 {
+	if baseline == 0 {
+		return nil
+	}
+	p := math.Round((current-baseline)/math.Abs(baseline)*10000) / 100
+	return &p
+}
+
+func groupBy(r types.CostAndUsageOutputType) []string {
+	labels := append([]string(nil), r.Dimensions...)
+	for _, t := range r.Tags {
+		labels = append(labels, "TAG:"+t)
+	}
+	return labels
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1e6) / 1e6
+}
@@ -0,0 +1,100 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func report(start, end string, costs ...interface{}) types.CostAndUsageOutputType {
+	r := types.CostAndUsageOutputType{
+		Start:      start,
+		End:        end,
+		Dimensions: []string{"SERVICE"},
+		Services:   make(map[int]types.Service),
+	}
+	for i := 0; i < len(costs); i += 2 {
+		r.Services[len(r.Services)] = types.Service{
+			Start: start,
+			End:   end,
+			Keys:  []string{costs[i].(string)},
+			Metrics: []types.Metrics{{
+				Name:          "UnblendedCost",
+				NumericAmount: costs[i+1].(float64),
+				Unit:          "USD",
+			}},
+		}
+	}
+	return r
+}
+
+func TestPeriods(t *testing.T) {
+	baseline := report("2024-01-01", "2024-02-01",
+		"Amazon EC2", 100.0, "Amazon S3", 10.0, "AWS Glue", 5.0)
+	current := report("2024-02-01", "2024-03-01",
+		"Amazon EC2", 80.0, "Amazon S3", 40.0, "Amazon Bedrock", 7.0)
+
+	c := Periods(baseline, current, "UnblendedCost")
+
+	assert.Equal(t, 115.0, c.Baseline.Total)
+	assert.Equal(t, 127.0, c.Current.Total)
+	assert.Equal(t, 12.0, c.AbsoluteDelta)
+	require.NotNil(t, c.PercentDelta)
+	assert.Equal(t, 10.43, *c.PercentDelta)
+	assert.Equal(t, []string{"SERVICE"}, c.GroupBy)
+
+	require.Len(t, c.Changed, 2)
+	assert.Equal(t, []string{"Amazon S3"}, c.Changed[0].Keys)
+	assert.Equal(t, 30.0, c.Changed[0].AbsoluteDelta)
+	assert.Equal(t, 300.0, *c.Changed[0].PercentDelta)
+	assert.Equal(t, -20.0, c.Changed[1].AbsoluteDelta)
+
+	require.Len(t, c.NewKeys, 1)
+	assert.Equal(t, []string{"Amazon Bedrock"}, c.NewKeys[0].Keys)
+	assert.Nil(t, c.NewKeys[0].PercentDelta)
+
+	require.Len(t, c.DisappearedKeys, 1)
+	assert.Equal(t, -5.0, c.DisappearedKeys[0].AbsoluteDelta)
+	assert.Equal(t, -100.0, *c.DisappearedKeys[0].PercentDelta)
+}
+
+func TestPeriodsSumsAcrossPeriods(t *testing.T) {
+	baseline := report("2024-01-01", "2024-01-02", "Amazon EC2", 1.0)
+	baseline.Services[1] = types.Service{
+		Start: "2024-01-02",
+		End:   "2024-01-03",
+		Keys:  []string{"Amazon EC2"},
+		Metrics: []types.Metrics{
+			{Name: "UnblendedCost", NumericAmount: 2, Unit: "USD"},
+			{Name: "UsageQuantity", NumericAmount: 50, Unit: "Hrs"},
+		},
+	}
+	current := report("2024-01-03", "2024-01-04", "Amazon EC2", 6.0)
+
+	c := Periods(baseline, current, "UnblendedCost")
+
+	require.Len(t, c.Changed, 1)
+	assert.Equal(t, 3.0, c.Changed[0].BaselineCost)
+	assert.Equal(t, 3.0, c.Changed[0].AbsoluteDelta)
+	assert.Empty(t, c.NewKeys)
+	assert.Empty(t, c.DisappearedKeys)
+}
+
+func TestPeriodsSparseServiceIndexes(t *testing.T) {
+	baseline := report("2024-01-01", "2024-02-01")
+	current := report("2024-02-01", "2024-03-01")
+	for i, cost := range map[int]float64{0: 1, 2: 2, 5: 4} {
+		baseline.Services[i] = types.Service{
+			Keys:    []string{"Amazon EC2"},
+			Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: cost, Unit: "USD"}},
+		}
+	}
+
+	c := Periods(baseline, current, "UnblendedCost")
+
+	assert.Equal(t, 7.0, c.Baseline.Total, "every service is counted when the indexes have gaps")
+	require.Len(t, c.DisappearedKeys, 1)
+	assert.Equal(t, -7.0, c.DisappearedKeys[0].AbsoluteDelta)
+}
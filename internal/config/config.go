@@ -1,14 +1,253 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
-// LoadConfigFunc is a function that loads the configuration
-var LoadConfigFunc = func(path string) func() {
-	return func() {
-		LoadConfig(path)
+	"github.com/spf13/viper"
+)
+
+const (
+	// FileName is the name of the user configuration file
+	FileName = "config.yaml"
+	// LocalFileName is the name of the configuration file read from the
+	// working directory, which overrides the user configuration
+	LocalFileName = "ccexplorer.yaml"
+
+	// DefaultsKey holds flag defaults, keyed by flag name
+	DefaultsKey = "defaults"
+	// ContextsKey holds named overlays of the configuration
+	ContextsKey = "contexts"
+	// CurrentContextKey names the context used when --context is not set
+	CurrentContextKey = "current_context"
+	// PresetsKey holds the user presets, keyed by name
+	PresetsKey = "presets"
+)
+
+// Template is the annotated configuration written by config init
+//
+//go:embed template.yaml
+var Template string
+
+// Sections lists the top-level keys of a configuration file. Contexts may
+// set every section except contexts and current_context.
+var Sections = []string{
+	"aws", "openai", "azure_openai", "embedding", "chat", "pinecone", "qdrant",
+	"pgvector", "vector_store", "mcp", DefaultsKey, PresetsKey, ContextsKey, CurrentContextKey,
+}
+
+// Loaded describes the configuration files that were read and the context
+// that was applied
+type Loaded struct {
+	Files   []string
+	Context string
+}
+
+// Dir returns the ccexplorer directory of $XDG_CONFIG_HOME, or of the user
+// configuration directory when it is not set
+func Dir() (string, error) {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		var err error
+		if base, err = os.UserConfigDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(base, "ccexplorer"), nil
+}
+
+// DefaultPath returns the path of the user configuration file
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
 	}
+	return filepath.Join(dir, FileName), nil
 }
 
-func LoadConfig(path string) {
+// Load reads file into viper or, when file is empty, the user configuration
+// file and then the one in the working directory, and applies the named
+// context. Environment variables override the files: the key aws.profile is
+// read from AWS_PROFILE, pinecone.api_key from PINECONE_API_KEY and so on.
+func Load(file, context string) (Loaded, error) {
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
+
+	var loaded Loaded
+	if file != "" {
+		if err := read(file, false); err != nil {
+			return loaded, err
+		}
+		loaded.Files = append(loaded.Files, file)
+	} else {
+		var paths []string
+		if path, err := DefaultPath(); err == nil {
+			paths = append(paths, path)
+		}
+		paths = append(paths, LocalFileName)
+
+		for _, path := range paths {
+			err := read(path, len(loaded.Files) > 0)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return loaded, err
+			}
+			loaded.Files = append(loaded.Files, path)
+		}
+	}
+
+	if context == "" {
+		context = viper.GetString(CurrentContextKey)
+	}
+	if context != "" {
+		if err := applyContext(context); err != nil {
+			return loaded, err
+		}
+	}
+	loaded.Context = context
+	return loaded, nil
+}
+
+func read(path string, merge bool) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+
+	readFn := viper.ReadInConfig
+	if merge {
+		readFn = viper.MergeInConfig
+	}
+	if err := readFn(); err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyContext merges the settings of the named context over the rest of
+// the configuration
+func applyContext(name string) error {
+	contexts := viper.GetStringMap(ContextsKey)
+	settings, ok := contexts[strings.ToLower(name)].(map[string]any)
+	if !ok {
+		return fmt.Errorf("unknown context %q, expected one of %s", name, listOrNone(Contexts()))
+	}
+	return viper.MergeConfigMap(settings)
+}
+
+// Contexts returns the names of the configured contexts
+func Contexts() []string {
+	var names []string
+	for name := range viper.GetStringMap(ContextsKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Defaults returns the flag defaults of the configuration with their names
+// in lower case. Lists are joined with commas, as they are on the command
+// line.
+func Defaults() map[string]string {
+	defaults := map[string]string{}
+	for name, value := range viper.GetStringMap(DefaultsKey) {
+		defaults[strings.ToLower(name)] = flagValue(value)
+	}
+	return defaults
+}
+
+func flagValue(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+	values := make([]string, len(list))
+	for i, v := range list {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, ",")
+}
+
+// Validate reports unknown sections of the loaded configuration and of its
+// contexts
+func Validate() []error {
+	var errs []error
+	errs = append(errs, unknownSections("", viper.AllSettings(), Sections)...)
+
+	contextSections := make([]string, 0, len(Sections))
+	for _, s := range Sections {
+		if s != ContextsKey && s != CurrentContextKey {
+			contextSections = append(contextSections, s)
+		}
+	}
+	for name, settings := range viper.GetStringMap(ContextsKey) {
+		m, ok := settings.(map[string]any)
+		if !ok {
+			errs = append(errs, fmt.Errorf("context %q must be a map of settings", name))
+			continue
+		}
+		errs = append(errs, unknownSections("context "+name+": ", m, contextSections)...)
+	}
+	return errs
+}
+
+func unknownSections(prefix string, settings map[string]any, known []string) []error {
+	var errs []error
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !slices.Contains(known, key) {
+			errs = append(errs, fmt.Errorf("%sunknown section %q", prefix, key))
+		}
+	}
+	return errs
+}
+
+// Redacted returns settings with the values of keys, secrets and
+// connection strings replaced, for display
+func Redacted(settings map[string]any) map[string]any {
+	redacted := make(map[string]any, len(settings))
+	for key, value := range settings {
+		switch v := value.(type) {
+		case map[string]any:
+			redacted[key] = Redacted(v)
+		default:
+			if isSecret(key) && fmt.Sprint(v) != "" {
+				redacted[key] = "********"
+			} else {
+				redacted[key] = v
+			}
+		}
+	}
+	return redacted
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range []string{"key", "secret", "token", "password", "dsn"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "(none configured)"
+	}
+	return strings.Join(list, ", ")
 }
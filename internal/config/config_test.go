@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userConfig = `
+aws:
+  profile: dev
+  region: eu-west-1
+pinecone:
+  api_key: pc-key
+defaults:
+  groupBy: DIMENSION=SERVICE
+  metric: [UnblendedCost, AmortizedCost]
+contexts:
+  prod:
+    aws:
+      profile: prod-readonly
+    defaults:
+      printFormat: csv
+`
+
+// setup isolates viper, the working directory and $XDG_CONFIG_HOME, and
+// writes the user configuration
+func setup(t *testing.T, user string) string {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	t.Chdir(dir)
+
+	if user != "" {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "xdg", "ccexplorer"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "xdg", "ccexplorer", FileName), []byte(user), 0600))
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := setup(t, userConfig)
+	require.NoError(t, os.WriteFile(LocalFileName, []byte("aws:\n  region: us-east-1\n"), 0600))
+
+	loaded, err := Load("", "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "xdg", "ccexplorer", FileName), LocalFileName}, loaded.Files)
+	assert.Empty(t, loaded.Context)
+	assert.Equal(t, "dev", viper.GetString("aws.profile"))
+	assert.Equal(t, "us-east-1", viper.GetString("aws.region"), "the working directory overrides the user file")
+	assert.Equal(t, map[string]string{
+		"groupby": "DIMENSION=SERVICE",
+		"metric":  "UnblendedCost,AmortizedCost",
+	}, Defaults())
+	assert.Equal(t, []string{"prod"}, Contexts())
+
+	t.Setenv("PINECONE_API_KEY", "env-key")
+	assert.Equal(t, "env-key", viper.GetString("pinecone.api_key"), "the environment overrides the files")
+}
+
+func TestLoadContext(t *testing.T) {
+	setup(t, userConfig+"current_context: prod\n")
+
+	loaded, err := Load("", "")
+	require.NoError(t, err)
+	assert.Equal(t, "prod", loaded.Context)
+	assert.Equal(t, "prod-readonly", viper.GetString("aws.profile"))
+	assert.Equal(t, "eu-west-1", viper.GetString("aws.region"))
+	assert.Equal(t, "csv", Defaults()["printformat"])
+	assert.Equal(t, "DIMENSION=SERVICE", Defaults()["groupby"])
+
+	viper.Reset()
+	_, err = Load("", "staging")
+	assert.ErrorContains(t, err, `unknown context "staging", expected one of prod`)
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := setup(t, userConfig)
+	file := filepath.Join(dir, "other.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("aws:\n  profile: other\n"), 0600))
+
+	loaded, err := Load(file, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{file}, loaded.Files, "an explicit file replaces the default files")
+	assert.Equal(t, "other", viper.GetString("aws.profile"))
+
+	_, err = Load(filepath.Join(dir, "missing.yaml"), "")
+	assert.Error(t, err)
+}
+
+func TestLoadWithoutFiles(t *testing.T) {
+	setup(t, "")
+
+	loaded, err := Load("", "")
+	require.NoError(t, err)
+	assert.Empty(t, loaded.Files)
+	assert.Empty(t, Defaults())
+}
+
+func TestValidate(t *testing.T) {
+	setup(t, userConfig+"  dev:\n    awss: {}\npinecone_index: x\n")
+
+	_, err := Load("", "")
+	require.NoError(t, err)
+	errs := Validate()
+	require.Len(t, errs, 2)
+	assert.ErrorContains(t, errs[0], `unknown section "pinecone_index"`)
+	assert.ErrorContains(t, errs[1], `context dev: unknown section "awss"`)
+}
+
+func TestRedacted(t *testing.T) {
+	redacted := Redacted(map[string]any{
+		"aws":      map[string]any{"profile": "dev"},
+		"pinecone": map[string]any{"api_key": "pc-key", "index": "https://index"},
+		"pgvector": map[string]any{"dsn": "postgres://user:password@db/costs"},
+		"openai":   map[string]any{"api_key": ""},
+	})
+
+	assert.Equal(t, map[string]any{
+		"aws":      map[string]any{"profile": "dev"},
+		"pinecone": map[string]any{"api_key": "********", "index": "https://index"},
+		"pgvector": map[string]any{"dsn": "********"},
+		"openai":   map[string]any{"api_key": ""},
+	}, redacted)
+}
+
+func TestTemplate(t *testing.T) {
+	example, err := os.ReadFile("../../configs/example-config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, Template, string(example), "configs/example-config.yaml is a copy of the template")
+
+	dir := setup(t, "")
+	file := filepath.Join(dir, FileName)
+	require.NoError(t, os.WriteFile(file, []byte(Template), 0600))
+	_, err = Load(file, "")
+	require.NoError(t, err)
+	assert.Empty(t, Validate())
+}
+
+func TestPresets(t *testing.T) {
+	setup(t, userConfig+`presets:
+  Weekly:
+    description: Weekly services
+    flags:
+      groupBy: DIMENSION=SERVICE
+      excludeDiscounts: true
+      startDate: "{since}"
+    params:
+      since: -7d
+`)
+
+	_, err := Load("", "")
+	require.NoError(t, err)
+	presets := Presets()
+	require.Len(t, presets, 1)
+	assert.Equal(t, "weekly", presets[0].Name)
+	assert.Equal(t, "Weekly services", presets[0].Description)
+	assert.Equal(t, map[string]string{
+		"groupby":          "DIMENSION=SERVICE",
+		"excludediscounts": "true",
+		"startdate":        "{since}",
+	}, presets[0].Flags)
+	assert.Equal(t, map[string]string{"since": "-7d"}, presets[0].Params)
+	assert.Empty(t, Validate())
+}
+
+func TestSavePreset(t *testing.T) {
+	dir := setup(t, "")
+	file := filepath.Join(dir, "config", FileName)
+	weekly := types.Preset{Name: "weekly", Flags: map[string]string{"groupBy": "DIMENSION=SERVICE"}}
+
+	require.NoError(t, SavePreset(file, weekly, false))
+	require.NoError(t, os.WriteFile(file, []byte("# AWS settings\naws:\n  profile: dev\npresets:\n"), 0600))
+	require.NoError(t, SavePreset(file, weekly, false))
+	assert.ErrorContains(t, SavePreset(file, weekly, false), "preset weekly already exists")
+
+	weekly.Description = "Weekly services"
+	weekly.Params = map[string]string{"since": "-7d"}
+	require.NoError(t, SavePreset(file, weekly, true))
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, `# AWS settings
+aws:
+  profile: dev
+presets:
+  weekly:
+    description: Weekly services
+    flags:
+      groupBy: DIMENSION=SERVICE
+    params:
+      since: -7d
+`, string(data))
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// presetEntry is a preset as written to the presets section
+type presetEntry struct {
+	Description string            `yaml:"description,omitempty"`
+	Flags       map[string]string `yaml:"flags"`
+	Params      map[string]string `yaml:"params,omitempty"`
+}
+
+// Presets returns the user presets of the loaded configuration, sorted by
+// name. Names are in lower case.
+func Presets() []types.Preset {
+	var presets []types.Preset
+	for name, value := range viper.GetStringMap(PresetsKey) {
+		settings, _ := value.(map[string]any)
+		presets = append(presets, types.Preset{
+			Name:        name,
+			Description: fmt.Sprint(valueOrEmpty(settings["description"])),
+			Flags:       stringMap(settings["flags"]),
+			Params:      stringMap(settings["params"]),
+		})
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+func stringMap(value any) map[string]string {
+	m, _ := value.(map[string]any)
+	values := make(map[string]string, len(m))
+	for k, v := range m {
+		values[k] = flagValue(v)
+	}
+	return values
+}
+
+func valueOrEmpty(value any) any {
+	if value == nil {
+		return ""
+	}
+	return value
+}
+
+// SavePreset adds p to the presets section of the configuration file at
+// path, creating the file if needed. Comments and the other settings of
+// the file are kept. An existing preset of the same name is only replaced
+// when overwrite is set.
+func SavePreset(path string, p types.Preset, overwrite bool) error {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a map of settings", path)
+	}
+
+	presets := mappingValue(root, PresetsKey)
+	name := strings.ToLower(p.Name)
+	var entry yaml.Node
+	if err := entry.Encode(presetEntry{Description: p.Description, Flags: p.Flags, Params: p.Params}); err != nil {
+		return err
+	}
+	if existing := find(presets, name); existing == nil {
+		presets.Content = append(presets.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, &entry)
+	} else if overwrite {
+		*existing = entry
+	} else {
+		return fmt.Errorf("preset %s already exists in %s", name, path)
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b.Bytes(), 0600)
+}
+
+// mappingValue returns the value of key in the mapping m, adding the key
+// if it is missing. A value that is not a mapping, e.g. an empty section,
+// is turned into an empty mapping.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if value := find(m, key); value != nil {
+		if value.Kind != yaml.MappingNode {
+			*value = yaml.Node{Kind: yaml.MappingNode, HeadComment: value.HeadComment,
+				FootComment: value.FootComment}
+		}
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// find returns the value of key in the mapping m, ignoring case, or nil
+func find(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if strings.EqualFold(m.Content[i].Value, key) {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
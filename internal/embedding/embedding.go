@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/openai"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const (
+	OpenAI = openai.OpenAI
+	Azure  = openai.Azure
+	Local  = openai.Local
+	Hash   = "hash"
+
+	// DefaultOpenAIModel is the model used before the model was configurable,
+	// and the model assumed for stored vectors that do not record one
+	DefaultOpenAIModel    = "text-embedding-ada-002"
+	DefaultLocalBaseURL   = openai.DefaultLocalBaseURL
+	DefaultHashDimensions = 256
+)
+
+// Providers lists the supported embedding providers
+var Providers = []string{OpenAI, Azure, Local, Hash}
+
+// Validate checks that the settings required by the selected provider are
+// present
+func Validate(cfg types.EmbeddingConfig) error {
+	if cfg.Dimensions < 0 {
+		return fmt.Errorf("embedding dimensions must not be negative")
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case OpenAI:
+		if cfg.APIKey == "" {
+			return fmt.Errorf("openai embeddings require OPENAI_API_KEY")
+		}
+	case Azure:
+		if cfg.APIKey == "" || cfg.BaseURL == "" {
+			return fmt.Errorf("azure embeddings require AZURE_OPENAI_API_KEY and AZURE_OPENAI_ENDPOINT")
+		}
+	case Local:
+		if cfg.Model == "" {
+			return fmt.Errorf("local embeddings require a model name, e.g. --embeddingModel nomic-embed-text")
+		}
+	case Hash:
+	default:
+		return fmt.Errorf("unknown embedding provider %q, expected one of %s",
+			cfg.Provider, strings.Join(Providers, ", "))
+	}
+	return nil
+}
+
+// New returns the embedder selected by cfg.Provider
+func New(cfg types.EmbeddingConfig) (ports.Embedder, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(cfg.Provider) {
+	case Hash:
+		return NewHashEmbedder(cfg.Dimensions), nil
+	case Local:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultLocalBaseURL
+		}
+	default:
+		if cfg.Model == "" {
+			cfg.Model = DefaultOpenAIModel
+		}
+	}
+	return openai.NewEmbedder(cfg), nil
+}
+
+// ModelOf returns the embedding model recorded in metadata. Documents written
+// before the model was recorded were embedded with DefaultOpenAIModel.
+func ModelOf(m types.VectorStoreItemMetadata) string {
+	if m.EmbeddingModel == "" {
+		return DefaultOpenAIModel
+	}
+	return m.EmbeddingModel
+}
@@ -0,0 +1,122 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.EmbeddingConfig
+		wantErr bool
+	}{
+		{"openai", types.EmbeddingConfig{Provider: "openai", APIKey: "sk"}, false},
+		{"openai without key", types.EmbeddingConfig{Provider: "openai"}, true},
+		{"azure without endpoint", types.EmbeddingConfig{Provider: "azure", APIKey: "k"}, true},
+		{"local", types.EmbeddingConfig{Provider: "LOCAL", Model: "nomic-embed-text"}, false},
+		{"local without model", types.EmbeddingConfig{Provider: "local"}, true},
+		{"hash", types.EmbeddingConfig{Provider: "hash"}, false},
+		{"negative dimensions", types.EmbeddingConfig{Provider: "hash", Dimensions: -1}, true},
+		{"unknown", types.EmbeddingConfig{Provider: "cohere"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHashEmbedder(t *testing.T) {
+	e := NewHashEmbedder(64)
+	assert.Equal(t, "hash-64", e.Model())
+
+	vectors, err := e.Embed(context.Background(), []string{
+		"Amazon S3 storage", "amazon s3, STORAGE", "EC2 compute",
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, 3)
+	assert.Len(t, vectors[0], 64)
+	assert.Equal(t, vectors[0], vectors[1], "case and punctuation are ignored")
+	assert.NotEqual(t, vectors[0], vectors[2])
+
+	var norm float32
+	for _, x := range vectors[0] {
+		norm += x * x
+	}
+	assert.InDelta(t, 1, norm, 1e-5)
+}
+
+type embeddingRequest struct {
+	Input      []string `json:"input"`
+	Model      string   `json:"model"`
+	Dimensions int      `json:"dimensions"`
+}
+
+func embeddingServer(t *testing.T, got *embeddingRequest, path *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.RequestURI()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(got))
+		// return the embeddings out of order to check they are reordered
+		_, _ = io.WriteString(w, `{"object":"list","data":[
+			{"object":"embedding","index":1,"embedding":[0,1]},
+			{"object":"embedding","index":0,"embedding":[1,0]}]}`)
+	}))
+}
+
+func TestOpenAICompatibleEmbedder(t *testing.T) {
+	var got embeddingRequest
+	var path string
+	srv := embeddingServer(t, &got, &path)
+	defer srv.Close()
+
+	e, err := New(types.EmbeddingConfig{
+		Provider:   "local",
+		Model:      "nomic-embed-text",
+		Dimensions: 2,
+		BaseURL:    srv.URL + "/v1/",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "nomic-embed-text", e.Model())
+
+	vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{1, 0}, {0, 1}}, vectors)
+	assert.Equal(t, "/v1/embeddings", path)
+	assert.Equal(t, embeddingRequest{Input: []string{"a", "b"}, Model: "nomic-embed-text", Dimensions: 2}, got)
+}
+
+func TestAzureEmbedder(t *testing.T) {
+	var got embeddingRequest
+	var path string
+	srv := embeddingServer(t, &got, &path)
+	defer srv.Close()
+
+	e, err := New(types.EmbeddingConfig{
+		Provider:        "azure",
+		APIKey:          "az-key",
+		BaseURL:         srv.URL,
+		AzureDeployment: "costs-embeddings",
+		AzureAPIVersion: "2024-02-01",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOpenAIModel, e.Model())
+
+	_, err = e.Embed(context.Background(), []string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "/openai/deployments/costs-embeddings/embeddings?api-version=2024-02-01", path)
+}
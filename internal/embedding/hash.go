@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// HashEmbedder derives vectors from the words of a text by feature hashing.
+// It needs no network access and always returns the same vector for the same
+// text, which makes it suitable for tests and offline experiments.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = DefaultHashDimensions
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+// Model returns a name that includes the dimension, as vectors of different
+// sizes are not comparable
+func (h *HashEmbedder) Model() string {
+	return fmt.Sprintf("%s-%d", Hash, h.dimensions)
+}
+
+// Embed returns one L2-normalized vector per text
+func (h *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vectors[i] = h.embed(text)
+	}
+	return vectors, nil
+}
+
+func (h *HashEmbedder) embed(text string) []float32 {
+	v := make([]float32, h.dimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for _, w := range words {
+		f := fnv.New64a()
+		_, _ = f.Write([]byte(w))
+		sum := f.Sum64()
+
+		sign := float32(1)
+		if sum>>63 == 1 {
+			sign = -1
+		}
+		v[sum%uint64(h.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, x := range v {
+		norm += float64(x * x)
+	}
+	if norm == 0 {
+		return v
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
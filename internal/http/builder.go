@@ -28,7 +28,8 @@ func (b *HttpRequestBuilder) Build(ctx context.Context, method, url string,
 		return http.NewRequestWithContext(ctx, method, url, nil)
 	}
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		method,
 		url,
 		payload,
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodyBytes bounds the response body kept in a StatusError
+const maxErrorBodyBytes = 2048
+
+// StatusError is returned for a non-2xx response and keeps the response
+// body, which usually explains what went wrong
+type StatusError struct {
+	Service    string
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the Retry-After header, if any
+	RetryAfter time.Duration
+}
+
+func (e StatusError) Error() string {
+	msg := fmt.Sprintf("%s returned status %d", e.Service, e.StatusCode)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Temporary reports whether the request may succeed when retried, i.e. it
+// was rate limited or failed on the server
+func (e StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// NewStatusError reads the body and Retry-After header of res
+func NewStatusError(service string, res *http.Response) StatusError {
+	b, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodyBytes))
+	return StatusError{
+		Service:    service,
+		StatusCode: res.StatusCode,
+		Body:       strings.TrimSpace(string(b)),
+		RetryAfter: retryAfter(res.Header.Get("Retry-After")),
+	}
+}
+
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// RetryPolicy retries temporary failures with exponential backoff and
+// jitter
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 6,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// Do calls fn until it succeeds, fails with an error that is not temporary,
+// the attempts are used up or ctx is done
+func (p RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+
+		var statusErr StatusError
+		if err == nil || !errors.As(err, &statusErr) || !statusErr.Temporary() ||
+			attempt >= p.MaxAttempts {
+			return err
+		}
+
+		timer := time.NewTimer(p.delay(attempt, statusErr.RetryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the wait before retry number attempt, preferring the delay
+// requested by the server
+func (p RetryPolicy) delay(attempt int, requested time.Duration) time.Duration {
+	if requested > 0 {
+		return min(requested, p.MaxDelay)
+	}
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || backoff > p.MaxDelay {
+		backoff = p.MaxDelay
+	}
+	// jitter spreads out the retries of concurrent workers
+	return backoff/2 + rand.N(backoff/2+1)
+}
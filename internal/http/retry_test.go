@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetries = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetryPolicy(t *testing.T) {
+	t.Run("retries rate limits and server errors", func(t *testing.T) {
+		calls := 0
+		err := fastRetries.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return StatusError{Service: "pinecone", StatusCode: http.StatusTooManyRequests}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		err := fastRetries.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return StatusError{Service: "qdrant", StatusCode: http.StatusBadGateway, Body: "upstream down"}
+		})
+		assert.EqualError(t, err, "qdrant returned status 502: upstream down")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		calls := 0
+		err := fastRetries.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return StatusError{StatusCode: http.StatusBadRequest}
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+
+		calls = 0
+		_ = fastRetries.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errors.New("connection refused")
+		})
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("prefers the requested delay up to the maximum", func(t *testing.T) {
+		assert.Equal(t, 5*time.Millisecond, fastRetries.delay(1, time.Minute))
+		for attempt := 1; attempt < 70; attempt++ {
+			d := fastRetries.delay(attempt, 0)
+			assert.Positive(t, d)
+			assert.LessOrEqual(t, d, fastRetries.MaxDelay)
+		}
+	})
+}
+
+func TestNewStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, "  {\"message\":\"rate limited\"}\n")
+	}))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	statusErr := NewStatusError("pinecone", res)
+	assert.Equal(t, `{"message":"rate limited"}`, statusErr.Body)
+	assert.Equal(t, 2*time.Second, statusErr.RetryAfter)
+	assert.True(t, statusErr.Temporary())
+}
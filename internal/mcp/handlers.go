@@ -2,11 +2,15 @@ package mcp
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cduggn/ccexplorer/internal/awsservice"
+	"github.com/cduggn/ccexplorer/internal/compare"
 	"github.com/cduggn/ccexplorer/internal/types"
 	"github.com/cduggn/ccexplorer/internal/utils"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -34,23 +38,219 @@ func (s *Server) handleGetCostAndUsage(ctx context.Context, request mcp.CallTool
 		return nil, fmt.Errorf("failed to translate parameters: %w", err)
 	}
 
+	ctx, cancel := withTimeout(ctx, mcpParams.TimeoutSeconds)
+	defer cancel()
+	progress := s.newProgressReporter(request, 1)
+
 	// Call AWS Cost Explorer service
-	result, err := s.awsService.GetCostAndUsage(ctx, internalRequest)
+	report, stopped, err := s.executeQuery(progress.startChunk(ctx, "cost and usage"),
+		&internalRequest, mcpParams.AllowPartial)
 	if err != nil {
-		return nil, fmt.Errorf("AWS service error: %w", err)
+		return nil, err
 	}
 
 	// Transform the response to a format suitable for MCP
-	response := utils.ToCostAndUsageOutputType(result, internalRequest)
+	response := buildCostAndUsageResult(report, internalRequest, mcpParams.Output)
+	if stopped != nil {
+		markPartial(&response, progress.pagesFetched(), stopped)
+	}
+
+	// Encode within the response byte budget
+	return renderCostAndUsageResult(response, mcpParams.Output.Format,
+		s.responseByteBudget)
+}
+
+// handleComparePeriods handles the compare_periods MCP tool call
+func (s *Server) handleComparePeriods(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	slog.Info("Handling compare_periods request", "arguments", request.Params.Arguments)
+
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid arguments type")
+	}
+
+	params, err := s.parseComparePeriodsParams(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	ctx, cancel := withTimeout(ctx, params.Query.TimeoutSeconds)
+	defer cancel()
+	progress := s.newProgressReporter(request, 2)
+
+	baseline, err := s.queryCostAndUsage(progress.startChunk(ctx, "baseline"),
+		params.Query, params.Baseline)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.queryCostAndUsage(progress.startChunk(ctx, "current"),
+		params.Query, params.Current)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := compare.Periods(baseline, current, params.Query.Metrics[0])
+	return renderComparison(comparison, params.Query.Output.MaxRows,
+		s.responseByteBudget)
+}
+
+// handleSemanticSearchCosts handles the semantic_search_costs MCP tool call
+func (s *Server) handleSemanticSearchCosts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	slog.Info("Handling semantic_search_costs request", "arguments", request.Params.Arguments)
+
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid arguments type")
+	}
 
-	// Convert to JSON for MCP response
-	responseJSON, err := json.MarshalIndent(response, "", "  ")
+	params, err := parseSemanticSearchParams(args)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal response: %w", err)
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	matches, err := s.costSearcher.Search(ctx, params.Query, params.TopK, params.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("vector store error: %w", err)
+	}
+
+	return renderSemanticSearch(types.MCPSemanticSearchResult{
+		Query:   params.Query,
+		Matches: matches,
+	}, s.responseByteBudget)
+}
+
+// queryCostAndUsage issues a single cost and usage query for the given range
+func (s *Server) queryCostAndUsage(ctx context.Context, query types.MCPToolParameters,
+	period types.Time) (types.CostAndUsageOutputType, error) {
+
+	query.StartDate = period.Start
+	query.EndDate = period.End
+
+	internalRequest, err := s.translateMCPToInternalRequest(query)
+	if err != nil {
+		return types.CostAndUsageOutputType{}, fmt.Errorf("failed to translate parameters: %w", err)
+	}
+
+	// a comparison of partly fetched periods would be misleading
+	report, stopped, err := s.executeQuery(ctx, &internalRequest, false)
+	if err == nil && stopped != nil {
+		return types.CostAndUsageOutputType{}, stopped
+	}
+	return report, err
+}
+
+// executeQuery applies the server policy to req and issues the billable
+// Cost Explorer calls, charging the call budget for every page. When the
+// budget runs out after some pages were fetched, or allowPartial is set and
+// ctx ends after some pages were fetched, those pages are returned with the
+// reason the query stopped.
+func (s *Server) executeQuery(ctx context.Context, req *types.CostAndUsageRequestType,
+	allowPartial bool) (report types.CostAndUsageOutputType, stopped error, err error) {
+
+	if err := s.guard.authorize(ctx, req); err != nil {
+		return types.CostAndUsageOutputType{}, nil, err
+	}
+
+	metered := awsservice.WithPageGate(ctx, s.guard.meter(sessionID(ctx)))
+	result, err := s.awsService.GetCostAndUsage(metered, *req)
+	if err != nil {
+		var policyErr PolicyError
+		switch {
+		case errors.As(err, &policyErr) && result != nil:
+			slog.Info("Returning partial cost and usage result", "reason", policyErr)
+			return utils.ToCostAndUsageOutputType(result, *req), policyErr, nil
+		case errors.As(err, &policyErr):
+			return types.CostAndUsageOutputType{}, nil, policyErr
+		case allowPartial && result != nil && ctx.Err() != nil:
+			slog.Info("Returning partial cost and usage result", "reason", ctx.Err())
+			return utils.ToCostAndUsageOutputType(result, *req), ctx.Err(), nil
+		}
+		return types.CostAndUsageOutputType{}, nil, fmt.Errorf("AWS service error: %w", err)
 	}
 
-	// Return MCP tool result
-	return mcp.NewToolResultText(string(responseJSON)), nil
+	return utils.ToCostAndUsageOutputType(result, *req), nil, nil
+}
+
+// withTimeout bounds ctx by the timeout_seconds argument, if one was given
+func withTimeout(ctx context.Context, seconds int) (context.Context, context.CancelFunc) {
+	if seconds <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+}
+
+// parseComparePeriodsParams parses the compare_periods tool arguments
+func (s *Server) parseComparePeriodsParams(args map[string]interface{}) (types.MCPComparePeriodsParameters, error) {
+	var params types.MCPComparePeriodsParameters
+
+	ranges := []struct {
+		name  string
+		value *string
+	}{
+		{"baseline_start", &params.Baseline.Start},
+		{"baseline_end", &params.Baseline.End},
+		{"current_start", &params.Current.Start},
+		{"current_end", &params.Current.End},
+	}
+	for _, r := range ranges {
+		v, ok := args[r.name].(string)
+		if !ok || v == "" {
+			return params, fmt.Errorf("%s is required and must be a string", r.name)
+		}
+		*r.value = v
+	}
+
+	if err := s.parseQueryParams(args, &params.Query); err != nil {
+		return params, err
+	}
+
+	// compare_periods ranks by a single metric
+	if metric, ok := args["metric"].(string); ok && metric != "" {
+		params.Query.Metrics = []string{metric}
+	}
+	params.Query.Metrics = params.Query.Metrics[:1]
+
+	return params, nil
+}
+
+// parseSemanticSearchParams parses the semantic_search_costs tool arguments
+func parseSemanticSearchParams(args map[string]interface{}) (types.MCPSemanticSearchParameters, error) {
+	var params types.MCPSemanticSearchParameters
+
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return params, fmt.Errorf("query is required and must be a string")
+	}
+	params.Query = query
+
+	topK, err := parseNonNegativeInt(args, "top_k")
+	if err != nil {
+		return params, err
+	}
+	switch {
+	case topK == 0:
+		params.TopK = defaultSemanticSearchTopK
+	case topK > maxSemanticSearchTopK:
+		return params, fmt.Errorf("top_k must not exceed %d", maxSemanticSearchTopK)
+	default:
+		params.TopK = topK
+	}
+
+	params.Filter.Start, _ = args["start_date"].(string)
+	params.Filter.End, _ = args["end_date"].(string)
+	if (params.Filter.Start == "") != (params.Filter.End == "") {
+		return params, fmt.Errorf("start_date and end_date must be given together")
+	}
+
+	if dimensions, ok := args["dimensions"].(string); ok {
+		for _, d := range strings.Split(dimensions, ",") {
+			if d = strings.ToUpper(strings.TrimSpace(d)); d != "" {
+				params.Filter.Dimensions = append(params.Filter.Dimensions, d)
+			}
+		}
+	}
+
+	return params, nil
 }
 
 // parseGetCostAndUsageParams parses the MCP tool arguments into MCPToolParameters
@@ -70,6 +270,20 @@ func (s *Server) parseGetCostAndUsageParams(args map[string]interface{}) (types.
 		return params, fmt.Errorf("end_date is required and must be a string")
 	}
 
+	if err := s.parseQueryParams(args, &params); err != nil {
+		return params, err
+	}
+
+	if allowPartial, ok := args["allow_partial"].(bool); ok {
+		params.AllowPartial = allowPartial
+	}
+
+	return params, nil
+}
+
+// parseQueryParams parses the optional arguments shared by every tool that
+// issues a cost and usage query
+func (s *Server) parseQueryParams(args map[string]interface{}, params *types.MCPToolParameters) error {
 	// Optional parameters with defaults
 	if granularity, ok := args["granularity"].(string); ok {
 		params.Granularity = granularity
@@ -141,6 +355,77 @@ func (s *Server) parseGetCostAndUsageParams(args map[string]interface{}) (types.
 		params.ExcludeDiscounts = excludeDiscounts
 	}
 
-	return params, nil
+	output, err := parseOutputOptions(args)
+	if err != nil {
+		return err
+	}
+	params.Output = output
+
+	if params.TimeoutSeconds, err = parseNonNegativeInt(args, "timeout_seconds"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// parseOutputOptions parses the result shaping arguments shared by tools
+// returning cost rows
+func parseOutputOptions(args map[string]interface{}) (types.MCPOutputOptions, error) {
+	var opts types.MCPOutputOptions
+	var err error
+
+	if opts.MaxRows, err = parseNonNegativeInt(args, "max_rows"); err != nil {
+		return opts, err
+	}
+	if opts.TopN, err = parseNonNegativeInt(args, "top_n"); err != nil {
+		return opts, err
+	}
+
+	if summaryOnly, ok := args["summary_only"].(bool); ok {
+		opts.SummaryOnly = summaryOnly
+	}
+
+	opts.Format = formatJSON
+	if format, ok := args["format"].(string); ok && format != "" {
+		format = strings.ToLower(format)
+		if format != formatJSON && format != formatCSV {
+			return opts, fmt.Errorf("format must be one of json, csv")
+		}
+		opts.Format = format
+	}
+
+	return opts, nil
+}
+
+// parseNonNegativeInt reads an optional integer argument, accepting both JSON
+// numbers and numeric strings
+func parseNonNegativeInt(args map[string]interface{}, name string) (int, error) {
+	var n int
+	switch v := args[name].(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("%s must be an integer", name)
+		}
+		n = int(v)
+	case int:
+		n = v
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s must be an integer", name)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("%s must be an integer", name)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative", name)
+	}
+	return n, nil
 }
 
@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/cduggn/ccexplorer/internal/awsservice"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAWSService returns canned groups keyed by the requested start date,
+// fetched in pages when pages is set. Like the real service, it checks the
+// page gate before each page and returns what it has alongside the error once
+// ctx is done.
+type fakeAWSService struct {
+	groups       map[string]map[string]string
+	pages        int
+	pagesFetched int
+	requests     []types.CostAndUsageRequestType
+}
+
+func (f *fakeAWSService) GetCostAndUsage(ctx context.Context,
+	req types.CostAndUsageRequestType) (*costexplorer.GetCostAndUsageOutput, error) {
+	f.requests = append(f.requests, req)
+
+	for page := 1; page <= max(f.pages, 1); page++ {
+		if err := awsservice.CheckPage(ctx, page); err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			return f.output(req), err
+		}
+		f.pagesFetched++
+	}
+	return f.output(req), ctx.Err()
+}
+
+func (f *fakeAWSService) output(req types.CostAndUsageRequestType) *costexplorer.GetCostAndUsageOutput {
+
+	result := cetypes.ResultByTime{
+		TimePeriod: &cetypes.DateInterval{
+			Start: aws.String(req.Time.Start),
+			End:   aws.String(req.Time.End),
+		},
+	}
+	for key, amount := range f.groups[req.Time.Start] {
+		result.Groups = append(result.Groups, cetypes.Group{
+			Keys: []string{key},
+			Metrics: map[string]cetypes.MetricValue{
+				req.Metrics[0]: {Amount: aws.String(amount), Unit: aws.String("USD")},
+			},
+		})
+	}
+	return &costexplorer.GetCostAndUsageOutput{
+		ResultsByTime: []cetypes.ResultByTime{result},
+	}
+}
+
+func (f *fakeAWSService) GetCostForecast(ctx context.Context,
+	req types.GetCostForecastRequest) (*costexplorer.GetCostForecastOutput, error) {
+	return &costexplorer.GetCostForecastOutput{}, nil
+}
+
+func callTool(args map[string]interface{}) mcp.CallToolRequest {
+	var req mcp.CallToolRequest
+	req.Params.Arguments = args
+	return req
+}
+
+func TestHandleComparePeriods(t *testing.T) {
+	aws := &fakeAWSService{groups: map[string]map[string]string{
+		"2024-01-01": {"Amazon EC2": "100", "AWS Glue": "5"},
+		"2024-02-01": {"Amazon EC2": "120", "Amazon Bedrock": "7"},
+	}}
+	s := NewServer(aws)
+
+	res, err := s.handleComparePeriods(context.Background(), callTool(map[string]interface{}{
+		"baseline_start": "2024-01-01",
+		"baseline_end":   "2024-02-01",
+		"current_start":  "2024-02-01",
+		"current_end":    "2024-03-01",
+		"group_by":       "SERVICE",
+	}))
+	require.NoError(t, err)
+	require.Len(t, aws.requests, 2)
+	assert.Equal(t, []string{"SERVICE"}, aws.requests[0].GroupBy)
+
+	var result types.MCPComparisonResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	assert.Equal(t, 22.0, result.AbsoluteDelta)
+	require.Len(t, result.Changed, 1)
+	assert.Equal(t, 20.0, result.Changed[0].AbsoluteDelta)
+	require.Len(t, result.NewKeys, 1)
+	require.Len(t, result.DisappearedKeys, 1)
+	assert.False(t, result.Truncated)
+}
+
+func TestParseComparePeriodsParams(t *testing.T) {
+	s := &Server{}
+
+	params, err := s.parseComparePeriodsParams(map[string]interface{}{
+		"baseline_start": "2024-01-01",
+		"baseline_end":   "2024-02-01",
+		"current_start":  "2024-02-01",
+		"current_end":    "2024-03-01",
+		"metric":         "AmortizedCost",
+		"group_by":       "SERVICE,TAG:Project",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.Time{Start: "2024-01-01", End: "2024-02-01"}, params.Baseline)
+	assert.Equal(t, []string{"AmortizedCost"}, params.Query.Metrics)
+	assert.Equal(t, []string{"SERVICE", "TAG:Project"}, params.Query.GroupBy)
+
+	_, err = s.parseComparePeriodsParams(map[string]interface{}{
+		"baseline_start": "2024-01-01",
+	})
+	assert.Error(t, err)
+}
+
+func TestRenderComparisonLimitsRows(t *testing.T) {
+	c := types.PeriodComparison{
+		Changed: []types.KeyDelta{
+			{Keys: []string{"a"}, AbsoluteDelta: 3},
+			{Keys: []string{"b"}, AbsoluteDelta: 2},
+			{Keys: []string{"c"}, AbsoluteDelta: 1},
+		},
+	}
+
+	res, err := renderComparison(c, 1, DefaultResponseByteBudget)
+	require.NoError(t, err)
+
+	var result types.MCPComparisonResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Changed, 1)
+	assert.Equal(t, []string{"a"}, result.Changed[0].Keys)
+	assert.Len(t, result.Notes, 1)
+}
+
+func TestHandleGetCostAndUsagePartialResults(t *testing.T) {
+	aws := &fakeAWSService{groups: map[string]map[string]string{
+		"2024-01-01": {"Amazon EC2": "100"},
+	}}
+	s := NewServer(aws)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	args := map[string]interface{}{
+		"start_date": "2024-01-01",
+		"end_date":   "2024-02-01",
+		"group_by":   "SERVICE",
+	}
+
+	_, err := s.handleGetCostAndUsage(ctx, callTool(args))
+	assert.Error(t, err)
+
+	args["allow_partial"] = true
+	res, err := s.handleGetCostAndUsage(ctx, callTool(args))
+	require.NoError(t, err)
+
+	var result types.MCPCostAndUsageResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	assert.True(t, result.Truncated)
+	require.Len(t, result.Rows, 1)
+	require.NotEmpty(t, result.Notes)
+	assert.Contains(t, result.Notes[len(result.Notes)-1], partialNotePrefix)
+}
+
+func TestParseGetCostAndUsageParamsCancellation(t *testing.T) {
+	s := &Server{}
+
+	params, err := s.parseGetCostAndUsageParams(map[string]interface{}{
+		"start_date":      "2024-01-01",
+		"end_date":        "2024-02-01",
+		"timeout_seconds": float64(30),
+		"allow_partial":   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 30, params.TimeoutSeconds)
+	assert.True(t, params.AllowPartial)
+
+	_, err = s.parseGetCostAndUsageParams(map[string]interface{}{
+		"start_date":      "2024-01-01",
+		"end_date":        "2024-02-01",
+		"timeout_seconds": float64(-1),
+	})
+	assert.Error(t, err)
+}
+
+type fakeCostSearcher struct {
+	topK    int
+	filter  types.VectorQueryFilter
+	matches []types.VectorQueryMatch
+}
+
+func (f *fakeCostSearcher) Search(ctx context.Context, text string, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+	f.topK, f.filter = topK, filter
+	return f.matches, nil
+}
+
+func TestHandleSemanticSearchCosts(t *testing.T) {
+	searcher := &fakeCostSearcher{matches: []types.VectorQueryMatch{
+		{ID: "a", Score: 0.9, PageContent: "EC2 in January"},
+		{ID: "b", Score: 0.8, PageContent: "S3 in January"},
+	}}
+	s := NewServer(&fakeAWSService{}, WithCostSearcher(searcher))
+
+	res, err := s.handleSemanticSearchCosts(context.Background(), callTool(map[string]interface{}{
+		"query":      "compute spend",
+		"start_date": "2024-01-01",
+		"end_date":   "2024-02-01",
+		"dimensions": "service, usage_type",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, defaultSemanticSearchTopK, searcher.topK)
+	assert.Equal(t, types.VectorQueryFilter{
+		Start:      "2024-01-01",
+		End:        "2024-02-01",
+		Dimensions: []string{"SERVICE", "USAGE_TYPE"},
+	}, searcher.filter)
+
+	var result types.MCPSemanticSearchResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	require.Len(t, result.Matches, 2)
+	assert.Equal(t, "EC2 in January", result.Matches[0].PageContent)
+}
+
+func TestParseSemanticSearchParams(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+	}{
+		{"missing query", map[string]interface{}{}},
+		{"top_k too large", map[string]interface{}{"query": "x", "top_k": float64(maxSemanticSearchTopK + 1)}},
+		{"start without end", map[string]interface{}{"query": "x", "start_date": "2024-01-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSemanticSearchParams(tt.args)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/awsservice"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	linkedAccountDimension = "LINKED_ACCOUNT"
+	serviceDimension       = "SERVICE"
+	defaultSessionID       = "stdio"
+)
+
+// Policy restricts what MCP clients may query. The zero value allows
+// everything.
+type Policy struct {
+	// AllowedAccounts limits results to these linked accounts
+	AllowedAccounts []string
+	// AllowedServices limits results to these services
+	AllowedServices []string
+	// MaxSpan is the longest date range allowed per granularity
+	MaxSpan map[string]time.Duration
+	// MaxCallsPerSession caps the billable Cost Explorer calls of a session
+	MaxCallsPerSession int
+	// MaxCallsPerMinute caps the billable Cost Explorer calls across sessions
+	MaxCallsPerMinute int
+	// DeniedGroupBy lists dimensions, or TAG:<key>, that may not be grouped by
+	DeniedGroupBy []string
+}
+
+// PolicyError is returned when a tool invocation violates the server policy
+type PolicyError struct {
+	Reason string
+}
+
+func (e PolicyError) Error() string {
+	return "request denied by policy: " + e.Reason
+}
+
+// guard enforces a Policy and tracks the call budget
+type guard struct {
+	policy Policy
+	now    func() time.Time
+
+	mu       sync.Mutex
+	sessions map[string]int
+	calls    []time.Time
+}
+
+func newGuard(p Policy) *guard {
+	return &guard{
+		policy:   p,
+		now:      time.Now,
+		sessions: make(map[string]int),
+	}
+}
+
+// authorize validates req against the policy and narrows it to the allowed
+// accounts and services. The call budget is charged per page by meter.
+func (g *guard) authorize(ctx context.Context, req *types.CostAndUsageRequestType) error {
+	if err := g.checkGroupBy(req); err != nil {
+		return err
+	}
+	if err := g.checkSpan(req); err != nil {
+		return err
+	}
+	if err := restrict(req, linkedAccountDimension, g.policy.AllowedAccounts); err != nil {
+		return err
+	}
+	if err := restrict(req, serviceDimension, g.policy.AllowedServices); err != nil {
+		return err
+	}
+	return nil
+}
+
+// meter returns a page gate that consumes one billable call of the budget of
+// session before each Cost Explorer page is requested
+func (g *guard) meter(session string) awsservice.PageGate {
+	return func(page int) error {
+		return g.consume(session)
+	}
+}
+
+// restrictsData reports whether the policy limits which accounts or services
+// may be returned
+func (g *guard) restrictsData() bool {
+	return len(g.policy.AllowedAccounts) > 0 || len(g.policy.AllowedServices) > 0
+}
+
+func (g *guard) checkGroupBy(req *types.CostAndUsageRequestType) error {
+	for _, d := range req.GroupBy {
+		if containsFold(g.policy.DeniedGroupBy, d) {
+			return PolicyError{Reason: fmt.Sprintf("grouping by %s is not allowed", d)}
+		}
+	}
+	for _, t := range req.GroupByTag {
+		if containsFold(g.policy.DeniedGroupBy, "TAG:"+t) {
+			return PolicyError{Reason: fmt.Sprintf("grouping by TAG:%s is not allowed", t)}
+		}
+	}
+	return nil
+}
+
+func (g *guard) checkSpan(req *types.CostAndUsageRequestType) error {
+	limit, ok := g.policy.MaxSpan[req.Granularity]
+	if !ok || limit <= 0 {
+		return nil
+	}
+
+	start, err := parseDate(req.Time.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseDate(req.Time.End)
+	if err != nil {
+		return err
+	}
+
+	if span := end.Sub(start); span > limit {
+		return PolicyError{Reason: fmt.Sprintf(
+			"%s queries may span at most %s, requested %s",
+			req.Granularity, formatSpan(limit), formatSpan(span))}
+	}
+	return nil
+}
+
+// restrict injects an allow-list filter for dimension. An explicit filter on
+// the same dimension must name an allowed value.
+func restrict(req *types.CostAndUsageRequestType, dimension string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	if v, ok := req.DimensionFilter[dimension]; ok && !slices.Contains(allowed, v) {
+		return PolicyError{Reason: fmt.Sprintf("%s %q is not allowed", dimension, v)}
+	}
+
+	if req.DimensionAllowList == nil {
+		req.DimensionAllowList = make(map[string][]string)
+	}
+	req.DimensionAllowList[dimension] = append([]string(nil), allowed...)
+	return nil
+}
+
+func (g *guard) consume(session string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if limit := g.policy.MaxCallsPerSession; limit > 0 && g.sessions[session] >= limit {
+		return PolicyError{Reason: fmt.Sprintf(
+			"session budget of %d Cost Explorer calls exhausted", limit)}
+	}
+
+	if limit := g.policy.MaxCallsPerMinute; limit > 0 {
+		cutoff := g.now().Add(-time.Minute)
+		recent := g.calls[:0]
+		for _, t := range g.calls {
+			if t.After(cutoff) {
+				recent = append(recent, t)
+			}
+		}
+		g.calls = recent
+		if len(g.calls) >= limit {
+			return PolicyError{Reason: fmt.Sprintf(
+				"rate limit of %d Cost Explorer calls per minute reached", limit)}
+		}
+		g.calls = append(g.calls, g.now())
+	}
+
+	g.sessions[session]++
+	return nil
+}
+
+// auditedHandler records every invocation of a tool with its decision, and
+// converts policy violations into tool errors the model can read
+func (s *Server) auditedHandler(tool string, h server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		started := time.Now()
+		result, err := h(ctx, request)
+
+		decision, reason := "allowed", ""
+		var policyErr PolicyError
+		switch {
+		case errors.As(err, &policyErr):
+			decision, reason = "denied", policyErr.Reason
+			result, err = mcp.NewToolResultError(policyErr.Error()), nil
+		case err != nil:
+			decision, reason = "error", err.Error()
+		}
+
+		s.auditLogger.Info("tool invocation",
+			"tool", tool,
+			"session", sessionID(ctx),
+			"decision", decision,
+			"reason", reason,
+			"arguments", request.Params.Arguments,
+			"duration_ms", time.Since(started).Milliseconds(),
+		)
+		return result, err
+	}
+}
+
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		if id := session.SessionID(); id != "" {
+			return id
+		}
+	}
+	return defaultSessionID
+}
+
+// parseDate accepts both the DAILY/MONTHLY and the HOURLY date formats
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC3339", s)
+}
+
+// ParseMaxSpan parses limits such as "HOURLY=14,DAILY=93" where each value
+// is a number of days
+func ParseMaxSpan(s string) (map[string]time.Duration, error) {
+	spans := make(map[string]time.Duration)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid max span %q, expected GRANULARITY=DAYS", part)
+		}
+		granularity := strings.ToUpper(strings.TrimSpace(kv[0]))
+		days, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || days <= 0 {
+			return nil, fmt.Errorf("invalid number of days in max span %q", part)
+		}
+		spans[granularity] = time.Duration(days) * 24 * time.Hour
+	}
+	return spans, nil
+}
+
+func formatSpan(d time.Duration) string {
+	days := d.Hours() / 24
+	if days == float64(int(days)) {
+		return fmt.Sprintf("%d days", int(days))
+	}
+	return fmt.Sprintf("%.1f days", days)
+}
+
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
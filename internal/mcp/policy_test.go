@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuardAuthorize(t *testing.T) {
+	policy := Policy{
+		AllowedAccounts: []string{"111111111111", "222222222222"},
+		MaxSpan:         map[string]time.Duration{"DAILY": 31 * 24 * time.Hour},
+		DeniedGroupBy:   []string{"RESOURCE_ID", "TAG:Owner"},
+	}
+
+	tests := []struct {
+		name    string
+		request types.CostAndUsageRequestType
+		wantErr bool
+	}{
+		{
+			name: "allowed request",
+			request: types.CostAndUsageRequestType{
+				Granularity: "DAILY",
+				GroupBy:     []string{"SERVICE"},
+				Time:        types.Time{Start: "2024-01-01", End: "2024-01-31"},
+			},
+		},
+		{
+			name: "denied dimension",
+			request: types.CostAndUsageRequestType{
+				Granularity: "MONTHLY",
+				GroupBy:     []string{"resource_id"},
+				Time:        types.Time{Start: "2024-01-01", End: "2024-03-01"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "denied tag",
+			request: types.CostAndUsageRequestType{
+				Granularity: "MONTHLY",
+				GroupByTag:  []string{"Owner"},
+				Time:        types.Time{Start: "2024-01-01", End: "2024-03-01"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "span too long",
+			request: types.CostAndUsageRequestType{
+				Granularity: "DAILY",
+				Time:        types.Time{Start: "2024-01-01", End: "2024-03-01"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "filter on account outside allow-list",
+			request: types.CostAndUsageRequestType{
+				Granularity:     "MONTHLY",
+				Time:            types.Time{Start: "2024-01-01", End: "2024-03-01"},
+				DimensionFilter: map[string]string{"LINKED_ACCOUNT": "333333333333"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newGuard(policy)
+			req := tt.request
+			err := g.authorize(context.Background(), &req)
+
+			if tt.wantErr {
+				var policyErr PolicyError
+				assert.ErrorAs(t, err, &policyErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, policy.AllowedAccounts, req.DimensionAllowList["LINKED_ACCOUNT"])
+		})
+	}
+}
+
+func TestGuardBudget(t *testing.T) {
+	t.Run("per session", func(t *testing.T) {
+		g := newGuard(Policy{MaxCallsPerSession: 2})
+		gate := g.meter("session-1")
+		require.NoError(t, gate(1))
+		require.NoError(t, gate(2))
+		var policyErr PolicyError
+		assert.ErrorAs(t, gate(3), &policyErr)
+		assert.NoError(t, g.meter("session-2")(1), "each session has its own budget")
+	})
+
+	t.Run("per minute", func(t *testing.T) {
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		g := newGuard(Policy{MaxCallsPerMinute: 1})
+		g.now = func() time.Time { return now }
+
+		require.NoError(t, g.meter("session-1")(1))
+		assert.Error(t, g.meter("session-2")(1))
+
+		now = now.Add(61 * time.Second)
+		assert.NoError(t, g.meter("session-1")(2))
+	})
+
+	t.Run("authorize does not charge the budget", func(t *testing.T) {
+		g := newGuard(Policy{MaxCallsPerSession: 1})
+		req := types.CostAndUsageRequestType{
+			Granularity: "MONTHLY",
+			Time:        types.Time{Start: "2024-01-01", End: "2024-02-01"},
+		}
+		require.NoError(t, g.authorize(context.Background(), &req))
+		require.NoError(t, g.authorize(context.Background(), &req))
+		assert.NoError(t, g.meter(defaultSessionID)(1))
+	})
+}
+
+func TestBudgetChargedPerPage(t *testing.T) {
+	aws := &fakeAWSService{pages: 3, groups: map[string]map[string]string{
+		"2024-01-01": {"Amazon EC2": "100"},
+	}}
+	s := NewServer(aws, WithPolicy(Policy{MaxCallsPerSession: 2}))
+
+	res, err := s.handleGetCostAndUsage(context.Background(), callTool(map[string]interface{}{
+		"start_date": "2024-01-01",
+		"end_date":   "2024-02-01",
+		"group_by":   "SERVICE",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, aws.pagesFetched, "the page beyond the budget is not requested")
+
+	var result types.MCPCostAndUsageResult
+	require.NoError(t, json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &result))
+	assert.True(t, result.Truncated)
+	require.NotEmpty(t, result.Notes)
+	assert.Contains(t, result.Notes[len(result.Notes)-1], "session budget of 2 Cost Explorer calls exhausted")
+
+	_, err = s.handleGetCostAndUsage(context.Background(), callTool(map[string]interface{}{
+		"start_date": "2024-01-01",
+		"end_date":   "2024-02-01",
+	}))
+	var policyErr PolicyError
+	assert.ErrorAs(t, err, &policyErr, "a query without budget for its first page is denied")
+}
+
+func TestAuditedHandlerRecordsDenials(t *testing.T) {
+	var buf bytes.Buffer
+	aws := &fakeAWSService{}
+	s := NewServer(aws,
+		WithPolicy(Policy{DeniedGroupBy: []string{"LINKED_ACCOUNT"}}),
+		WithAuditLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+
+	handler := s.auditedHandler("get_cost_and_usage", s.handleGetCostAndUsage)
+	res, err := handler(context.Background(), callTool(map[string]interface{}{
+		"start_date": "2024-01-01",
+		"end_date":   "2024-02-01",
+		"group_by":   "LINKED_ACCOUNT",
+	}))
+	require.NoError(t, err)
+	assert.True(t, res.IsError)
+	assert.Contains(t, res.Content[0].(mcp.TextContent).Text, "LINKED_ACCOUNT")
+	assert.Empty(t, aws.requests)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "get_cost_and_usage", record["tool"])
+	assert.Equal(t, "denied", record["decision"])
+}
+
+func TestParseMaxSpan(t *testing.T) {
+	spans, err := ParseMaxSpan("hourly=14, DAILY=93")
+	require.NoError(t, err)
+	assert.Equal(t, 14*24*time.Hour, spans["HOURLY"])
+	assert.Equal(t, 93*24*time.Hour, spans["DAILY"])
+
+	for _, invalid := range []string{"DAILY", "DAILY=-1", "DAILY=14abc", "DAILY=7 days", "DAILY=1.5"} {
+		t.Run(invalid, func(t *testing.T) {
+			_, err := ParseMaxSpan(invalid)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/cduggn/ccexplorer/internal/awsservice"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const progressNotificationMethod = "notifications/progress"
+
+// progressReporter sends MCP progress notifications for a single tool call.
+// The number of pages is not known up front, so notifications carry no total
+// and describe the position in the message instead. It is a no-op when the
+// client did not supply a progress token.
+type progressReporter struct {
+	mcpServer *server.MCPServer
+	token     mcp.ProgressToken
+
+	mu       sync.Mutex
+	progress int
+	chunk    int
+	chunks   int
+	pages    int
+}
+
+// newProgressReporter creates a reporter for request. chunks is the number of
+// Cost Explorer queries the tool issues.
+func (s *Server) newProgressReporter(request mcp.CallToolRequest, chunks int) *progressReporter {
+	p := &progressReporter{mcpServer: s.mcpServer, chunks: chunks}
+	if request.Params.Meta != nil {
+		p.token = request.Params.Meta.ProgressToken
+	}
+	return p
+}
+
+// startChunk announces the next query and returns a context that reports
+// every page fetched for it
+func (p *progressReporter) startChunk(ctx context.Context, label string) context.Context {
+	p.mu.Lock()
+	p.chunk++
+	p.pages = 0
+	message := fmt.Sprintf("chunk %d of %d: %s", p.chunk, p.chunks, label)
+	p.mu.Unlock()
+
+	if p.chunks > 1 {
+		p.send(ctx, message)
+	}
+
+	return awsservice.WithPageObserver(ctx, func(page int) {
+		p.mu.Lock()
+		p.pages = page
+		message := fmt.Sprintf("page %d", page)
+		if p.chunks > 1 {
+			message = fmt.Sprintf("chunk %d of %d: page %d", p.chunk, p.chunks, page)
+		}
+		p.mu.Unlock()
+		p.send(ctx, message)
+	})
+}
+
+// pagesFetched returns the number of pages fetched for the current chunk
+func (p *progressReporter) pagesFetched() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pages
+}
+
+func (p *progressReporter) send(ctx context.Context, message string) {
+	if p.token == nil || p.mcpServer == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.progress++
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      p.progress,
+		"message":       message,
+	}
+	p.mu.Unlock()
+
+	err := p.mcpServer.SendNotificationToClient(ctx, progressNotificationMethod, params)
+	if err != nil {
+		slog.Debug("Unable to send progress notification", "error", err)
+	}
+}
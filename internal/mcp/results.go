@@ -0,0 +1,528 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	// DefaultResponseByteBudget caps the size of a single tool response so
+	// that a long DAILY query cannot exhaust the model context
+	DefaultResponseByteBudget = 64 * 1024
+
+	otherKey        = "Other"
+	topMoversLimit  = 5
+	formatJSON      = "json"
+	formatCSV       = "csv"
+	minBudgetedRows = 1
+
+	defaultSemanticSearchTopK = 5
+	maxSemanticSearchTopK     = 50
+
+	rowsNotePrefix    = "returned"
+	partialNotePrefix = "partial result"
+	budgetNotePrefix  = "response truncated to fit the"
+)
+
+// buildCostAndUsageResult converts the internal report into the model-facing
+// result, applying top_n rollups, max_rows and summary_only
+func buildCostAndUsageResult(report types.CostAndUsageOutputType,
+	req types.CostAndUsageRequestType, opts types.MCPOutputOptions) types.MCPCostAndUsageResult {
+
+	rankMetric := "UnblendedCost"
+	if len(req.Metrics) > 0 {
+		rankMetric = req.Metrics[0]
+	}
+
+	result := types.MCPCostAndUsageResult{
+		Granularity: report.Granularity,
+		Start:       report.Start,
+		End:         report.End,
+		GroupBy:     groupByLabels(req),
+		RankMetric:  rankMetric,
+	}
+
+	rows := toCostRows(report.Services, req.Metrics)
+	sortRows(rows, rankMetric)
+	result.Periods = summarizePeriods(rows, rankMetric)
+	result.TopMovers = topMovers(rows, rankMetric, topMoversLimit)
+
+	if opts.TopN > 0 {
+		rows = rollupTopN(rows, rankMetric, opts.TopN)
+	}
+	result.TotalRows = len(rows)
+
+	if opts.SummaryOnly {
+		result.Notes = append(result.Notes,
+			fmt.Sprintf("summary_only requested: %d rows omitted", len(rows)))
+		return result
+	}
+
+	if opts.MaxRows > 0 && len(rows) > opts.MaxRows {
+		rows = limitRows(&result, rows, opts.MaxRows)
+	}
+
+	result.Rows = rows
+	result.ReturnedRows = len(rows)
+	return result
+}
+
+// renderCostAndUsageResult encodes the result in the requested format and
+// drops the cheapest rows until the encoding fits within budget bytes
+func renderCostAndUsageResult(result types.MCPCostAndUsageResult, format string,
+	budget int) (*mcp.CallToolResult, error) {
+
+	if budget <= 0 {
+		budget = DefaultResponseByteBudget
+	}
+
+	for {
+		blocks, err := encodeResult(result, format)
+		if err != nil {
+			return nil, err
+		}
+		size := 0
+		for _, b := range blocks {
+			size += len(b)
+		}
+
+		if size <= budget || len(result.Rows) == 0 {
+			if size > budget {
+				return nil, fmt.Errorf("response summary exceeds byte budget of %d bytes", budget)
+			}
+			return toToolResult(blocks), nil
+		}
+
+		// shrink proportionally, always dropping at least one row
+		keep := int(float64(len(result.Rows)) * float64(budget) / float64(size) * 0.9)
+		if keep >= len(result.Rows) {
+			keep = len(result.Rows) - 1
+		}
+		if keep < minBudgetedRows {
+			keep = 0
+		}
+		result.Rows = limitRows(&result, result.Rows, keep)
+		result.ReturnedRows = len(result.Rows)
+		result.Notes = setNote(result.Notes, budgetNotePrefix,
+			fmt.Sprintf("%s %d byte budget", budgetNotePrefix, budget))
+	}
+}
+
+// renderComparison limits each list of deltas to maxRows, then drops the
+// smallest deltas until the encoding fits within budget bytes
+func renderComparison(c types.PeriodComparison, maxRows, budget int) (*mcp.CallToolResult, error) {
+	if budget <= 0 {
+		budget = DefaultResponseByteBudget
+	}
+
+	result := types.MCPComparisonResult{PeriodComparison: c}
+	if maxRows > 0 {
+		limitComparison(&result, maxRows)
+	}
+
+	for {
+		body, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		if len(body) <= budget {
+			return mcp.NewToolResultText(string(body)), nil
+		}
+
+		longest := max(len(result.Changed), len(result.NewKeys), len(result.DisappearedKeys))
+		if longest == 0 {
+			return nil, fmt.Errorf("response summary exceeds byte budget of %d bytes", budget)
+		}
+		limitComparison(&result, int(float64(longest)*float64(budget)/float64(len(body))*0.9))
+		result.Notes = setNote(result.Notes, budgetNotePrefix,
+			fmt.Sprintf("%s %d byte budget", budgetNotePrefix, budget))
+	}
+}
+
+// renderSemanticSearch encodes result, dropping the least similar matches
+// until it fits the byte budget
+func renderSemanticSearch(result types.MCPSemanticSearchResult, budget int) (*mcp.CallToolResult, error) {
+	if budget <= 0 {
+		budget = DefaultResponseByteBudget
+	}
+
+	for {
+		body, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		if len(body) <= budget {
+			return mcp.NewToolResultText(string(body)), nil
+		}
+		if len(result.Matches) == 0 {
+			return nil, fmt.Errorf("response summary exceeds byte budget of %d bytes", budget)
+		}
+
+		result.Matches = result.Matches[:len(result.Matches)-1]
+		result.Truncated = true
+		result.Notes = setNote(result.Notes, budgetNotePrefix,
+			fmt.Sprintf("%s %d byte budget", budgetNotePrefix, budget))
+	}
+}
+
+// limitComparison truncates every delta list to at most n entries. Lists are
+// sorted by absolute delta, so the smallest changes are dropped.
+func limitComparison(result *types.MCPComparisonResult, n int) {
+	lists := []struct {
+		name string
+		list *[]types.KeyDelta
+	}{
+		{"changed", &result.Changed},
+		{"new_keys", &result.NewKeys},
+		{"disappeared_keys", &result.DisappearedKeys},
+	}
+	for _, l := range lists {
+		total := len(*l.list)
+		if total <= n {
+			continue
+		}
+		*l.list = (*l.list)[:max(n, 0)]
+		result.Truncated = true
+		prefix := fmt.Sprintf("%s %s:", rowsNotePrefix, l.name)
+		result.Notes = setNote(result.Notes, prefix,
+			fmt.Sprintf("%s %d of %d entries", prefix, len(*l.list), total))
+	}
+}
+
+// encodeResult encodes the result as the text content blocks of the tool
+// result. CSV bodies cannot carry notes inline, so the summary travels as a
+// second JSON block, which counts towards the byte budget like the rows.
+func encodeResult(result types.MCPCostAndUsageResult, format string) ([][]byte, error) {
+	switch format {
+	case formatCSV:
+		body, err := encodeRowsCSV(result)
+		if err != nil {
+			return nil, err
+		}
+		summary := result
+		summary.Rows = nil
+		meta, err := json.Marshal(summary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return [][]byte{body, meta}, nil
+	default:
+		b, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal response: %w", err)
+		}
+		return [][]byte{b}, nil
+	}
+}
+
+// toToolResult wraps the encoded content blocks
+func toToolResult(blocks [][]byte) *mcp.CallToolResult {
+	content := make([]mcp.Content, len(blocks))
+	for i, b := range blocks {
+		content[i] = mcp.NewTextContent(string(b))
+	}
+	return &mcp.CallToolResult{Content: content}
+}
+
+func encodeRowsCSV(result types.MCPCostAndUsageResult) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	keyColumns := len(result.GroupBy)
+	for _, r := range result.Rows {
+		if len(r.Keys) > keyColumns {
+			keyColumns = len(r.Keys)
+		}
+	}
+
+	header := []string{"start", "end"}
+	for i := 0; i < keyColumns; i++ {
+		if i < len(result.GroupBy) {
+			header = append(header, result.GroupBy[i])
+		} else {
+			header = append(header, fmt.Sprintf("key_%d", i+1))
+		}
+	}
+	header = append(header, "metric", "amount", "unit")
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	for _, r := range result.Rows {
+		keys := make([]string, keyColumns)
+		copy(keys, r.Keys)
+		for _, m := range r.Metrics {
+			record := append([]string{r.Start, r.End}, keys...)
+			record = append(record, m.Name,
+				strconv.FormatFloat(m.Amount, 'f', -1, 64), m.Unit)
+			if err := w.Write(record); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// toCostRows flattens the service map into rows with metrics in the order
+// they were requested
+func toCostRows(services map[int]types.Service, metrics []string) []types.MCPCostRow {
+	order := make(map[string]int, len(metrics))
+	for i, m := range metrics {
+		order[m] = i
+	}
+
+	rows := make([]types.MCPCostRow, 0, len(services))
+	for _, s := range services {
+		row := types.MCPCostRow{
+			Start: s.Start,
+			End:   s.End,
+			Keys:  append([]string(nil), s.Keys...),
+		}
+		for _, m := range s.Metrics {
+			row.Metrics = append(row.Metrics, types.MCPMetricValue{
+				Name:   m.Name,
+				Amount: m.NumericAmount,
+				Unit:   m.Unit,
+			})
+		}
+		sort.SliceStable(row.Metrics, func(i, j int) bool {
+			return order[row.Metrics[i].Name] < order[row.Metrics[j].Name]
+		})
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// sortRows orders rows by period, then by descending rank metric, then by key
+func sortRows(rows []types.MCPCostRow, metric string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Start != rows[j].Start {
+			return rows[i].Start < rows[j].Start
+		}
+		ai, aj := metricAmount(rows[i], metric), metricAmount(rows[j], metric)
+		if ai != aj {
+			return ai > aj
+		}
+		return joinKeys(rows[i].Keys) < joinKeys(rows[j].Keys)
+	})
+}
+
+func summarizePeriods(rows []types.MCPCostRow, metric string) []types.MCPPeriodSummary {
+	var periods []types.MCPPeriodSummary
+	for _, r := range rows {
+		if len(periods) == 0 || periods[len(periods)-1].Start != r.Start {
+			periods = append(periods, types.MCPPeriodSummary{
+				Start: r.Start,
+				End:   r.End,
+			})
+		}
+		p := &periods[len(periods)-1]
+		p.Total = round(p.Total + metricAmount(r, metric))
+		p.Groups++
+		if p.Unit == "" {
+			p.Unit = metricUnit(r, metric)
+		}
+	}
+	return periods
+}
+
+// topMovers ranks groups by the absolute change of the rank metric between
+// the first and last period. Groups missing from a period count as zero.
+func topMovers(rows []types.MCPCostRow, metric string, limit int) []types.MCPCostMover {
+	if len(rows) == 0 || rows[0].Start == rows[len(rows)-1].Start {
+		return nil
+	}
+	first, last := rows[0].Start, rows[len(rows)-1].Start
+
+	movers := make(map[string]*types.MCPCostMover)
+	var order []string
+	for _, r := range rows {
+		if r.Start != first && r.Start != last {
+			continue
+		}
+		k := joinKeys(r.Keys)
+		m, ok := movers[k]
+		if !ok {
+			m = &types.MCPCostMover{Keys: r.Keys}
+			movers[k] = m
+			order = append(order, k)
+		}
+		if r.Start == first {
+			m.First += metricAmount(r, metric)
+		} else {
+			m.Last += metricAmount(r, metric)
+		}
+	}
+
+	result := make([]types.MCPCostMover, 0, len(order))
+	for _, k := range order {
+		m := movers[k]
+		m.First, m.Last = round(m.First), round(m.Last)
+		m.Delta = round(m.Last - m.First)
+		result = append(result, *m)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return math.Abs(result[i].Delta) > math.Abs(result[j].Delta)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// rollupTopN keeps the n most expensive groups of each period and folds the
+// remainder into a single "Other" row. Rows must already be sorted.
+func rollupTopN(rows []types.MCPCostRow, metric string, n int) []types.MCPCostRow {
+	var result []types.MCPCostRow
+	var other *types.MCPCostRow
+	kept := 0
+
+	flush := func() {
+		if other != nil {
+			result = append(result, *other)
+			other = nil
+		}
+	}
+
+	for i, r := range rows {
+		if i > 0 && rows[i-1].Start != r.Start {
+			flush()
+			kept = 0
+		}
+		if kept < n {
+			result = append(result, r)
+			kept++
+			continue
+		}
+		if other == nil {
+			other = &types.MCPCostRow{Start: r.Start, End: r.End, Keys: []string{otherKey}}
+		}
+		other.Metrics = addMetrics(other.Metrics, r.Metrics)
+		other.RolledUp++
+	}
+	flush()
+	return result
+}
+
+// markPartial flags a result built from a query that stopped early
+func markPartial(result *types.MCPCostAndUsageResult, pages int, cause error) {
+	result.Truncated = true
+	result.Notes = setNote(result.Notes, partialNotePrefix, fmt.Sprintf(
+		"%s: the query stopped after %d page(s) (%v); later periods may be missing",
+		partialNotePrefix, pages, cause))
+}
+
+// limitRows keeps the max most expensive rows, preserving the period order,
+// and records how much spend was omitted
+func limitRows(result *types.MCPCostAndUsageResult, rows []types.MCPCostRow,
+	max int) []types.MCPCostRow {
+
+	if max >= len(rows) {
+		return rows
+	}
+
+	idx := make([]int, len(rows))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return metricAmount(rows[idx[i]], result.RankMetric) >
+			metricAmount(rows[idx[j]], result.RankMetric)
+	})
+
+	keep := make(map[int]bool, max)
+	var omitted float64
+	for rank, i := range idx {
+		if rank < max {
+			keep[i] = true
+		} else {
+			omitted += metricAmount(rows[i], result.RankMetric)
+		}
+	}
+
+	limited := make([]types.MCPCostRow, 0, max)
+	for i, r := range rows {
+		if keep[i] {
+			limited = append(limited, r)
+		}
+	}
+
+	result.Truncated = true
+	result.Notes = setNote(result.Notes, rowsNotePrefix, fmt.Sprintf(
+		"%s %d of %d rows; omitted rows account for %.2f %s", rowsNotePrefix,
+		len(limited), result.TotalRows, omitted, result.RankMetric))
+	return limited
+}
+
+func addMetrics(acc []types.MCPMetricValue, m []types.MCPMetricValue) []types.MCPMetricValue {
+	for _, v := range m {
+		found := false
+		for i := range acc {
+			if acc[i].Name == v.Name {
+				acc[i].Amount = round(acc[i].Amount + v.Amount)
+				found = true
+				break
+			}
+		}
+		if !found {
+			acc = append(acc, v)
+		}
+	}
+	return acc
+}
+
+func metricAmount(r types.MCPCostRow, metric string) float64 {
+	for _, m := range r.Metrics {
+		if m.Name == metric {
+			return m.Amount
+		}
+	}
+	return 0
+}
+
+func metricUnit(r types.MCPCostRow, metric string) string {
+	for _, m := range r.Metrics {
+		if m.Name == metric {
+			return m.Unit
+		}
+	}
+	return ""
+}
+
+func groupByLabels(req types.CostAndUsageRequestType) []string {
+	labels := append([]string(nil), req.GroupBy...)
+	for _, t := range req.GroupByTag {
+		labels = append(labels, "TAG:"+t)
+	}
+	return labels
+}
+
+func joinKeys(keys []string) string {
+	b, _ := json.Marshal(keys)
+	return string(b)
+}
+
+// setNote replaces the note starting with prefix, or appends it
+func setNote(notes []string, prefix, note string) []string {
+	for i, n := range notes {
+		if strings.HasPrefix(n, prefix) {
+			notes[i] = note
+			return notes
+		}
+	}
+	return append(notes, note)
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1e6) / 1e6
+}
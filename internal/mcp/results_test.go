@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReport() (types.CostAndUsageOutputType, types.CostAndUsageRequestType) {
+	service := func(start, end, key string, amount float64) types.Service {
+		return types.Service{
+			Start: start,
+			End:   end,
+			Keys:  []string{key},
+			Metrics: []types.Metrics{{
+				Name:          "UnblendedCost",
+				Amount:        fmt.Sprintf("%f", amount),
+				NumericAmount: amount,
+				Unit:          "USD",
+			}},
+		}
+	}
+
+	report := types.CostAndUsageOutputType{
+		Granularity: "MONTHLY",
+		Start:       "2024-01-01",
+		End:         "2024-03-01",
+		Services: map[int]types.Service{
+			0: service("2024-01-01", "2024-02-01", "Amazon S3", 10),
+			1: service("2024-01-01", "2024-02-01", "Amazon EC2", 100),
+			2: service("2024-01-01", "2024-02-01", "AWS Lambda", 1),
+			3: service("2024-02-01", "2024-03-01", "Amazon S3", 40),
+			4: service("2024-02-01", "2024-03-01", "Amazon EC2", 90),
+			5: service("2024-02-01", "2024-03-01", "AWS Lambda", 2),
+		},
+		OpenAIAPIKey:   "sk-secret",
+		PineconeAPIKey: "pc-secret",
+	}
+	req := types.CostAndUsageRequestType{
+		Granularity: "MONTHLY",
+		GroupBy:     []string{"SERVICE"},
+		Metrics:     []string{"UnblendedCost"},
+	}
+	return report, req
+}
+
+func TestBuildCostAndUsageResult(t *testing.T) {
+	report, req := testReport()
+
+	t.Run("orders rows and summarizes periods", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{})
+
+		require.Len(t, result.Rows, 6)
+		assert.Equal(t, []string{"Amazon EC2"}, result.Rows[0].Keys)
+		assert.Equal(t, []string{"AWS Lambda"}, result.Rows[2].Keys)
+		assert.Equal(t, "2024-02-01", result.Rows[3].Start)
+
+		require.Len(t, result.Periods, 2)
+		assert.Equal(t, 111.0, result.Periods[0].Total)
+		assert.Equal(t, 132.0, result.Periods[1].Total)
+		assert.Equal(t, 3, result.Periods[0].Groups)
+
+		require.NotEmpty(t, result.TopMovers)
+		assert.Equal(t, []string{"Amazon S3"}, result.TopMovers[0].Keys)
+		assert.Equal(t, 30.0, result.TopMovers[0].Delta)
+		assert.False(t, result.Truncated)
+	})
+
+	t.Run("top_n rolls up the remainder per period", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{TopN: 1})
+
+		require.Len(t, result.Rows, 4)
+		assert.Equal(t, []string{otherKey}, result.Rows[1].Keys)
+		assert.Equal(t, 2, result.Rows[1].RolledUp)
+		assert.Equal(t, 11.0, result.Rows[1].Metrics[0].Amount)
+		assert.Equal(t, 42.0, result.Rows[3].Metrics[0].Amount)
+	})
+
+	t.Run("max_rows keeps the most expensive rows", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{MaxRows: 3})
+
+		require.Len(t, result.Rows, 3)
+		assert.True(t, result.Truncated)
+		assert.Equal(t, 6, result.TotalRows)
+		assert.Equal(t, 3, result.ReturnedRows)
+		assert.Equal(t, "2024-01-01", result.Rows[0].Start)
+		assert.Equal(t, []string{"Amazon S3"}, result.Rows[2].Keys)
+		require.Len(t, result.Notes, 1)
+		assert.Contains(t, result.Notes[0], "13.00")
+	})
+
+	t.Run("summary_only omits rows", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{SummaryOnly: true})
+
+		assert.Empty(t, result.Rows)
+		assert.Len(t, result.Periods, 2)
+		assert.NotEmpty(t, result.Notes)
+	})
+}
+
+func TestRenderCostAndUsageResult(t *testing.T) {
+	report, req := testReport()
+
+	t.Run("never echoes API keys", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{})
+		res, err := renderCostAndUsageResult(result, formatJSON, DefaultResponseByteBudget)
+		require.NoError(t, err)
+
+		text := res.Content[0].(mcp.TextContent).Text
+		assert.NotContains(t, text, "sk-secret")
+		assert.NotContains(t, text, "pc-secret")
+	})
+
+	t.Run("truncates to the byte budget", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{})
+		res, err := renderCostAndUsageResult(result, formatJSON, 1500)
+		require.NoError(t, err)
+
+		text := res.Content[0].(mcp.TextContent).Text
+		assert.LessOrEqual(t, len(text), 1500)
+
+		var decoded types.MCPCostAndUsageResult
+		require.NoError(t, json.Unmarshal([]byte(text), &decoded))
+		assert.True(t, decoded.Truncated)
+		assert.Less(t, decoded.ReturnedRows, 6)
+	})
+
+	t.Run("csv includes a header and a summary block", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{})
+		res, err := renderCostAndUsageResult(result, formatCSV, DefaultResponseByteBudget)
+		require.NoError(t, err)
+		require.Len(t, res.Content, 2)
+
+		lines := strings.Split(strings.TrimSpace(res.Content[0].(mcp.TextContent).Text), "\n")
+		assert.Equal(t, "start,end,SERVICE,metric,amount,unit", lines[0])
+		assert.Len(t, lines, 7)
+	})
+
+	t.Run("csv summary block counts towards the byte budget", func(t *testing.T) {
+		result := buildCostAndUsageResult(report, req, types.MCPOutputOptions{})
+		full, err := renderCostAndUsageResult(result, formatCSV, DefaultResponseByteBudget)
+		require.NoError(t, err)
+		rows := len(full.Content[0].(mcp.TextContent).Text)
+		budget := rows + len(full.Content[1].(mcp.TextContent).Text) - 1
+
+		res, err := renderCostAndUsageResult(result, formatCSV, budget)
+		require.NoError(t, err)
+		require.Len(t, res.Content, 2)
+		size := len(res.Content[0].(mcp.TextContent).Text) + len(res.Content[1].(mcp.TextContent).Text)
+		assert.LessOrEqual(t, size, budget)
+		assert.Contains(t, res.Content[1].(mcp.TextContent).Text, budgetNotePrefix)
+	})
+}
+
+func TestParseOutputOptions(t *testing.T) {
+	opts, err := parseOutputOptions(map[string]interface{}{
+		"max_rows":     float64(10),
+		"top_n":        "5",
+		"summary_only": true,
+		"format":       "CSV",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, types.MCPOutputOptions{MaxRows: 10, TopN: 5, SummaryOnly: true, Format: formatCSV}, opts)
+
+	_, err = parseOutputOptions(map[string]interface{}{"format": "xml"})
+	assert.Error(t, err)
+
+	_, err = parseOutputOptions(map[string]interface{}{"max_rows": float64(-1)})
+	assert.Error(t, err)
+}
@@ -10,12 +10,51 @@ import (
 
 // Server wraps the MCP server with ccExplorer-specific functionality
 type Server struct {
-	mcpServer  *server.MCPServer
-	awsService ports.AWSService
+	mcpServer          *server.MCPServer
+	awsService         ports.AWSService
+	responseByteBudget int
+	guard              *guard
+	auditLogger        *slog.Logger
+	costSearcher       ports.CostSearcher
+}
+
+// ServerOption configures optional Server behaviour
+type ServerOption func(*Server)
+
+// WithResponseByteBudget sets the maximum size in bytes of a tool response
+func WithResponseByteBudget(bytes int) ServerOption {
+	return func(s *Server) {
+		if bytes > 0 {
+			s.responseByteBudget = bytes
+		}
+	}
+}
+
+// WithPolicy restricts tool invocations to those allowed by p
+func WithPolicy(p Policy) ServerOption {
+	return func(s *Server) {
+		s.guard = newGuard(p)
+	}
+}
+
+// WithAuditLogger sets the logger receiving one record per tool invocation
+func WithAuditLogger(l *slog.Logger) ServerOption {
+	return func(s *Server) {
+		if l != nil {
+			s.auditLogger = l
+		}
+	}
+}
+
+// WithCostSearcher enables the semantic_search_costs tool backed by searcher
+func WithCostSearcher(searcher ports.CostSearcher) ServerOption {
+	return func(s *Server) {
+		s.costSearcher = searcher
+	}
 }
 
 // NewServer creates a new MCP server instance for stdio transport
-func NewServer(awsService ports.AWSService) *Server {
+func NewServer(awsService ports.AWSService, opts ...ServerOption) *Server {
 	slog.Info("Creating new ccExplorer MCP server")
 	
 	mcpServer := server.NewMCPServer(
@@ -24,10 +63,17 @@ func NewServer(awsService ports.AWSService) *Server {
 		server.WithToolCapabilities(true),
 	)
 
-	return &Server{
-		mcpServer:  mcpServer,
-		awsService: awsService,
+	s := &Server{
+		mcpServer:          mcpServer,
+		awsService:         awsService,
+		responseByteBudget: DefaultResponseByteBudget,
+		guard:              newGuard(Policy{}),
+		auditLogger:        slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // RegisterTools registers all available MCP tools with the server
@@ -44,11 +90,73 @@ func (s *Server) RegisterTools() error {
 		mcp.WithString("group_by"),
 		mcp.WithString("filter_by_service"),
 		mcp.WithBoolean("exclude_discounts"),
+		mcp.WithNumber("max_rows", mcp.Min(0),
+			mcp.Description("Return at most this many rows, keeping the most expensive")),
+		mcp.WithNumber("top_n", mcp.Min(0),
+			mcp.Description("Keep the top N groups per period and roll the rest into an \"Other\" row")),
+		mcp.WithBoolean("summary_only",
+			mcp.Description("Return only per-period totals and the top movers")),
+		mcp.WithString("format", mcp.Enum("json", "csv"),
+			mcp.Description("Encoding of the returned rows (default: json)")),
+		mcp.WithNumber("timeout_seconds", mcp.Min(0),
+			mcp.Description("Stop fetching further pages after this many seconds")),
+		mcp.WithBoolean("allow_partial",
+			mcp.Description("Return the pages fetched so far, marked truncated, if the query is cancelled or times out")),
 	)
 
 	// Add tool to the MCP server with our handler
-	s.mcpServer.AddTool(getCostTool, s.handleGetCostAndUsage)
+	s.mcpServer.AddTool(getCostTool,
+		s.auditedHandler("get_cost_and_usage", s.handleGetCostAndUsage))
 	slog.Info("Successfully registered get_cost_and_usage tool")
+
+	// Register the compare_periods tool
+	comparePeriodsTool := mcp.NewTool("compare_periods",
+		mcp.WithDescription("Compare AWS costs between a baseline and a current date range. "+
+			"Returns per-key baseline cost, current cost, absolute and percent delta, "+
+			"plus new and disappeared keys, sorted by absolute delta"),
+		mcp.WithString("baseline_start", mcp.Required()),
+		mcp.WithString("baseline_end", mcp.Required()),
+		mcp.WithString("current_start", mcp.Required()),
+		mcp.WithString("current_end", mcp.Required()),
+		mcp.WithString("granularity", mcp.Enum("DAILY", "MONTHLY", "HOURLY")),
+		mcp.WithString("metric",
+			mcp.Description("Metric to compare (default: UnblendedCost)")),
+		mcp.WithString("group_by"),
+		mcp.WithString("filter_by_service"),
+		mcp.WithBoolean("exclude_discounts"),
+		mcp.WithNumber("max_rows", mcp.Min(0),
+			mcp.Description("Return at most this many entries per list, keeping the largest changes")),
+		mcp.WithNumber("timeout_seconds", mcp.Min(0),
+			mcp.Description("Abort the comparison after this many seconds")),
+	)
+
+	s.mcpServer.AddTool(comparePeriodsTool,
+		s.auditedHandler("compare_periods", s.handleComparePeriods))
+	slog.Info("Successfully registered compare_periods tool")
+
+	// Stored documents cannot be narrowed to the allowed accounts and
+	// services, so retrieval is only offered without allow-lists
+	if s.costSearcher != nil && !s.guard.restrictsData() {
+		semanticSearchTool := mcp.NewTool("semantic_search_costs",
+			mcp.WithDescription("Search previously stored AWS cost documents by meaning. "+
+				"Returns the most similar documents with their period, dimensions and cost, "+
+				"without issuing Cost Explorer calls"),
+			mcp.WithString("query", mcp.Required(),
+				mcp.Description("Natural-language description of the costs to find")),
+			mcp.WithNumber("top_k", mcp.Min(1), mcp.Max(maxSemanticSearchTopK),
+				mcp.Description("Number of documents to return (default: 5)")),
+			mcp.WithString("start_date",
+				mcp.Description("Only return documents for periods starting on or after this date (YYYY-MM-DD)")),
+			mcp.WithString("end_date",
+				mcp.Description("Only return documents for periods starting before this date (YYYY-MM-DD)")),
+			mcp.WithString("dimensions",
+				mcp.Description("Only return documents grouped by exactly these dimensions, e.g. SERVICE,USAGE_TYPE")),
+		)
+
+		s.mcpServer.AddTool(semanticSearchTool,
+			s.auditedHandler("semantic_search_costs", s.handleSemanticSearchCosts))
+		slog.Info("Successfully registered semantic_search_costs tool")
+	}
 	
 	return nil
 }
@@ -0,0 +1,95 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	gogpt "github.com/sashabaranov/go-openai"
+)
+
+// ChatClient calls a chat-completions model through the OpenAI API, Azure
+// OpenAI or an OpenAI-compatible server
+type ChatClient struct {
+	client *gogpt.Client
+	model  string
+}
+
+func NewChatClient(cfg types.ChatConfig) *ChatClient {
+	return &ChatClient{
+		client: gogpt.NewClientWithConfig(clientConfig(endpoint{
+			provider:        cfg.Provider,
+			apiKey:          cfg.APIKey,
+			baseURL:         cfg.BaseURL,
+			azureDeployment: cfg.AzureDeployment,
+			azureAPIVersion: cfg.AzureAPIVersion,
+		})),
+		model: cfg.Model,
+	}
+}
+
+// Model returns the name of the chat model
+func (c *ChatClient) Model() string {
+	return c.model
+}
+
+// Complete returns the reply of the model to messages
+func (c *ChatClient) Complete(ctx context.Context, messages []types.ChatMessage) (string, error) {
+	req := gogpt.ChatCompletionRequest{
+		Model:    c.model,
+		Messages: make([]gogpt.ChatCompletionMessage, len(messages)),
+	}
+	for i, m := range messages {
+		req.Messages[i] = gogpt.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("%s chat completion failed: %w", c.model, err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no completion", c.model)
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// CallFunction forces the model to call fn and returns the JSON arguments
+// of the call
+func (c *ChatClient) CallFunction(ctx context.Context, messages []types.ChatMessage,
+	fn types.ChatFunction) (string, error) {
+
+	req := gogpt.ChatCompletionRequest{
+		Model:    c.model,
+		Messages: make([]gogpt.ChatCompletionMessage, len(messages)),
+		Tools: []gogpt.Tool{{
+			Type: gogpt.ToolTypeFunction,
+			Function: &gogpt.FunctionDefinition{
+				Name:        fn.Name,
+				Description: fn.Description,
+				Parameters:  fn.Parameters,
+			},
+		}},
+		ToolChoice: gogpt.ToolChoice{
+			Type:     gogpt.ToolTypeFunction,
+			Function: gogpt.ToolFunction{Name: fn.Name},
+		},
+	}
+	for i, m := range messages {
+		req.Messages[i] = gogpt.ChatCompletionMessage{Role: m.Role, Content: m.Content}
+	}
+
+	resp, err := c.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("%s chat completion failed: %w", c.model, err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("%s returned no completion", c.model)
+	}
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		if call.Function.Name == fn.Name {
+			return call.Function.Arguments, nil
+		}
+	}
+	return "", fmt.Errorf("%s did not call %s: %s", c.model, fn.Name,
+		resp.Choices[0].Message.Content)
+}
@@ -2,41 +2,117 @@ package openai
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+
+	http2 "github.com/cduggn/ccexplorer/internal/http"
+	"github.com/cduggn/ccexplorer/internal/types"
 	gogpt "github.com/sashabaranov/go-openai"
 )
 
-type OpenAI interface {
-	GenerateEmbeddings(items []string) ([]gogpt.Embedding,
-		error)
-}
+const (
+	OpenAI = "openai"
+	Azure  = "azure"
+	Local  = "local"
+
+	// DefaultLocalBaseURL is the OpenAI-compatible endpoint of a local Ollama
+	DefaultLocalBaseURL = "http://localhost:11434/v1"
+)
 
-type Client struct {
-	client *gogpt.Client
+// Embedder generates embeddings through the OpenAI API, Azure OpenAI or an
+// OpenAI-compatible server such as Ollama or llama.cpp
+type Embedder struct {
+	client     *gogpt.Client
+	model      string
+	dimensions int
 }
 
-func NewClient(apiKey string) OpenAI {
-	return &Client{
-		client: gogpt.NewClient(apiKey),
+func NewEmbedder(cfg types.EmbeddingConfig) *Embedder {
+	return &Embedder{
+		client: gogpt.NewClientWithConfig(clientConfig(endpoint{
+			provider:        cfg.Provider,
+			apiKey:          cfg.APIKey,
+			baseURL:         cfg.BaseURL,
+			azureDeployment: cfg.AzureDeployment,
+			azureAPIVersion: cfg.AzureAPIVersion,
+		})),
+		model:      cfg.Model,
+		dimensions: cfg.Dimensions,
 	}
 }
 
-func (o *Client) GenerateEmbeddings(items []string) ([]gogpt.
-	Embedding,
-	error) {
+// Model returns the name of the embedding model
+func (e *Embedder) Model() string {
+	return e.model
+}
 
-	req := gogpt.EmbeddingRequest{
-		Input: items,
-		Model: gogpt.AdaEmbeddingV2,
+// Embed returns one vector per text, in input order
+func (e *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	resp, err := e.client.CreateEmbeddings(ctx, gogpt.EmbeddingRequest{
+		Input:      texts,
+		Model:      gogpt.EmbeddingModel(e.model),
+		Dimensions: e.dimensions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s embedding request failed: %w", e.model,
+			statusError("embedding API", err))
+	}
+	if len(resp.Data) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d inputs",
+			e.model, len(resp.Data), len(texts))
 	}
 
-	resp, err := o.client.CreateEmbeddings(context.Background(),
-		req)
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, fmt.Errorf("%s returned an embedding for unknown input %d",
+				e.model, d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
 
-	if err != nil {
-		fmt.Printf("Embedding error: %v\n", err)
-		return nil, err
+// statusError converts the HTTP errors of the client into StatusErrors, so
+// rate-limited and failed requests can be retried
+func statusError(service string, err error) error {
+	var apiErr *gogpt.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return http2.StatusError{Service: service, StatusCode: apiErr.HTTPStatusCode, Body: apiErr.Message}
 	}
+	var reqErr *gogpt.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return http2.StatusError{Service: service, StatusCode: reqErr.HTTPStatusCode, Body: reqErr.Err.Error()}
+	}
+	return err
+}
 
-	return resp.Data, nil
+// endpoint locates the API serving a model
+type endpoint struct {
+	provider        string
+	apiKey          string
+	baseURL         string
+	azureDeployment string
+	azureAPIVersion string
+}
+
+func clientConfig(e endpoint) gogpt.ClientConfig {
+	switch strings.ToLower(e.provider) {
+	case Azure:
+		c := gogpt.DefaultAzureConfig(e.apiKey, e.baseURL)
+		if e.azureAPIVersion != "" {
+			c.APIVersion = e.azureAPIVersion
+		}
+		if e.azureDeployment != "" {
+			c.AzureModelMapperFunc = func(string) string { return e.azureDeployment }
+		}
+		return c
+	default:
+		c := gogpt.DefaultConfig(e.apiKey)
+		if e.baseURL != "" {
+			c.BaseURL = strings.TrimSuffix(e.baseURL, "/")
+		}
+		return c
+	}
 }
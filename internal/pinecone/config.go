@@ -1,11 +1,17 @@
 package pinecone
 
-import "net/http"
+import (
+	"net/http"
+
+	http2 "github.com/cduggn/ccexplorer/internal/http"
+)
 
 type ClientConfig struct {
 	apiKey     string
 	BaseURL    string
 	HTTPClient *http.Client
+	// Retry governs retries of rate-limited and failed requests
+	Retry http2.RetryPolicy
 }
 
 func DefaultConfig(indexUrl, apiKey string) ClientConfig {
@@ -13,5 +19,6 @@ func DefaultConfig(indexUrl, apiKey string) ClientConfig {
 		BaseURL:    indexUrl,
 		HTTPClient: &http.Client{},
 		apiKey:     apiKey,
+		Retry:      http2.DefaultRetryPolicy,
 	}
 }
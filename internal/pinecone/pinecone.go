@@ -5,36 +5,48 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/cduggn/ccexplorer/internal/codec"
 	http2 "github.com/cduggn/ccexplorer/internal/http"
-	"github.com/cduggn/ccexplorer/internal/openai"
 	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
 	"io"
 	"net/http"
-	"strings"
+)
+
+const backendName = "pinecone"
+
+// upsertBatchSize keeps upsert requests under the 2MB request limit for
+// 1536-dimensional vectors with metadata
+const upsertBatchSize = 100
+
+const (
+	// deleteBatchSize is the most IDs Pinecone deletes per request
+	deleteBatchSize = 1000
+	// maxQueryTopK is the most matches Pinecone returns per query without
+	// metadata
+	maxQueryTopK = 10000
 )
 
 func NewVectorStoreClient(builder http2.Builder,
-	indexURL, pineconeAPIKey, openAIAPIKey string) *ClientAPI {
+	indexURL, pineconeAPIKey, namespace string) *ClientAPI {
 
 	return &ClientAPI{
 		RequestBuilder: builder,
 		Config:         DefaultConfig(indexURL, pineconeAPIKey),
-		LLMClient:      openai.NewClient(openAIAPIKey),
-		Encoder:        codec.NewEncoder(),
+		Namespace:      namespace,
 	}
 }
 
 func (p *ClientAPI) Upsert(ctx context.Context,
-	data []PineconeStruct) (types.UpsertResponse, error) {
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
 
-	batches := splitIntoBatches(data)
+	batches := splitIntoBatches(toPineconeStructs(items))
 
 	var resp types.UpsertResponse
 
 	for _, batch := range batches {
 		message := UpsertVectorsRequest{
-			Message: batch,
+			Message:   batch,
+			Namespace: p.Namespace,
 		}
 		res, err := p.sendBatchRequest(ctx, message)
 		resp.UpsertedCount += res.UpsertedCount
@@ -45,11 +57,35 @@ func (p *ClientAPI) Upsert(ctx context.Context,
 	return resp, nil
 }
 
+func toPineconeStructs(items []*types.VectorStoreItem) []PineconeStruct {
+	return utils.ConvertSlice(items, func(v *types.VectorStoreItem) PineconeStruct {
+		return PineconeStruct{
+			ID:     v.ID,
+			Values: v.EmbeddingVector,
+			Metadata: Metadata{
+				PageContent: v.EmbeddingText,
+				Source:      "aws cost explorer",
+				Dimensions:  v.Metadata.Dimensions,
+				Granularity: v.Metadata.Granularity,
+				Tags:        v.Metadata.Tags,
+				Keys:        v.Metadata.Keys,
+				Attributes:  v.Metadata.Attributes,
+				Metrics:     v.Metadata.Metrics,
+				Document:    v.Metadata.Document,
+				Start:       v.Metadata.StartDate,
+				End:         v.Metadata.EndDate,
+				Cost:        v.Metadata.Cost,
+				CostAmount:  v.Metadata.CostAmount,
+				Model:       v.Metadata.EmbeddingModel,
+			},
+		}
+	})
+}
+
 func splitIntoBatches(data []PineconeStruct) [][]PineconeStruct {
 	var batches [][]PineconeStruct
-	batchSize := 25
-	for i := 0; i < len(data); i += batchSize {
-		end := i + batchSize
+	for i := 0; i < len(data); i += upsertBatchSize {
+		end := i + upsertBatchSize
 		if end > len(data) {
 			end = len(data)
 		}
@@ -61,66 +97,131 @@ func splitIntoBatches(data []PineconeStruct) [][]PineconeStruct {
 func (p *ClientAPI) sendBatchRequest(ctx context.Context,
 	message UpsertVectorsRequest) (resp types.UpsertResponse, err error) {
 
-	payload, err := json.Marshal(message)
+	err = p.post(ctx, "/vectors/upsert", message, &resp)
 	if err != nil {
 		return types.UpsertResponse{}, err
 	}
+	return
+}
+
+// Delete removes vectors of the namespace by ID, by filter or all of them.
+// Serverless indexes do not delete by metadata filter, so filtered deletes
+// look up the matching IDs and delete them by ID on every index type.
+func (p *ClientAPI) Delete(ctx context.Context, req types.VectorDeleteRequest) error {
+	if req.IsEmpty() {
+		return fmt.Errorf("delete request selects no vectors")
+	}
 
-	req, err := p.RequestBuilder.Build(ctx, http.MethodPost,
-		p.Config.BaseURL+"/vectors/upsert", bytes.NewReader(payload))
-	if err != nil {
-		return types.UpsertResponse{}, err
+	switch {
+	case req.All:
+		return p.post(ctx, "/vectors/delete",
+			DeleteVectorsRequest{DeleteAll: true, Namespace: p.Namespace}, nil)
+	case len(req.IDs) > 0:
+		return p.deleteIDs(ctx, req.IDs)
+	default:
+		return p.deleteByFilter(ctx, req.Filter)
 	}
+}
 
-	err = p.sendRequest(req, &resp)
+// deleteByFilter queries the namespace with a constant vector for the IDs
+// matching filter and deletes them, until a query returns no ID that has not
+// been deleted yet. Deleted vectors may briefly still match while the index
+// catches up, so they are skipped rather than counted as remaining.
+func (p *ClientAPI) deleteByFilter(ctx context.Context, filter types.VectorQueryFilter) error {
+	metadataFilter, err := BuildQueryFilter(filter)
 	if err != nil {
-		return types.UpsertResponse{}, err
+		return err
+	}
+	stats, err := p.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.Count == 0 || stats.Dimension == 0 {
+		return nil
+	}
+
+	probe := make([]float32, stats.Dimension)
+	for i := range probe {
+		probe[i] = 1
+	}
+	deleted := make(map[string]bool)
+	for {
+		var resp QueryVectorsResponse
+		err := p.post(ctx, "/query", QueryVectorsRequest{
+			Vector:    probe,
+			TopK:      maxQueryTopK,
+			Filter:    metadataFilter,
+			Namespace: p.Namespace,
+		}, &resp)
+		if err != nil {
+			return err
+		}
+
+		var ids []string
+		for _, m := range resp.Matches {
+			if !deleted[m.ID] {
+				deleted[m.ID] = true
+				ids = append(ids, m.ID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := p.deleteIDs(ctx, ids); err != nil {
+			return err
+		}
 	}
-	return
 }
 
-func (p *ClientAPI) ConvertToVectorStoreItem(r types.CostAndUsageOutputType) []*types.VectorStoreItem {
-	var s []*types.VectorStoreItem
-	for _, d := range r.Services {
-
-		dimensions := strings.Join(r.Dimensions, ",")
-		tags := strings.Join(r.Tags, ",")
-
-		item := types.VectorStoreItem{
-			EmbeddingText: p.AddSemanticMeaning(d, dimensions, tags),
-			Metadata: types.VectorStoreItemMetadata{
-				StartDate:   d.Start,
-				EndDate:     d.End,
-				Granularity: r.Granularity,
-				Dimensions:  dimensions,
-				Tags:        tags,
-				Cost:        d.Metrics[0].Amount,
-			},
+// deleteIDs deletes ids in batches of at most deleteBatchSize
+func (p *ClientAPI) deleteIDs(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += deleteBatchSize {
+		end := min(start+deleteBatchSize, len(ids))
+		err := p.post(ctx, "/vectors/delete",
+			DeleteVectorsRequest{IDs: ids[start:end], Namespace: p.Namespace}, nil)
+		if err != nil {
+			return err
 		}
-		s = append(s, &item)
 	}
-	return s
+	return nil
 }
 
-func (p *ClientAPI) AddSemanticMeaning(s types.Service, dimensions, tags string) string {
-	var r strings.Builder
+// Stats returns the vector count of the namespace and of every namespace in
+// the index, and the index dimension
+func (p *ClientAPI) Stats(ctx context.Context) (types.VectorStoreStats, error) {
+	var resp DescribeIndexStatsResponse
+	if err := p.post(ctx, "/describe_index_stats", struct{}{}, &resp); err != nil {
+		return types.VectorStoreStats{}, err
+	}
 
-	// append keys
-	fmt.Fprintf(&r, "AWS Cost explorer cost and usage results grouped by dimensions and tags named %s %s ", dimensions, tags)
-	fmt.Fprintf(&r, "and with the following key values %s,", strings.Join(s.Keys, ","))
-	fmt.Fprintf(&r, " over the time period which starts and ends at %s,%s,%s,", s.Start, s.End, s.Name)
+	namespaces := make(map[string]int, len(resp.Namespaces))
+	for name, ns := range resp.Namespaces {
+		namespaces[name] = ns.VectorCount
+	}
+	return types.VectorStoreStats{
+		Backend:    backendName,
+		Namespace:  p.Namespace,
+		Count:      namespaces[p.Namespace],
+		Dimension:  resp.Dimension,
+		Namespaces: namespaces,
+	}, nil
+}
 
-	// append metrics
-	metrics := make([]string, len(s.Metrics))
-	for i, v := range s.Metrics {
-		encodedAmount := p.Encoder.CategorizeCostsWithBinning(v.NumericAmount)
-		metrics[i] = fmt.Sprintf(
-			"the metrics values include the cost category dataset name: %s, the cost associated with this grouped dimension and/or tag for this time period: %s, the currency unit used to represent the cost: %s, and an encoded value to normalize the cost into a binning category: %s",
-			v.Name, v.Amount, v.Unit, encodedAmount)
+// post sends message to path, retrying rate-limited and failed requests
+func (p *ClientAPI) post(ctx context.Context, path string, message, v any) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
 	}
 
-	r.WriteString(strings.Join(metrics, ","))
-	return r.String()
+	return p.Config.Retry.Do(ctx, func(ctx context.Context) error {
+		req, err := p.RequestBuilder.Build(ctx, http.MethodPost,
+			p.Config.BaseURL+path, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		return p.sendRequest(req, v)
+	})
 }
 
 func (p *ClientAPI) sendRequest(req *http.Request, v any) error {
@@ -136,7 +237,7 @@ func (p *ClientAPI) sendRequest(req *http.Request, v any) error {
 	defer res.Body.Close()
 	if res.StatusCode < http.StatusOK || res.StatusCode >= http.
 		StatusBadRequest {
-		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+		return http2.NewStatusError(backendName, res)
 	}
 	return decodeResponse(res.Body, v)
 }
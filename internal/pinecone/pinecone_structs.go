@@ -1,16 +1,14 @@
 package pinecone
 
 import (
-	"github.com/cduggn/ccexplorer/internal/codec"
 	"github.com/cduggn/ccexplorer/internal/http"
-	"github.com/cduggn/ccexplorer/internal/openai"
 )
 
 type ClientAPI struct {
 	RequestBuilder http.Builder
 	Config         ClientConfig
-	LLMClient      openai.OpenAI
-	Encoder        codec.Encode
+	// Namespace scopes every request; empty is the default namespace
+	Namespace string
 }
 
 type PineconeStruct struct {
@@ -23,11 +21,56 @@ type Metadata struct {
 	PageContent string `json:"page_content"`
 	Source      string `json:"source"`
 	Dimensions  string `json:"dimensions"`
-	Start       string `json:"start"`
-	End         string `json:"end"`
-	Cost        string `json:"cost"`
+	Granularity string `json:"granularity,omitempty"`
+	Tags        string `json:"tags,omitempty"`
+	Keys        string `json:"keys,omitempty"`
+	// Attributes is stored as a list so it can be filtered with $in
+	Attributes []string `json:"attributes,omitempty"`
+	Metrics    string   `json:"metrics,omitempty"`
+	Document   string   `json:"document,omitempty"`
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Cost       string   `json:"cost"`
+	// CostAmount is numeric so it can be filtered with range operators
+	CostAmount float64 `json:"cost_amount"`
+	Model      string  `json:"embedding_model,omitempty"`
 }
 
 type UpsertVectorsRequest struct {
-	Message []PineconeStruct `json:"vectors"`
+	Message   []PineconeStruct `json:"vectors"`
+	Namespace string           `json:"namespace,omitempty"`
+}
+
+type QueryVectorsRequest struct {
+	Vector          []float32      `json:"vector"`
+	TopK            int            `json:"topK"`
+	Filter          map[string]any `json:"filter,omitempty"`
+	IncludeMetadata bool           `json:"includeMetadata"`
+	Namespace       string         `json:"namespace,omitempty"`
+}
+
+type QueryVectorsResponse struct {
+	Matches []Match `json:"matches"`
+}
+
+type Match struct {
+	ID       string   `json:"id"`
+	Score    float32  `json:"score"`
+	Metadata Metadata `json:"metadata"`
+}
+
+type DeleteVectorsRequest struct {
+	IDs       []string `json:"ids,omitempty"`
+	DeleteAll bool     `json:"deleteAll,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+}
+
+type DescribeIndexStatsResponse struct {
+	Dimension        int                       `json:"dimension"`
+	TotalVectorCount int                       `json:"totalVectorCount"`
+	Namespaces       map[string]NamespaceStats `json:"namespaces"`
+}
+
+type NamespaceStats struct {
+	VectorCount int `json:"vectorCount"`
 }
@@ -0,0 +1,101 @@
+package pinecone
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+)
+
+// maxFilterDays bounds the number of dates enumerated into a start filter.
+// Pinecone only supports range operators on numeric metadata, and start is
+// stored as a date string.
+const maxFilterDays = 3 * 366
+
+// Query returns the topK stored cost documents closest to vector
+func (p *ClientAPI) Query(ctx context.Context, vector []float32, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+
+	metadataFilter, err := BuildQueryFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp QueryVectorsResponse
+	err = p.post(ctx, "/query", QueryVectorsRequest{
+		Vector:          vector,
+		TopK:            topK,
+		Filter:          metadataFilter,
+		IncludeMetadata: true,
+		Namespace:       p.Namespace,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]types.VectorQueryMatch, len(resp.Matches))
+	for i, m := range resp.Matches {
+		matches[i] = types.VectorQueryMatch{
+			ID:          m.ID,
+			Score:       m.Score,
+			PageContent: m.Metadata.PageContent,
+			Metadata: types.VectorStoreItemMetadata{
+				StartDate:      m.Metadata.Start,
+				EndDate:        m.Metadata.End,
+				Dimensions:     m.Metadata.Dimensions,
+				Granularity:    m.Metadata.Granularity,
+				Tags:           m.Metadata.Tags,
+				Keys:           m.Metadata.Keys,
+				Attributes:     m.Metadata.Attributes,
+				Metrics:        m.Metadata.Metrics,
+				Document:       m.Metadata.Document,
+				Cost:           m.Metadata.Cost,
+				CostAmount:     m.Metadata.CostAmount,
+				EmbeddingModel: m.Metadata.Model,
+			},
+		}
+	}
+	return matches, nil
+}
+
+// BuildQueryFilter converts filter into a Pinecone metadata filter. The date
+// range is expanded into the set of YYYY-MM-DD start dates it contains.
+func BuildQueryFilter(filter types.VectorQueryFilter) (map[string]any, error) {
+	var clauses []any
+
+	if filter.Start != "" || filter.End != "" {
+		dates, err := utils.DatesBetween(filter.Start, filter.End, maxFilterDays)
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, map[string]any{"start": map[string]any{"$in": dates}})
+	}
+
+	if len(filter.Dimensions) > 0 {
+		clauses = append(clauses, map[string]any{
+			"dimensions": map[string]any{"$eq": strings.Join(filter.Dimensions, ",")},
+		})
+	}
+
+	if filter.Granularity != "" {
+		clauses = append(clauses, map[string]any{
+			"granularity": map[string]any{"$eq": filter.Granularity},
+		})
+	}
+
+	if filter.MinCost != 0 {
+		clauses = append(clauses, map[string]any{
+			"cost_amount": map[string]any{"$gte": filter.MinCost},
+		})
+	}
+
+	switch len(clauses) {
+	case 0:
+		return nil, nil
+	case 1:
+		return clauses[0].(map[string]any), nil
+	default:
+		return map[string]any{"$and": clauses}, nil
+	}
+}
@@ -0,0 +1,234 @@
+package pinecone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	http2 "github.com/cduggn/ccexplorer/internal/http"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildQueryFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  types.VectorQueryFilter
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:   "no filter",
+			filter: types.VectorQueryFilter{},
+			want:   nil,
+		},
+		{
+			name:   "dimensions only",
+			filter: types.VectorQueryFilter{Dimensions: []string{"SERVICE", "USAGE_TYPE"}},
+			want: map[string]any{
+				"dimensions": map[string]any{"$eq": "SERVICE,USAGE_TYPE"},
+			},
+		},
+		{
+			name:   "date range and dimensions",
+			filter: types.VectorQueryFilter{Start: "2024-01-30", End: "2024-02-02", Dimensions: []string{"SERVICE"}},
+			want: map[string]any{"$and": []any{
+				map[string]any{"start": map[string]any{"$in": []string{"2024-01-30", "2024-01-31", "2024-02-01"}}},
+				map[string]any{"dimensions": map[string]any{"$eq": "SERVICE"}},
+			}},
+		},
+		{
+			name:   "dimensions and granularity",
+			filter: types.VectorQueryFilter{Dimensions: []string{"SERVICE"}, Granularity: "DAILY"},
+			want: map[string]any{"$and": []any{
+				map[string]any{"dimensions": map[string]any{"$eq": "SERVICE"}},
+				map[string]any{"granularity": map[string]any{"$eq": "DAILY"}},
+			}},
+		},
+		{
+			name:   "granularity and minimum cost",
+			filter: types.VectorQueryFilter{Granularity: "MONTHLY", MinCost: 100},
+			want: map[string]any{"$and": []any{
+				map[string]any{"granularity": map[string]any{"$eq": "MONTHLY"}},
+				map[string]any{"cost_amount": map[string]any{"$gte": 100.0}},
+			}},
+		},
+		{
+			name:    "end before start",
+			filter:  types.VectorQueryFilter{Start: "2024-02-01", End: "2024-01-01"},
+			wantErr: true,
+		},
+		{
+			name:    "range too long",
+			filter:  types.VectorQueryFilter{Start: "2020-01-01", End: "2024-01-01"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildQueryFilter(tt.filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestQuery(t *testing.T) {
+	var received QueryVectorsRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/query", r.URL.Path)
+		assert.Equal(t, "pc-key", r.Header.Get("Api-Key"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		_ = json.NewEncoder(w).Encode(QueryVectorsResponse{Matches: []Match{{
+			ID:    "abc",
+			Score: 0.9,
+			Metadata: Metadata{
+				PageContent: "EC2 costs in January",
+				Start:       "2024-01-01",
+				End:         "2024-02-01",
+				Dimensions:  "SERVICE",
+				Cost:        "12.5",
+			},
+		}}})
+	}))
+	defer srv.Close()
+
+	client := NewVectorStoreClient(http2.NewRequestBuilder(), srv.URL, "pc-key", "acct-1")
+
+	matches, err := client.Query(context.Background(), []float32{0.1, 0.2}, 3,
+		types.VectorQueryFilter{Dimensions: []string{"SERVICE"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, received.TopK)
+	assert.True(t, received.IncludeMetadata)
+	assert.Equal(t, "acct-1", received.Namespace)
+	assert.Equal(t, []float32{0.1, 0.2}, received.Vector)
+	assert.Equal(t, map[string]any{"dimensions": map[string]any{"$eq": "SERVICE"}}, received.Filter)
+
+	require.Len(t, matches, 1)
+	assert.Equal(t, "EC2 costs in January", matches[0].PageContent)
+	assert.Equal(t, "2024-01-01", matches[0].Metadata.StartDate)
+	assert.Equal(t, "12.5", matches[0].Metadata.Cost)
+}
+
+func TestDeleteRequiresSelection(t *testing.T) {
+	client := NewVectorStoreClient(http2.NewRequestBuilder(), "http://unused", "pc-key", "")
+	assert.Error(t, client.Delete(context.Background(), types.VectorDeleteRequest{}))
+}
+
+func TestDeleteByFilterDeletesMatchingIDs(t *testing.T) {
+	var queries []QueryVectorsRequest
+	var deletes []DeleteVectorsRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/describe_index_stats":
+			_, _ = io.WriteString(w, `{"dimension":3,"namespaces":{"acct-1":{"vectorCount":2}}}`)
+		case "/query":
+			var q QueryVectorsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&q))
+			queries = append(queries, q)
+			matches := []Match{{ID: "jan-ec2"}, {ID: "jan-s3"}}
+			if len(queries) > 1 {
+				// a deleted vector still matches until the index catches up
+				matches = matches[:1]
+			}
+			_ = json.NewEncoder(w).Encode(QueryVectorsResponse{Matches: matches})
+		case "/vectors/delete":
+			var d DeleteVectorsRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&d))
+			deletes = append(deletes, d)
+			_, _ = io.WriteString(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewVectorStoreClient(http2.NewRequestBuilder(), srv.URL, "pc-key", "acct-1")
+	err := client.Delete(context.Background(), types.VectorDeleteRequest{
+		Filter: types.VectorQueryFilter{Granularity: "MONTHLY"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, []float32{1, 1, 1}, queries[0].Vector)
+	assert.Equal(t, maxQueryTopK, queries[0].TopK)
+	assert.False(t, queries[0].IncludeMetadata)
+	assert.Equal(t, map[string]any{"granularity": map[string]any{"$eq": "MONTHLY"}}, queries[0].Filter)
+	assert.Equal(t, []DeleteVectorsRequest{{IDs: []string{"jan-ec2", "jan-s3"}, Namespace: "acct-1"}}, deletes,
+		"serverless indexes do not delete by filter, so matches are deleted by ID")
+}
+
+func TestDeleteIDsInBatches(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d DeleteVectorsRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&d))
+		batches = append(batches, len(d.IDs))
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	ids := make([]string, deleteBatchSize+1)
+	for i := range ids {
+		ids[i] = fmt.Sprint(i)
+	}
+	client := NewVectorStoreClient(http2.NewRequestBuilder(), srv.URL, "pc-key", "")
+	require.NoError(t, client.Delete(context.Background(), types.VectorDeleteRequest{IDs: ids}))
+	assert.Equal(t, []int{deleteBatchSize, 1}, batches)
+}
+
+func TestStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/describe_index_stats", r.URL.Path)
+		_, _ = io.WriteString(w, `{"dimension":1536,"totalVectorCount":7,
+			"namespaces":{"":{"vectorCount":4},"MONTHLY":{"vectorCount":3}}}`)
+	}))
+	defer srv.Close()
+
+	stats, err := NewVectorStoreClient(http2.NewRequestBuilder(), srv.URL, "pc-key", "MONTHLY").
+		Stats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, types.VectorStoreStats{
+		Backend:    "pinecone",
+		Namespace:  "MONTHLY",
+		Count:      3,
+		Dimension:  1536,
+		Namespaces: map[string]int{"": 4, "MONTHLY": 3},
+	}, stats)
+}
+
+func TestUpsertRetriesAndSurfacesBody(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = io.WriteString(w, `{"message":"rate limited"}`)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"message":"Vector dimension 3 does not match the dimension of the index 1536"}`)
+	}))
+	defer srv.Close()
+
+	client := NewVectorStoreClient(http2.NewRequestBuilder(), srv.URL, "pc-key", "")
+	client.Config.Retry = http2.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	_, err := client.Upsert(context.Background(), []*types.VectorStoreItem{
+		{ID: "a", EmbeddingVector: []float32{1, 0, 0}},
+	})
+	assert.ErrorContains(t, err, "pinecone returned status 400")
+	assert.ErrorContains(t, err, "does not match the dimension of the index")
+	assert.Equal(t, int32(2), calls.Load(), "the rate-limited request is retried once")
+}
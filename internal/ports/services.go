@@ -16,3 +16,32 @@ type AWSService interface {
 		*costexplorer.
 			GetCostForecastOutput, error)
 }
+
+type CostSearcher interface {
+	Search(ctx context.Context, text string, topK int,
+		filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error)
+}
+
+type VectorStore interface {
+	Upsert(ctx context.Context,
+		items []*types.VectorStoreItem) (types.UpsertResponse, error)
+	Query(ctx context.Context, vector []float32, topK int,
+		filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error)
+	Delete(ctx context.Context, req types.VectorDeleteRequest) error
+	Stats(ctx context.Context) (types.VectorStoreStats, error)
+}
+
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Model() string
+}
+
+type ChatModel interface {
+	Complete(ctx context.Context, messages []types.ChatMessage) (string, error)
+	Model() string
+}
+
+type FunctionCaller interface {
+	CallFunction(ctx context.Context, messages []types.ChatMessage,
+		fn types.ChatFunction) (string, error)
+}
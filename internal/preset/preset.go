@@ -0,0 +1,221 @@
+// Package preset resolves named get aws queries into flag values
+package preset
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/spf13/pflag"
+)
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_-]*)\}`)
+	slugSeparator      = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// dateFlags are the flags whose values may be date expressions
+var dateFlags = []string{"startdate", "enddate"}
+
+// Slug returns the name of a preset alias, e.g. s3-costs-grouped-by-operation
+// for "S3 costs grouped by OPERATION"
+func Slug(alias string) string {
+	return strings.Trim(slugSeparator.ReplaceAllString(strings.ToLower(alias), "-"), "-")
+}
+
+// FromParams converts the parameters of a built-in preset into flags
+func FromParams(p types.PresetParams) types.Preset {
+	flags := map[string]string{}
+
+	groupBy := make([]string, len(p.Dimension))
+	for i, d := range p.Dimension {
+		groupBy[i] = "DIMENSION=" + d
+	}
+	flags["groupBy"] = strings.Join(groupBy, ",")
+
+	var filterBy []string
+	if p.FilterByDimension {
+		keys := make([]string, 0, len(p.Filter))
+		for k := range p.Filter {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			filterBy = append(filterBy, k+"="+p.Filter[k])
+		}
+	}
+	if p.FilterByTag && p.Tag != "" {
+		filterBy = append(filterBy, "TAG="+p.Tag)
+	}
+	if len(filterBy) > 0 {
+		flags["filterBy"] = strings.Join(filterBy, ",")
+	}
+
+	if p.ExcludeDiscounts {
+		flags["excludeDiscounts"] = "true"
+	}
+	if p.Granularity != "" {
+		flags["granularity"] = p.Granularity
+	}
+	if p.PrintFormat != "" {
+		flags["printFormat"] = p.PrintFormat
+	}
+	if len(p.Metric) > 0 {
+		flags["metric"] = p.Metric[0]
+	}
+
+	return types.Preset{
+		Name:        Slug(p.Alias),
+		Description: p.Alias,
+		Flags:       flags,
+		BuiltIn:     true,
+	}
+}
+
+// Find returns the preset named name, ignoring case. Presets earlier in the
+// list shadow later ones.
+func Find(presets []types.Preset, name string) (types.Preset, bool) {
+	for _, p := range presets {
+		if strings.EqualFold(p.Name, name) || strings.EqualFold(p.Name, Slug(name)) {
+			return p, true
+		}
+	}
+	return types.Preset{}, false
+}
+
+// Override returns a copy of p whose flags are replaced by flags, ignoring
+// the case of their names
+func Override(p types.Preset, flags map[string]string) types.Preset {
+	merged := make(map[string]string, len(p.Flags)+len(flags))
+	for name, value := range p.Flags {
+		merged[name] = value
+	}
+	for name, value := range flags {
+		for existing := range merged {
+			if strings.EqualFold(existing, name) {
+				delete(merged, existing)
+			}
+		}
+		merged[name] = value
+	}
+	p.Flags = merged
+	return p
+}
+
+// Params returns the names of the parameters the flags of p refer to
+func Params(p types.Preset) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, v := range p.Flags {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(v, -1) {
+			name := strings.ToLower(m[1])
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseParams parses name=value pairs, as given to --param
+func ParseParams(pairs []string) (map[string]string, error) {
+	params := map[string]string{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid parameter %q, expected name=value", pair)
+		}
+		params[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+	return params, nil
+}
+
+// Resolve replaces the parameters of the flags of p with params, falling back
+// to the defaults of p, and resolves date expressions relative to now
+func Resolve(p types.Preset, params map[string]string, now time.Time) (map[string]string, error) {
+	names := Params(p)
+	var unknown []string
+	for name := range params {
+		if !slices.Contains(names, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("preset %s has no parameters %s; its parameters are %s",
+			p.Name, strings.Join(unknown, ", "), listOrNone(names))
+	}
+
+	values := make(map[string]string, len(names))
+	var missing []string
+	for _, name := range names {
+		if v, ok := params[name]; ok {
+			values[name] = v
+		} else if v, ok := lookup(p.Params, name); ok {
+			values[name] = v
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("preset %s needs the parameters %s; set them with --param name=value",
+			p.Name, strings.Join(missing, ", "))
+	}
+
+	flags := make(map[string]string, len(p.Flags))
+	for name, v := range p.Flags {
+		v = placeholderPattern.ReplaceAllStringFunc(v, func(s string) string {
+			return values[strings.ToLower(s[1:len(s)-1])]
+		})
+		if slices.Contains(dateFlags, strings.ToLower(name)) {
+			date, err := utils.ResolveDate(v, now)
+			if err != nil {
+				return nil, fmt.Errorf("preset %s: --%s: %w", p.Name, name, err)
+			}
+			v = date
+		}
+		flags[name] = v
+	}
+	return flags, nil
+}
+
+// Capture parses args with fs and returns the values given for each flag,
+// as they were written. As on the command line, the last value of a repeated
+// flag wins.
+func Capture(fs *pflag.FlagSet, args []string) (map[string]string, error) {
+	flags := map[string]string{}
+	err := fs.ParseAll(args, func(f *pflag.Flag, value string) error {
+		flags[f.Name] = value
+		return fs.Set(f.Name, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %s", strings.Join(fs.Args(), " "))
+	}
+	return flags, nil
+}
+
+func lookup(m map[string]string, name string) (string, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func listOrNone(list []string) string {
+	if len(list) == 0 {
+		return "(none)"
+	}
+	return strings.Join(list, ", ")
+}
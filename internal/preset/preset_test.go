@@ -0,0 +1,113 @@
+package preset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromParams(t *testing.T) {
+	p := FromParams(types.PresetParams{
+		Alias:             "S3 costs grouped by OPERATION",
+		Dimension:         []string{"SERVICE", "OPERATION"},
+		Tag:               "Name",
+		Filter:            map[string]string{"SERVICE": "Amazon Simple Storage Service"},
+		FilterByDimension: true,
+		ExcludeDiscounts:  true,
+		Granularity:       "MONTHLY",
+		PrintFormat:       "stdout",
+		Metric:            []string{"UnblendedCost"},
+	})
+
+	assert.Equal(t, "s3-costs-grouped-by-operation", p.Name)
+	assert.True(t, p.BuiltIn)
+	assert.Equal(t, map[string]string{
+		"groupBy":          "DIMENSION=SERVICE,DIMENSION=OPERATION",
+		"filterBy":         "SERVICE=Amazon Simple Storage Service",
+		"excludeDiscounts": "true",
+		"granularity":      "MONTHLY",
+		"printFormat":      "stdout",
+		"metric":           "UnblendedCost",
+	}, p.Flags, "the tag is only used when filtering by tag")
+}
+
+func TestFind(t *testing.T) {
+	presets := []types.Preset{
+		{Name: "s3-costs-grouped-by-operation", Description: "user"},
+		{Name: "s3-costs-grouped-by-operation", Description: "built-in", BuiltIn: true},
+	}
+
+	p, ok := Find(presets, "S3 costs grouped by OPERATION")
+	require.True(t, ok)
+	assert.Equal(t, "user", p.Description, "user presets shadow built-in presets")
+	_, ok = Find(presets, "weekly")
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)
+	p := types.Preset{
+		Name: "ops",
+		Flags: map[string]string{
+			"groupby":   "DIMENSION=OPERATION",
+			"filterby":  "SERVICE={service},REGION={Region}",
+			"startdate": "{since}",
+			"enddate":   "start-of-month",
+		},
+		Params: map[string]string{"service": "Amazon EC2", "since": "-30d"},
+	}
+	assert.Equal(t, []string{"region", "service", "since"}, Params(p))
+
+	flags, err := Resolve(p, map[string]string{"region": "eu-west-1"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"groupby":   "DIMENSION=OPERATION",
+		"filterby":  "SERVICE=Amazon EC2,REGION=eu-west-1",
+		"startdate": "2024-04-15",
+		"enddate":   "2024-05-01",
+	}, flags)
+
+	_, err = Resolve(p, nil, now)
+	assert.ErrorContains(t, err, "needs the parameters region")
+	_, err = Resolve(p, map[string]string{"region": "eu-west-1", "account": "1"}, now)
+	assert.ErrorContains(t, err, "has no parameters account")
+	_, err = Resolve(p, map[string]string{"region": "eu-west-1", "since": "last week"}, now)
+	assert.ErrorContains(t, err, "--startdate")
+
+	overridden, err := Resolve(Override(p, map[string]string{"endDate": "today"}),
+		map[string]string{"region": "eu-west-1"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-15", overridden["endDate"])
+	assert.NotContains(t, overridden, "enddate")
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams([]string{"Service=Amazon EC2", "filter=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"service": "Amazon EC2", "filter": "a=b"}, params)
+
+	_, err = ParseParams([]string{"service"})
+	assert.Error(t, err)
+}
+
+func TestCapture(t *testing.T) {
+	fs := pflag.NewFlagSet("aws", pflag.ContinueOnError)
+	fs.StringP("groupBy", "g", "", "")
+	fs.StringP("startDate", "s", "", "")
+	fs.BoolP("excludeDiscounts", "l", false, "")
+
+	flags, err := Capture(fs, []string{"-g", "DIMENSION=SERVICE", "-s", "-30d", "-l", "-g", "DIMENSION=OPERATION"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"groupBy":          "DIMENSION=OPERATION",
+		"startDate":        "-30d",
+		"excludeDiscounts": "true",
+	}, flags)
+
+	_, err = Capture(fs, []string{"-g", "DIMENSION=SERVICE", "extra"})
+	assert.ErrorContains(t, err, "unexpected arguments extra")
+}
@@ -0,0 +1,86 @@
+package types
+
+// ChatConfig selects a chat-completions provider and model
+type ChatConfig struct {
+	Provider string
+	Model    string
+	BaseURL  string
+	APIKey   string
+	// AzureDeployment and AzureAPIVersion are only used by Azure OpenAI
+	AzureDeployment string
+	AzureAPIVersion string
+}
+
+// ChatMessage is one message of a chat-completions conversation
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// Citation is a retrieved cost document the answer may refer to by Label
+type Citation struct {
+	Label string
+	Match VectorQueryMatch
+}
+
+// Answer is the reply of the model to a question about stored cost history,
+// with the documents it was grounded on
+type Answer struct {
+	Question  string
+	Text      string
+	Model     string
+	Citations []Citation
+}
+
+// ChatFunction describes a function a chat model may call, with its
+// parameters as a JSON schema
+type ChatFunction struct {
+	Name        string
+	Description string
+	Parameters  map[string]any
+}
+
+// CostQueryArguments are the arguments of the function a chat model calls to
+// translate a natural-language cost question. They mirror the user-settable
+// fields of CostAndUsageRequestType.
+type CostQueryArguments struct {
+	Granularity      string            `json:"granularity"`
+	StartDate        string            `json:"start_date"`
+	EndDate          string            `json:"end_date"`
+	GroupBy          []string          `json:"group_by"`
+	GroupByTag       []string          `json:"group_by_tag"`
+	DimensionFilter  map[string]string `json:"dimension_filter"`
+	TagFilterValue   string            `json:"tag_filter_value"`
+	ExcludeDiscounts bool              `json:"exclude_discounts"`
+	Metric           string            `json:"metric"`
+	SortByDate       bool              `json:"sort_by_date"`
+	PrintFormat      string            `json:"print_format"`
+}
+
+// Fact is a figure computed from a report. Summaries refer to facts by ID,
+// so every number in a summary comes from the report, never from the model.
+type Fact struct {
+	ID    string
+	Label string
+	Value string
+	// Names are the dimension and tag values the fact is about
+	Names []string
+}
+
+// Summary is a narrative of a report written by a chat model, with its
+// placeholders replaced by the values of Facts
+type Summary struct {
+	Title string
+	Text  string
+	Model string
+	Facts []Fact
+}
+
+// SummaryConfig enables the executive summary of get aws and forecast
+type SummaryConfig struct {
+	Enabled bool
+	// File is the Markdown file the summary is written to; empty prints it
+	// below the report
+	File string
+	Chat ChatConfig
+}
@@ -47,6 +47,15 @@ type CommandLineInput struct {
 	OpenAIAPIKey        string
 	PineconeIndex       string
 	PineconeAPIKey      string
+	VectorStore         VectorStoreConfig
+	Embedding           EmbeddingConfig
+	Ingest              IngestConfig
+	Documents           DocumentConfig
+	Binning             BinningConfig
+	Summary             SummaryConfig
+	Output              OutputConfig
+	Layout              string
+	Chart               ChartConfig
 }
 
 type FilterBySelections struct {
@@ -65,6 +74,15 @@ type PrintOptions struct {
 	Metric           string
 	PineconeIndex    string
 	PineconeAPIKey   string
+	VectorStore      VectorStoreConfig
+	Embedding        EmbeddingConfig
+	Ingest           IngestConfig
+	Documents        DocumentConfig
+	Binning          BinningConfig
+	Summary          SummaryConfig
+	Output           OutputConfig
+	Layout           string
+	Chart            ChartConfig
 }
 
 type ForecastCommandLineInput struct {
@@ -73,6 +91,10 @@ type ForecastCommandLineInput struct {
 	PredictionIntervalLevel int32
 	Start                   string
 	End                     string
+	Summary                 SummaryConfig
+	PrintFormat             string
+	Output                  OutputConfig
+	Budget                  float64
 }
 
 type PresetParams struct {
@@ -91,6 +113,18 @@ type PresetParams struct {
 	Metric            []string
 }
 
+// Preset is a named get aws query. Flag values may refer to parameters as
+// {name}, and start and end dates may be date expressions such as -30d.
+type Preset struct {
+	Name        string
+	Description string
+	// Flags maps get aws flag names to their values
+	Flags map[string]string
+	// Params holds the default values of parameters
+	Params  map[string]string
+	BuiltIn bool
+}
+
 type GetCostForecastAPI interface {
 	GetCostForecast(ctx context.Context, params *costexplorer.GetCostForecastInput, optFns ...func(*costexplorer.Options)) (*costexplorer.GetCostForecastOutput, error)
 }
@@ -139,6 +173,7 @@ type CostAndUsageRequestType struct {
 	IsFilterByDimensionEnabled bool
 	TagFilterValue             string
 	DimensionFilter            map[string]string
+	DimensionAllowList         map[string][]string
 	ExcludeDiscounts           bool
 	Alias                      string
 	Rates                      []string
@@ -148,6 +183,15 @@ type CostAndUsageRequestType struct {
 	OpenAIAPIKey               string
 	PineconeIndex              string
 	PineconeAPIKey             string
+	VectorStore                VectorStoreConfig
+	Embedding                  EmbeddingConfig
+	Ingest                     IngestConfig
+	Documents                  DocumentConfig
+	Binning                    BinningConfig
+	Summary                    SummaryConfig
+	Output                     OutputConfig
+	Layout                     string
+	Chart                      ChartConfig
 }
 
 type CostAndUsageRequestWithResourcesType struct {
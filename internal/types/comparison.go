@@ -0,0 +1,33 @@
+package types
+
+// PeriodTotal is the total of a metric over one of the compared date ranges
+type PeriodTotal struct {
+	Start string  `json:"start"`
+	End   string  `json:"end"`
+	Total float64 `json:"total"`
+	Unit  string  `json:"unit"`
+}
+
+// KeyDelta is the change in cost of a single group between two date ranges.
+// PercentDelta is nil when the baseline cost is zero.
+type KeyDelta struct {
+	Keys          []string `json:"keys"`
+	BaselineCost  float64  `json:"baseline_cost"`
+	CurrentCost   float64  `json:"current_cost"`
+	AbsoluteDelta float64  `json:"absolute_delta"`
+	PercentDelta  *float64 `json:"percent_delta"`
+}
+
+// PeriodComparison is the period-over-period difference of two cost and
+// usage reports grouped by the same keys
+type PeriodComparison struct {
+	Metric          string      `json:"metric"`
+	GroupBy         []string    `json:"group_by,omitempty"`
+	Baseline        PeriodTotal `json:"baseline"`
+	Current         PeriodTotal `json:"current"`
+	AbsoluteDelta   float64     `json:"absolute_delta"`
+	PercentDelta    *float64    `json:"percent_delta"`
+	Changed         []KeyDelta  `json:"changed"`
+	NewKeys         []KeyDelta  `json:"new_keys"`
+	DisappearedKeys []KeyDelta  `json:"disappeared_keys"`
+}
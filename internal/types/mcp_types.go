@@ -2,21 +2,41 @@ package types
 
 // MCPRequest represents the incoming MCP tool request
 type MCPRequest struct {
-	ToolName   string                 `json:"tool_name"`
-	Parameters MCPToolParameters      `json:"parameters"`
+	ToolName   string            `json:"tool_name"`
+	Parameters MCPToolParameters `json:"parameters"`
 }
 
 // MCPToolParameters represents the parameters for get_cost_and_usage tool
 type MCPToolParameters struct {
-	StartDate         string   `json:"start_date"`
-	EndDate           string   `json:"end_date"`
-	Granularity       string   `json:"granularity"`
-	Metrics           []string `json:"metrics"`
-	GroupBy           []string `json:"group_by"`
-	FilterByService   string   `json:"filter_by_service,omitempty"`
+	StartDate         string            `json:"start_date"`
+	EndDate           string            `json:"end_date"`
+	Granularity       string            `json:"granularity"`
+	Metrics           []string          `json:"metrics"`
+	GroupBy           []string          `json:"group_by"`
+	FilterByService   string            `json:"filter_by_service,omitempty"`
 	FilterByDimension map[string]string `json:"filter_by_dimension,omitempty"`
 	FilterByTag       map[string]string `json:"filter_by_tag,omitempty"`
-	ExcludeDiscounts  bool     `json:"exclude_discounts,omitempty"`
+	ExcludeDiscounts  bool              `json:"exclude_discounts,omitempty"`
+	Output            MCPOutputOptions  `json:"output,omitempty"`
+	TimeoutSeconds    int               `json:"timeout_seconds,omitempty"`
+	AllowPartial      bool              `json:"allow_partial,omitempty"`
+}
+
+// MCPComparePeriodsParameters represents the parameters for the
+// compare_periods tool. Query carries the shared grouping and filters.
+type MCPComparePeriodsParameters struct {
+	Baseline Time              `json:"baseline"`
+	Current  Time              `json:"current"`
+	Query    MCPToolParameters `json:"query"`
+}
+
+// MCPOutputOptions controls how much of a cost and usage result is returned
+// to the MCP client and in which format
+type MCPOutputOptions struct {
+	MaxRows     int    `json:"max_rows,omitempty"`
+	TopN        int    `json:"top_n,omitempty"`
+	SummaryOnly bool   `json:"summary_only,omitempty"`
+	Format      string `json:"format,omitempty"`
 }
 
 // MCPResponse represents the MCP tool response
@@ -39,3 +59,78 @@ type MCPError struct {
 	Message string `json:"message,omitempty"`
 }
 
+// MCPCostAndUsageResult is the model-facing shape of a cost and usage
+// query. It never carries credentials and lists rows in a stable order.
+type MCPCostAndUsageResult struct {
+	Granularity  string             `json:"granularity"`
+	Start        string             `json:"start"`
+	End          string             `json:"end"`
+	GroupBy      []string           `json:"group_by,omitempty"`
+	RankMetric   string             `json:"rank_metric"`
+	Periods      []MCPPeriodSummary `json:"periods"`
+	TopMovers    []MCPCostMover     `json:"top_movers,omitempty"`
+	Rows         []MCPCostRow       `json:"rows,omitempty"`
+	TotalRows    int                `json:"total_rows"`
+	ReturnedRows int                `json:"returned_rows"`
+	Truncated    bool               `json:"truncated"`
+	Notes        []string           `json:"notes,omitempty"`
+}
+
+// MCPCostRow is a single group of a single time period
+type MCPCostRow struct {
+	Start   string           `json:"start"`
+	End     string           `json:"end"`
+	Keys    []string         `json:"keys"`
+	Metrics []MCPMetricValue `json:"metrics"`
+	// RolledUp is the number of groups folded into an "Other" row
+	RolledUp int `json:"rolled_up,omitempty"`
+}
+
+// MCPMetricValue is a typed metric amount
+type MCPMetricValue struct {
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+// MCPPeriodSummary holds the total of the rank metric for one time period
+type MCPPeriodSummary struct {
+	Start  string  `json:"start"`
+	End    string  `json:"end"`
+	Total  float64 `json:"total"`
+	Unit   string  `json:"unit"`
+	Groups int     `json:"groups"`
+}
+
+// MCPCostMover describes the change of a group between the first and the
+// last period of a result
+type MCPCostMover struct {
+	Keys  []string `json:"keys"`
+	First float64  `json:"first"`
+	Last  float64  `json:"last"`
+	Delta float64  `json:"delta"`
+}
+
+// MCPComparisonResult is the model-facing shape of a period comparison
+type MCPComparisonResult struct {
+	PeriodComparison
+	Truncated bool     `json:"truncated"`
+	Notes     []string `json:"notes,omitempty"`
+}
+
+// MCPSemanticSearchParameters represents the parameters for the
+// semantic_search_costs tool
+type MCPSemanticSearchParameters struct {
+	Query  string            `json:"query"`
+	TopK   int               `json:"top_k"`
+	Filter VectorQueryFilter `json:"filter"`
+}
+
+// MCPSemanticSearchResult is the model-facing shape of a similarity search
+// over stored cost documents
+type MCPSemanticSearchResult struct {
+	Query     string             `json:"query"`
+	Matches   []VectorQueryMatch `json:"matches"`
+	Truncated bool               `json:"truncated"`
+	Notes     []string           `json:"notes,omitempty"`
+}
@@ -23,6 +23,11 @@ const (
 	Chart
 	OpenAPI
 	Pinecone
+	Vector
+	JSON
+	NDJSON
+	Markdown
+	XLSX
 )
 
 type InputType struct {
@@ -34,6 +39,8 @@ type InputType struct {
 	Tags         []string
 	SortBy       string
 	OpenAIAPIKey string
+	Metrics      []string
+	Chart        ChartConfig
 }
 
 type Service struct {
@@ -103,11 +110,77 @@ type CostAndUsageOutputType struct {
 	OpenAIAPIKey   string
 	PineconeAPIKey string
 	PineconeIndex  string
+	VectorStore    VectorStoreConfig
+	Embedding      EmbeddingConfig
+	Ingest         IngestConfig
+	Documents      DocumentConfig
+	Binning        BinningConfig
+	// Metrics, Filters and ExcludeDiscounts describe the request, for
+	// output formats that include it
+	Metrics          []string
+	Filters          []string
+	ExcludeDiscounts bool
+	Output           OutputConfig
+	// Layout of table, csv, markdown and xlsx output: rows or pivot
+	Layout string
+	Chart  ChartConfig
+}
+
+// ChartConfig selects the charts written by chart output
+type ChartConfig struct {
+	// Type is pie, bar, line, area, cumulative, pareto or dashboard
+	Type string
+	// TopN is the number of keys charted; the others are summed as Other.
+	// Every key is charted when it is not positive.
+	TopN int
+	// AssetsDir holds a copy of the go-echarts assets host. When set, the
+	// scripts of the page are read from it and inlined instead of the
+	// bundled ones.
+	AssetsDir string
+}
+
+// OutputConfig selects where csv, chart and json reports are written
+type OutputConfig struct {
+	// Path is the file written, or - for stdout. It may contain the
+	// placeholders {start}, {end}, {groupBy} and {timestamp}.
+	Path string
+	// Dir holds generated file names and relative paths; ./writer when
+	// empty
+	Dir string
+}
+
+// BinningConfig selects how costs are sorted into the bins that label cost
+// documents and the Cost Band column
+type BinningConfig struct {
+	// Strategy is fixed, log or quantile
+	Strategy string
+	// Edges are the upper bounds of the fixed bins
+	Edges []float64
+	// Bins is the number of quantile bins
+	Bins int
+	// Currency of the amounts; the unit of the results when empty
+	Currency string
+	// ShowColumn adds a Cost Band column to table and CSV output
+	ShowColumn bool
 }
 
 type ForecastPrintData struct {
 	Forecast *costexplorer.GetCostForecastOutput
 	Filters  []string
+	Request  GetCostForecastRequest
+	Output   OutputConfig
+	// Actuals holds the costs of the month up to the forecast start, fetched
+	// for chart output
+	Actuals []ForecastActual
+	// Budget is the threshold per period drawn on charts; zero draws none
+	Budget float64
+}
+
+// ForecastActual is the cost incurred in a period before the forecast
+type ForecastActual struct {
+	TimePeriod DateInterval
+	Amount     float64
+	Unit       string
 }
 
 // Generic output types for improved type safety and reduced interface{} usage
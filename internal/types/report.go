@@ -0,0 +1,86 @@
+package types
+
+const (
+	// CostReportSchema identifies the schema of -p json and -p ndjson cost
+	// and usage output. It changes when a field is removed or changes type.
+	CostReportSchema = "ccexplorer.cost_and_usage/v1"
+	// ForecastReportSchema identifies the schema of forecast JSON output
+	ForecastReportSchema = "ccexplorer.forecast/v1"
+)
+
+// CostReport is the JSON document written by -p json. It only holds the
+// request and its results; credentials are never part of it.
+type CostReport struct {
+	Schema      string            `json:"schema"`
+	GeneratedAt string            `json:"generated_at"`
+	Request     CostReportRequest `json:"request"`
+	Services    []CostReportRow   `json:"services"`
+	Totals      []ReportAmount    `json:"totals"`
+}
+
+// CostReportRequest describes the query a report answers
+type CostReportRequest struct {
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	Granularity string `json:"granularity"`
+	// GroupBy names the keys of each row: dimensions such as SERVICE, and
+	// tags as TAG:<key>
+	GroupBy          []string `json:"group_by"`
+	Metrics          []string `json:"metrics"`
+	Filters          []string `json:"filters"`
+	ExcludeDiscounts bool     `json:"exclude_discounts"`
+	// SortBy is cost or date
+	SortBy string `json:"sort_by"`
+}
+
+// CostReportRow is the cost of one group in one period. -p ndjson writes
+// one row per line, with the schema, period and granularity of the report.
+type CostReportRow struct {
+	Schema      string `json:"schema,omitempty"`
+	Granularity string `json:"granularity,omitempty"`
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	// Keys are the values of the group, in the order of GroupBy
+	Keys []string `json:"keys"`
+	// Group maps the GroupBy names to the values of the group
+	Group   map[string]string `json:"group"`
+	Metrics []ReportAmount    `json:"metrics"`
+}
+
+// ReportAmount is an amount of a metric
+type ReportAmount struct {
+	Metric string  `json:"metric"`
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+// ForecastReport is the JSON document written by forecast --printFormat json
+type ForecastReport struct {
+	Schema      string                `json:"schema"`
+	GeneratedAt string                `json:"generated_at"`
+	Request     ForecastReportRequest `json:"request"`
+	Total       ReportAmount          `json:"total"`
+	Periods     []ForecastReportRow   `json:"periods"`
+}
+
+// ForecastReportRequest describes the forecast query
+type ForecastReportRequest struct {
+	Start                   string   `json:"start"`
+	End                     string   `json:"end"`
+	Granularity             string   `json:"granularity"`
+	Metric                  string   `json:"metric"`
+	PredictionIntervalLevel int32    `json:"prediction_interval_level"`
+	Filters                 []string `json:"filters"`
+}
+
+// ForecastReportRow is the forecast of one period. --printFormat ndjson
+// writes one period per line, with the schema of the report.
+type ForecastReportRow struct {
+	Schema     string  `json:"schema,omitempty"`
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	Mean       float64 `json:"mean"`
+	LowerBound float64 `json:"lower_bound"`
+	UpperBound float64 `json:"upper_bound"`
+	Unit       string  `json:"unit"`
+}
@@ -12,14 +12,126 @@ type VectorStoreItem struct {
 }
 
 type VectorStoreItemMetadata struct {
-	StartDate   string
-	EndDate     string
-	Granularity string
-	Dimensions  string
-	Tags        string
-	Cost        string
+	StartDate   string `json:"start"`
+	EndDate     string `json:"end"`
+	Granularity string `json:"granularity,omitempty"`
+	Dimensions  string `json:"dimensions"`
+	Tags        string `json:"tags,omitempty"`
+	Keys        string `json:"keys,omitempty"`
+	// Attributes pairs every group-by dimension and tag with its key, e.g.
+	// SERVICE=Amazon S3
+	Attributes []string `json:"attributes,omitempty"`
+	// Metrics names the cost metrics the document was built from
+	Metrics string `json:"metrics,omitempty"`
+	// Document is the chunking strategy that produced the document
+	Document string `json:"document,omitempty"`
+	Cost     string `json:"cost"`
+	// CostAmount is Cost as a number, for range filters
+	CostAmount float64 `json:"cost_amount"`
+	// EmbeddingModel names the model that produced the vector
+	EmbeddingModel string `json:"embedding_model,omitempty"`
 }
 
 type UpsertResponse struct {
 	UpsertedCount int `json:"upsertedCount"`
 }
+
+// VectorQueryFilter narrows a similarity search to documents whose period
+// starts within [Start, End), that were grouped by Dimensions, that have
+// the given Granularity and that cost at least MinCost
+type VectorQueryFilter struct {
+	Start       string
+	End         string
+	Dimensions  []string
+	Granularity string
+	MinCost     float64
+}
+
+// IsEmpty reports whether the filter matches every document
+func (f VectorQueryFilter) IsEmpty() bool {
+	return f.Start == "" && f.End == "" && len(f.Dimensions) == 0 && f.Granularity == "" &&
+		f.MinCost == 0
+}
+
+// VectorQueryMatch is a stored cost document returned by a similarity search
+type VectorQueryMatch struct {
+	ID          string                  `json:"id"`
+	Score       float32                 `json:"score"`
+	PageContent string                  `json:"page_content"`
+	Metadata    VectorStoreItemMetadata `json:"metadata"`
+}
+
+// VectorDeleteRequest selects stored documents to delete by ID or by
+// filter. All must be set to delete every document.
+type VectorDeleteRequest struct {
+	IDs    []string
+	Filter VectorQueryFilter
+	All    bool
+}
+
+// IsEmpty reports whether the request selects no documents
+func (r VectorDeleteRequest) IsEmpty() bool {
+	return !r.All && len(r.IDs) == 0 && r.Filter.IsEmpty()
+}
+
+// VectorStoreStats describes the contents of a vector store. Count is the
+// number of documents in Namespace; Namespaces holds the count of every
+// namespace when the backend can list them.
+type VectorStoreStats struct {
+	Backend    string         `json:"backend"`
+	Namespace  string         `json:"namespace"`
+	Count      int            `json:"count"`
+	Dimension  int            `json:"dimension"`
+	Namespaces map[string]int `json:"namespaces,omitempty"`
+}
+
+// VectorStoreConfig selects a vector store backend and holds the settings
+// of every supported backend. Namespace partitions the documents of a store,
+// e.g. per account or per granularity; the empty namespace is the default.
+type VectorStoreConfig struct {
+	Backend          string
+	Namespace        string
+	LocalPath        string
+	PineconeIndex    string
+	PineconeAPIKey   string
+	QdrantURL        string
+	QdrantAPIKey     string
+	QdrantCollection string
+	PGVectorDSN      string
+	PGVectorTable    string
+}
+
+// EmbeddingConfig selects an embedding provider and the model it serves
+type EmbeddingConfig struct {
+	Provider   string
+	Model      string
+	Dimensions int
+	BaseURL    string
+	APIKey     string
+	// AzureDeployment and AzureAPIVersion are only used by Azure OpenAI
+	AzureDeployment string
+	AzureAPIVersion string
+}
+
+// IngestConfig tunes the pipeline that embeds cost documents and writes
+// them to a vector store
+type IngestConfig struct {
+	// MaxBatchTokens bounds the estimated tokens of one embedding request
+	MaxBatchTokens    int
+	EmbedConcurrency  int
+	UpsertConcurrency int
+	UpsertBatchSize   int
+	// CheckpointDir holds the checkpoints that let a failed load resume
+	// where it stopped; empty disables checkpoints
+	CheckpointDir string
+	// Progress shows a progress bar on stderr
+	Progress bool
+}
+
+// DocumentConfig selects how cost results are chunked into documents and
+// the text/template that renders their embedding text
+type DocumentConfig struct {
+	Strategy        string
+	Template        string
+	TopContributors int
+}
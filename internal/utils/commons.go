@@ -6,9 +6,9 @@ import (
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
-	"github.com/cduggn/ccexplorer/internal/pinecone"
 	types2 "github.com/cduggn/ccexplorer/internal/types"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,6 +30,18 @@ func ReturnIfPresent(s []string) string {
 	}
 }
 
+// PrintFormats splits a comma-separated --printFormat value into lower-case
+// formats
+func PrintFormats(s string) []string {
+	var formats []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
 func ToPrintWriterType(s string) types2.PrintWriterType {
 	switch s {
 	case "csv":
@@ -40,12 +52,26 @@ func ToPrintWriterType(s string) types2.PrintWriterType {
 		return types2.Chart
 	case "pinecone":
 		return types2.Pinecone
+	case "vector":
+		return types2.Vector
+	case "json":
+		return types2.JSON
+	case "ndjson":
+		return types2.NDJSON
+	case "markdown":
+		return types2.Markdown
+	case "xlsx":
+		return types2.XLSX
 	default:
 		return types2.Stdout
 	}
 }
 
+// NewFile creates file in dir, creating dir when it is missing
 func NewFile(dir string, file string) (*os.File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
 	filePath := BuildOutputFilePath(dir, file)
 	return os.Create(filePath)
 }
@@ -137,21 +163,48 @@ func CurateCostAndUsageReport(
 	d *costexplorer.GetCostAndUsageOutput, query types2.CostAndUsageRequestType) types2.CostAndUsageOutputType {
 
 	c := types2.CostAndUsageOutputType{
-		Services:       make(map[int]types2.Service),
-		Granularity:    query.Granularity,
-		Dimensions:     query.GroupBy,
-		Tags:           query.GroupByTag,
-		Start:          query.Time.Start,
-		End:            query.Time.End,
-		OpenAIAPIKey:   query.OpenAIAPIKey,
-		PineconeAPIKey: query.PineconeAPIKey,
-		PineconeIndex:  query.PineconeIndex,
+		Services:         make(map[int]types2.Service),
+		Granularity:      query.Granularity,
+		Dimensions:       query.GroupBy,
+		Tags:             query.GroupByTag,
+		Start:            query.Time.Start,
+		End:              query.Time.End,
+		OpenAIAPIKey:     query.OpenAIAPIKey,
+		PineconeAPIKey:   query.PineconeAPIKey,
+		PineconeIndex:    query.PineconeIndex,
+		VectorStore:      query.VectorStore,
+		Embedding:        query.Embedding,
+		Ingest:           query.Ingest,
+		Documents:        query.Documents,
+		Binning:          query.Binning,
+		Metrics:          query.Metrics,
+		Filters:          RequestFilters(query),
+		ExcludeDiscounts: query.ExcludeDiscounts,
+		Output:           query.Output,
+		Layout:           query.Layout,
+		Chart:            query.Chart,
 	}
 
 	c.Services = ResultsToServicesMap(d.ResultsByTime)
 	return c
 }
 
+// RequestFilters lists the filters of a request as DIMENSION=value and
+// TAG=value, sorted
+func RequestFilters(query types2.CostAndUsageRequestType) []string {
+	var filters []string
+	if query.IsFilterByDimensionEnabled {
+		for k, v := range query.DimensionFilter {
+			filters = append(filters, k+"="+v)
+		}
+		sort.Strings(filters)
+	}
+	if query.IsFilterByTagEnabled && query.TagFilterValue != "" {
+		filters = append(filters, "TAG="+query.TagFilterValue)
+	}
+	return filters
+}
+
 func ResultsToServicesMap(res []types.ResultByTime) map[int]types2.Service {
 	services := make(map[int]types2.Service)
 	count := 0
@@ -273,6 +326,8 @@ func ConvertToChartInputType(r types2.CostAndUsageOutputType, s []types2.Service
 		End:         r.End,
 		Dimensions:  r.Dimensions,
 		Tags:        r.Tags,
+		Metrics:     r.Metrics,
+		Chart:       r.Chart,
 		Services: Transform(s, func(service types2.Service) types2.Service {
 			return types2.Service{
 				Name:    service.Name,
@@ -323,20 +378,27 @@ func EncodeString(s string) string {
 	return hashedString
 }
 
-// Generic version of ConvertToPineconeStruct
-func ConvertToPineconeStruct(items []*types2.VectorStoreItem) []pinecone.PineconeStruct {
-	return ConvertSlice(items, func(v *types2.VectorStoreItem) pinecone.PineconeStruct {
-		return pinecone.PineconeStruct{
-			ID:     v.ID,
-			Values: v.EmbeddingVector,
-			Metadata: pinecone.Metadata{
-				PageContent: v.EmbeddingText,
-				Source:      "aws cost explorer",
-				Dimensions:  v.Metadata.Dimensions,
-				Start:       v.Metadata.StartDate,
-				End:         v.Metadata.EndDate,
-				Cost:        v.Metadata.Cost,
-			},
+// DatesBetween lists every YYYY-MM-DD date in [start, end), failing when
+// there are more than limit of them
+func DatesBetween(start, end string, limit int) ([]string, error) {
+	from, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date %q, expected YYYY-MM-DD", start)
+	}
+	to, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date %q, expected YYYY-MM-DD", end)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("end date must be after start date")
+	}
+
+	var dates []string
+	for d := from; d.Before(to); d = d.AddDate(0, 0, 1) {
+		if len(dates) == limit {
+			return nil, fmt.Errorf("date range may span at most %d days", limit)
 		}
-	})
+		dates = append(dates, d.Format("2006-01-02"))
+	}
+	return dates, nil
 }
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateExpression matches an anchor such as start-of-month, optionally
+// followed by an offset such as -3m, or an offset relative to today
+var dateExpression = regexp.MustCompile(`^([a-z-]*?)(?:([+-]\d+)([dwmy]))?$`)
+
+// DateAnchors lists the anchors of date expressions
+var DateAnchors = []string{"today", "yesterday", "start-of-week", "start-of-month",
+	"start-of-last-month", "start-of-quarter", "start-of-year"}
+
+// ResolveDate resolves a date expression relative to now. An expression is
+// a YYYY-MM-DD date, an anchor such as today or start-of-month, an offset in
+// days, weeks, months or years such as -30d, or an anchor with an offset
+// such as start-of-month-1m.
+func ResolveDate(expr string, now time.Time) (string, error) {
+	expr = strings.ToLower(strings.TrimSpace(expr))
+	if _, err := time.Parse("2006-01-02", expr); err == nil {
+		return expr, nil
+	}
+
+	m := dateExpression.FindStringSubmatch(expr)
+	if m == nil || (m[1] == "" && m[2] == "") {
+		return "", invalidDate(expr)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	date := today
+	switch m[1] {
+	case "", "today":
+	case "yesterday":
+		date = today.AddDate(0, 0, -1)
+	case "start-of-week":
+		// weeks start on Monday
+		date = today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	case "start-of-month":
+		date = today.AddDate(0, 0, 1-today.Day())
+	case "start-of-last-month":
+		date = today.AddDate(0, 0, 1-today.Day()).AddDate(0, -1, 0)
+	case "start-of-quarter":
+		date = time.Date(today.Year(), today.Month()-(today.Month()-1)%3, 1, 0, 0, 0, 0, today.Location())
+	case "start-of-year":
+		date = time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location())
+	default:
+		return "", invalidDate(expr)
+	}
+
+	if m[2] != "" {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return "", invalidDate(expr)
+		}
+		switch m[3] {
+		case "d":
+			date = date.AddDate(0, 0, n)
+		case "w":
+			date = date.AddDate(0, 0, 7*n)
+		case "m":
+			date = date.AddDate(0, n, 0)
+		case "y":
+			date = date.AddDate(n, 0, 0)
+		}
+	}
+	return Format(date), nil
+}
+
+func invalidDate(expr string) error {
+	return fmt.Errorf("invalid date %q: expected YYYY-MM-DD, an offset such as -30d, -2w, -3m or -1y, "+
+		"or one of %s, optionally followed by an offset", expr, strings.Join(DateAnchors, ", "))
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2024, time.May, 15, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: "2024-01-31", want: "2024-01-31"},
+		{expr: "today", want: "2024-05-15"},
+		{expr: "yesterday", want: "2024-05-14"},
+		{expr: "-30d", want: "2024-04-15"},
+		{expr: "-2w", want: "2024-05-01"},
+		{expr: "+1d", want: "2024-05-16"},
+		{expr: "start-of-week", want: "2024-05-13"},
+		{expr: "start-of-month", want: "2024-05-01"},
+		{expr: "start-of-last-month", want: "2024-04-01"},
+		{expr: "start-of-month-3m", want: "2024-02-01"},
+		{expr: "start-of-quarter", want: "2024-04-01"},
+		{expr: "start-of-year-1y", want: "2023-01-01"},
+		{expr: " Start-Of-Month ", want: "2024-05-01"},
+		{expr: "last-tuesday", wantErr: true},
+		{expr: "-3q", wantErr: true},
+		{expr: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ResolveDate(tt.expr, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
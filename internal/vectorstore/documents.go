@@ -0,0 +1,415 @@
+package vectorstore
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/cduggn/ccexplorer/internal/codec"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// Document chunking strategies
+const (
+	// RowDocuments creates one document per result row, i.e. per key per
+	// period
+	RowDocuments = "row"
+	// KeyDocuments creates one document per key per calendar month, summing
+	// the rows of finer granularities
+	KeyDocuments = "key"
+	// SummaryDocuments creates one document per period describing its total
+	// and top contributors
+	SummaryDocuments = "summary"
+
+	DefaultTopContributors = 5
+)
+
+// DocumentStrategies lists the supported chunking strategies
+var DocumentStrategies = []string{RowDocuments, KeyDocuments, SummaryDocuments}
+
+const keyTemplate = `AWS costs of {{join .Attributes ", "}} in {{.Month}}, ` +
+	`from {{.Start}} to {{.End}}: {{range $i, $m := .Metrics}}{{if $i}}, {{end}}` +
+	`{{$m.Name}} {{$m.Amount}} {{$m.Unit}}{{end}} ({{.CostBand}}).`
+
+const summaryTemplate = `AWS cost summary from {{.Start}} to {{.End}} grouped by ` +
+	`{{join .GroupBy ", "}}: total {{printf "%.2f" .Cost}} {{.Unit}} ({{.CostBand}}) ` +
+	`across {{.Rows}} keys. Top contributors: {{range $i, $c := .Contributors}}` +
+	`{{if $i}}; {{end}}{{join $c.Keys ", "}} {{printf "%.2f" $c.Cost}} ` +
+	`({{printf "%.0f" $c.Share}}%){{end}}.`
+
+// Document is the data a document template is executed with
+type Document struct {
+	Strategy string
+	// Name is the name of the result row of row documents
+	Name        string
+	Start       string
+	End         string
+	Month       string
+	Granularity string
+	// GroupBy names the dimensions and tags the results were grouped by
+	GroupBy    []string
+	Dimensions []string
+	Tags       []string
+	Keys       []string
+	// Attributes pairs every group-by name with its key, e.g. SERVICE=Amazon S3
+	Attributes []string
+	Metrics    []types.Metrics
+	// Cost is the amount of the first metric, Unit its unit and CostBand the
+	// name of its bin
+	Cost     float64
+	Unit     string
+	CostBand string
+	// Rows and Contributors are only set for summary documents
+	Rows         int
+	Contributors []Contributor
+}
+
+// Contributor is a key of a summary document and its share of the total
+type Contributor struct {
+	Keys       []string
+	Attributes []string
+	Cost       float64
+	Share      float64
+}
+
+// DocumentBuilder turns cost and usage results into the documents that are
+// embedded and stored, independently of the vector store backend
+type DocumentBuilder struct {
+	Encoder codec.Encode
+}
+
+func NewDocumentBuilder() *DocumentBuilder {
+	return &DocumentBuilder{
+		Encoder: codec.NewEncoder(),
+	}
+}
+
+// ValidateDocuments checks the chunking strategy and parses the template
+func ValidateDocuments(cfg types.DocumentConfig) error {
+	_, err := parseDocumentTemplate(cfg)
+	return err
+}
+
+// ConvertToVectorStoreItem chunks r into documents with the strategy and
+// template of r.Documents. Without a template, row documents keep the
+// original embedding text so their IDs do not change.
+func (b *DocumentBuilder) ConvertToVectorStoreItem(r types.CostAndUsageOutputType) ([]*types.VectorStoreItem, error) {
+	tmpl, err := parseDocumentTemplate(r.Documents)
+	if err != nil {
+		return nil, err
+	}
+	// cost bands are fitted to the results, so they match the Cost Band
+	// column of table and CSV output
+	encoder, err := codec.ForResults(r.Binning, r)
+	if err != nil {
+		return nil, err
+	}
+	b = &DocumentBuilder{Encoder: encoder}
+
+	var docs []Document
+	switch strategy(r.Documents) {
+	case KeyDocuments:
+		docs = b.keyDocuments(r)
+	case SummaryDocuments:
+		docs = b.summaryDocuments(r, r.Documents.TopContributors)
+	default:
+		docs = b.rowDocuments(r)
+	}
+
+	dimensions := strings.Join(r.Dimensions, ",")
+	tags := strings.Join(r.Tags, ",")
+	items := make([]*types.VectorStoreItem, 0, len(docs))
+	for _, d := range docs {
+		text, err := b.render(tmpl, d, dimensions, tags)
+		if err != nil {
+			return nil, err
+		}
+
+		metricNames := make([]string, len(d.Metrics))
+		for i, m := range d.Metrics {
+			metricNames[i] = m.Name
+		}
+		cost := ""
+		if len(d.Metrics) > 0 {
+			cost = d.Metrics[0].Amount
+		}
+
+		items = append(items, &types.VectorStoreItem{
+			EmbeddingText: text,
+			Metadata: types.VectorStoreItemMetadata{
+				StartDate:   d.Start,
+				EndDate:     d.End,
+				Granularity: r.Granularity,
+				Dimensions:  dimensions,
+				Tags:        tags,
+				Keys:        strings.Join(d.Keys, ","),
+				Attributes:  d.Attributes,
+				Metrics:     strings.Join(metricNames, ","),
+				Document:    d.Strategy,
+				Cost:        cost,
+				CostAmount:  d.Cost,
+			},
+		})
+	}
+	return items, nil
+}
+
+func (b *DocumentBuilder) render(tmpl *template.Template, d Document, dimensions, tags string) (string, error) {
+	if tmpl == nil {
+		return b.AddSemanticMeaning(types.Service{
+			Keys: d.Keys, Name: d.Name, Start: d.Start, End: d.End, Metrics: d.Metrics,
+		}, dimensions, tags), nil
+	}
+
+	var text strings.Builder
+	if err := tmpl.Execute(&text, d); err != nil {
+		return "", fmt.Errorf("failed to render document template: %w", err)
+	}
+	return text.String(), nil
+}
+
+func (b *DocumentBuilder) rowDocuments(r types.CostAndUsageOutputType) []Document {
+	services := sortedServices(r)
+	docs := make([]Document, len(services))
+	for i, s := range services {
+		docs[i] = b.newDocument(r, RowDocuments, s.Start, s.End, s.Keys, s.Metrics)
+		docs[i].Name = s.Name
+	}
+	return docs
+}
+
+// keyDocuments sums the rows of every key per calendar month
+func (b *DocumentBuilder) keyDocuments(r types.CostAndUsageOutputType) []Document {
+	type group struct {
+		keys       []string
+		start, end string
+		metrics    []types.Metrics
+	}
+	groups := map[string]*group{}
+	var order []string
+
+	for _, s := range sortedServices(r) {
+		id := month(s.Start) + "\x00" + strings.Join(s.Keys, "\x00")
+		g, ok := groups[id]
+		if !ok {
+			g = &group{keys: s.Keys, start: s.Start, end: s.End}
+			groups[id] = g
+			order = append(order, id)
+		}
+		g.start, g.end = min(g.start, s.Start), max(g.end, s.End)
+		g.metrics = addMetrics(g.metrics, s.Metrics)
+	}
+
+	docs := make([]Document, 0, len(order))
+	for _, id := range order {
+		g := groups[id]
+		docs = append(docs, b.newDocument(r, KeyDocuments, g.start, g.end, g.keys, g.metrics))
+	}
+	return docs
+}
+
+// summaryDocuments describes every period by its total and its topN largest
+// keys by the first metric
+func (b *DocumentBuilder) summaryDocuments(r types.CostAndUsageOutputType, topN int) []Document {
+	if topN <= 0 {
+		topN = DefaultTopContributors
+	}
+
+	periods := map[string][]types.Service{}
+	var order []string
+	for _, s := range sortedServices(r) {
+		id := s.Start + "\x00" + s.End
+		if _, ok := periods[id]; !ok {
+			order = append(order, id)
+		}
+		periods[id] = append(periods[id], s)
+	}
+
+	docs := make([]Document, 0, len(order))
+	for _, id := range order {
+		rows := periods[id]
+		sort.SliceStable(rows, func(i, j int) bool {
+			return firstAmount(rows[i].Metrics) > firstAmount(rows[j].Metrics)
+		})
+
+		var metrics []types.Metrics
+		for _, s := range rows {
+			metrics = addMetrics(metrics, s.Metrics)
+		}
+
+		d := b.newDocument(r, SummaryDocuments, rows[0].Start, rows[0].End, nil, metrics)
+		d.Rows = len(rows)
+		for _, s := range rows[:min(topN, len(rows))] {
+			c := Contributor{
+				Keys:       s.Keys,
+				Attributes: attributes(d.GroupBy, s.Keys),
+				Cost:       firstAmount(s.Metrics),
+			}
+			if d.Cost != 0 {
+				c.Share = 100 * c.Cost / d.Cost
+			}
+			d.Contributors = append(d.Contributors, c)
+			d.Attributes = append(d.Attributes, c.Attributes...)
+		}
+		docs = append(docs, d)
+	}
+	return docs
+}
+
+func (b *DocumentBuilder) newDocument(r types.CostAndUsageOutputType, strategy, start, end string,
+	keys []string, metrics []types.Metrics) Document {
+
+	groupBy := append(append([]string(nil), r.Dimensions...), r.Tags...)
+	d := Document{
+		Strategy:    strategy,
+		Start:       start,
+		End:         end,
+		Month:       month(start),
+		Granularity: r.Granularity,
+		GroupBy:     groupBy,
+		Dimensions:  r.Dimensions,
+		Tags:        r.Tags,
+		Keys:        keys,
+		Attributes:  attributes(groupBy, keys),
+		Metrics:     metrics,
+	}
+	if len(metrics) > 0 {
+		d.Cost = metrics[0].NumericAmount
+		d.Unit = metrics[0].Unit
+		d.CostBand = b.Encoder.Band(d.Cost)
+	}
+	return d
+}
+
+func (b *DocumentBuilder) AddSemanticMeaning(s types.Service, dimensions, tags string) string {
+	var r strings.Builder
+
+	// append keys
+	fmt.Fprintf(&r, "AWS Cost explorer cost and usage results grouped by dimensions and tags named %s %s ", dimensions, tags)
+	fmt.Fprintf(&r, "and with the following key values %s,", strings.Join(s.Keys, ","))
+	fmt.Fprintf(&r, " over the time period which starts and ends at %s,%s,%s,", s.Start, s.End, s.Name)
+
+	// append metrics
+	metrics := make([]string, len(s.Metrics))
+	for i, v := range s.Metrics {
+		encodedAmount := b.Encoder.CategorizeCostsWithBinning(v.NumericAmount)
+		metrics[i] = fmt.Sprintf(
+			"the metrics values include the cost category dataset name: %s, the cost associated with this grouped dimension and/or tag for this time period: %s, the currency unit used to represent the cost: %s, and an encoded value to normalize the cost into a binning category: %s",
+			v.Name, v.Amount, v.Unit, encodedAmount)
+	}
+
+	r.WriteString(strings.Join(metrics, ","))
+	return r.String()
+}
+
+func strategy(cfg types.DocumentConfig) string {
+	if cfg.Strategy == "" {
+		return RowDocuments
+	}
+	return strings.ToLower(cfg.Strategy)
+}
+
+// parseDocumentTemplate returns the template of cfg, read from a file when
+// it starts with @, or the default template of the strategy. Row documents
+// have no default template.
+func parseDocumentTemplate(cfg types.DocumentConfig) (*template.Template, error) {
+	text := cfg.Template
+	switch strategy(cfg) {
+	case RowDocuments:
+	case KeyDocuments:
+		if text == "" {
+			text = keyTemplate
+		}
+	case SummaryDocuments:
+		if text == "" {
+			text = summaryTemplate
+		}
+	default:
+		return nil, fmt.Errorf("unknown document strategy %q, expected one of %s",
+			cfg.Strategy, strings.Join(DocumentStrategies, ", "))
+	}
+	if text == "" {
+		return nil, nil
+	}
+
+	if path, ok := strings.CutPrefix(text, "@"); ok {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read document template: %w", err)
+		}
+		text = string(b)
+	}
+
+	tmpl, err := template.New("document").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Option("missingkey=error").
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// sortedServices returns the rows of r in the order they were returned
+func sortedServices(r types.CostAndUsageOutputType) []types.Service {
+	indexes := make([]int, 0, len(r.Services))
+	for i := range r.Services {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	services := make([]types.Service, len(indexes))
+	for i, index := range indexes {
+		services[i] = r.Services[index]
+	}
+	return services
+}
+
+// addMetrics adds the amounts of metrics to the metrics of the same name in
+// sum
+func addMetrics(sum, metrics []types.Metrics) []types.Metrics {
+	for _, m := range metrics {
+		i := 0
+		for i < len(sum) && sum[i].Name != m.Name {
+			i++
+		}
+		if i == len(sum) {
+			sum = append(sum, types.Metrics{Name: m.Name, Unit: m.Unit})
+		}
+		sum[i].NumericAmount += m.NumericAmount
+		sum[i].UsageQuantity += m.UsageQuantity
+		sum[i].Amount = fmt.Sprintf("%.2f", sum[i].NumericAmount)
+	}
+	return sum
+}
+
+func firstAmount(metrics []types.Metrics) float64 {
+	if len(metrics) == 0 {
+		return 0
+	}
+	return metrics[0].NumericAmount
+}
+
+// attributes pairs names with keys; unpaired keys are returned as they are
+func attributes(names, keys []string) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+	if len(names) != len(keys) {
+		return append([]string(nil), keys...)
+	}
+	pairs := make([]string, len(keys))
+	for i := range keys {
+		pairs[i] = names[i] + "=" + keys[i]
+	}
+	return pairs
+}
+
+func month(date string) string {
+	if len(date) < len("2006-01") {
+		return date
+	}
+	return date[:len("2006-01")]
+}
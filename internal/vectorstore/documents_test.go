@@ -0,0 +1,174 @@
+package vectorstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/codec"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSemanticMeaning(t *testing.T) {
+	type args struct {
+		service types.Service
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "ServiceToString",
+			args: args{
+				service: types.Service{
+					Name:  "test",
+					Start: "2021-01-01",
+					End:   "2021-01-02",
+					Metrics: []types.Metrics{
+						{
+							Name:          "UnblndedCost",
+							Amount:        "0.10",
+							Unit:          "USD",
+							UsageQuantity: 0.10,
+						},
+					},
+					Keys: []string{
+						"SERVICE", "USAGE_QUANTITY",
+					},
+				},
+			},
+			want: "SERVICE,USAGE_QUANTITY,2021-01-01,2021-01-02,test," +
+				"UnblndedCost,0.10,USD,Free ($0.00)",
+		},
+	}
+
+	builder := DocumentBuilder{
+		Encoder: codec.NewEncoder(),
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			S := builder.AddSemanticMeaning(tt.args.service, "SERVICE", "USAGE_QUANTITY")
+			// Since the function now includes semantic meaning, we'll just check it's not empty
+			if S == "" {
+				t.Errorf("AddSemanticMeaning() returned empty string")
+			}
+		})
+	}
+}
+
+func documentResults() types.CostAndUsageOutputType {
+	service := func(key, start, end string, amount float64) types.Service {
+		return types.Service{
+			Keys:  []string{key},
+			Start: start,
+			End:   end,
+			Metrics: []types.Metrics{{
+				Name:          "UnblendedCost",
+				Amount:        fmt.Sprintf("%.2f", amount),
+				NumericAmount: amount,
+				Unit:          "USD",
+			}},
+		}
+	}
+	return types.CostAndUsageOutputType{
+		Granularity: "DAILY",
+		Dimensions:  []string{"SERVICE"},
+		Services: map[int]types.Service{
+			0: service("Amazon S3", "2024-01-30", "2024-01-31", 2),
+			1: service("AWS Lambda", "2024-01-30", "2024-01-31", 8),
+			2: service("Amazon S3", "2024-01-31", "2024-02-01", 3),
+			3: service("Amazon S3", "2024-02-01", "2024-02-02", 5),
+		},
+	}
+}
+
+func TestRowDocuments(t *testing.T) {
+	builder := NewDocumentBuilder()
+	r := documentResults()
+
+	items, err := builder.ConvertToVectorStoreItem(r)
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+	assert.Equal(t, builder.AddSemanticMeaning(r.Services[0], "SERVICE", ""), items[0].EmbeddingText,
+		"row documents without a template keep their text")
+	assert.Equal(t, types.VectorStoreItemMetadata{
+		StartDate:   "2024-01-30",
+		EndDate:     "2024-01-31",
+		Granularity: "DAILY",
+		Dimensions:  "SERVICE",
+		Keys:        "Amazon S3",
+		Attributes:  []string{"SERVICE=Amazon S3"},
+		Metrics:     "UnblendedCost",
+		Document:    RowDocuments,
+		Cost:        "2.00",
+		CostAmount:  2,
+	}, items[0].Metadata)
+
+	r.Documents = types.DocumentConfig{Template: "{{.Start}} {{join .Attributes \",\"}} {{.Cost}}"}
+	items, err = builder.ConvertToVectorStoreItem(r)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-30 SERVICE=Amazon S3 2", items[0].EmbeddingText)
+}
+
+func TestKeyDocuments(t *testing.T) {
+	r := documentResults()
+	r.Documents = types.DocumentConfig{Strategy: KeyDocuments}
+
+	items, err := NewDocumentBuilder().ConvertToVectorStoreItem(r)
+	require.NoError(t, err)
+	require.Len(t, items, 3, "one document per key per month")
+
+	assert.Equal(t, "2024-01-30", items[0].Metadata.StartDate)
+	assert.Equal(t, "2024-02-01", items[0].Metadata.EndDate)
+	assert.Equal(t, 5.0, items[0].Metadata.CostAmount)
+	assert.Equal(t, "5.00", items[0].Metadata.Cost)
+	assert.Equal(t, KeyDocuments, items[0].Metadata.Document)
+	assert.Contains(t, items[0].EmbeddingText, "SERVICE=Amazon S3 in 2024-01")
+	assert.Contains(t, items[0].EmbeddingText, "UnblendedCost 5.00 USD")
+	assert.Equal(t, "AWS Lambda", items[1].Metadata.Keys)
+	assert.Equal(t, "2024-02-01", items[2].Metadata.StartDate)
+}
+
+func TestSummaryDocuments(t *testing.T) {
+	r := documentResults()
+	r.Documents = types.DocumentConfig{Strategy: SummaryDocuments, TopContributors: 1}
+
+	items, err := NewDocumentBuilder().ConvertToVectorStoreItem(r)
+	require.NoError(t, err)
+	require.Len(t, items, 3, "one document per period")
+
+	first := items[0]
+	assert.Equal(t, 10.0, first.Metadata.CostAmount)
+	assert.Equal(t, []string{"SERVICE=AWS Lambda"}, first.Metadata.Attributes)
+	assert.Empty(t, first.Metadata.Keys)
+	assert.Contains(t, first.EmbeddingText, "total 10.00 USD")
+	assert.Contains(t, first.EmbeddingText, "across 2 keys")
+	assert.Contains(t, first.EmbeddingText, "Top contributors: AWS Lambda 8.00 (80%).")
+}
+
+func TestDocumentTemplates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "document.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("{{.Month}}: {{.Rows}} keys"), 0600))
+
+	r := documentResults()
+	r.Documents = types.DocumentConfig{Strategy: SummaryDocuments, Template: "@" + path}
+	items, err := NewDocumentBuilder().ConvertToVectorStoreItem(r)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01: 2 keys", items[0].EmbeddingText)
+
+	assert.ErrorContains(t, ValidateDocuments(types.DocumentConfig{Strategy: "weekly"}),
+		"unknown document strategy")
+	assert.ErrorContains(t, ValidateDocuments(types.DocumentConfig{Template: "{{.Cost"}),
+		"invalid document template")
+	assert.ErrorContains(t, ValidateDocuments(types.DocumentConfig{Template: "@" + path + ".missing"}),
+		"failed to read document template")
+
+	r.Documents = types.DocumentConfig{Template: "{{.Account}}"}
+	_, err = NewDocumentBuilder().ConvertToVectorStoreItem(r)
+	assert.Error(t, err, "unknown fields are rejected")
+}
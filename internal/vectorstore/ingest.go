@@ -0,0 +1,363 @@
+package vectorstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	DefaultMaxBatchTokens    = 20000
+	DefaultEmbedConcurrency  = 4
+	DefaultUpsertConcurrency = 4
+	DefaultUpsertBatchSize   = 100
+
+	// maxBatchInputs is the most inputs the OpenAI embeddings API accepts in
+	// one request
+	maxBatchInputs = 2048
+)
+
+// ProgressFunc is called after every upserted batch with the number of
+// documents written so far, including documents skipped on resume
+type ProgressFunc func(done, total int)
+
+// Ingester embeds documents in token-bounded batches and upserts them
+// concurrently. Documents that were upserted are recorded in a checkpoint,
+// so running the same load again after a failure only writes the rest.
+type Ingester struct {
+	Embedder ports.Embedder
+	Store    ports.VectorStore
+	Config   types.IngestConfig
+	Progress ProgressFunc
+}
+
+func NewIngester(embedder ports.Embedder, store ports.VectorStore,
+	cfg types.IngestConfig) *Ingester {
+
+	if cfg.MaxBatchTokens <= 0 {
+		cfg.MaxBatchTokens = DefaultMaxBatchTokens
+	}
+	if cfg.EmbedConcurrency <= 0 {
+		cfg.EmbedConcurrency = DefaultEmbedConcurrency
+	}
+	if cfg.UpsertConcurrency <= 0 {
+		cfg.UpsertConcurrency = DefaultUpsertConcurrency
+	}
+	if cfg.UpsertBatchSize <= 0 {
+		cfg.UpsertBatchSize = DefaultUpsertBatchSize
+	}
+	return &Ingester{
+		Embedder: embedder,
+		Store:    store,
+		Config:   cfg,
+		Progress: func(done, total int) {},
+	}
+}
+
+// Ingest embeds items, which must have their IDs set, and writes them to
+// the store. checkpoint names the load; an empty name disables resuming.
+// The checkpoint is removed once every item is written.
+func (in *Ingester) Ingest(ctx context.Context, checkpoint string,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
+
+	cp, err := openCheckpoint(in.Config.CheckpointDir, checkpoint)
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+	defer cp.Close()
+
+	pending := make([]*types.VectorStoreItem, 0, len(items))
+	for _, item := range items {
+		if !cp.done[item.ID] {
+			pending = append(pending, item)
+		}
+	}
+	progress := newProgress(in.Progress, len(items), len(items)-len(pending))
+	if len(pending) == 0 {
+		return types.UpsertResponse{}, cp.Remove()
+	}
+
+	batches := batchByTokens(pending, in.Config.MaxBatchTokens, maxBatchInputs)
+
+	// the first batch is embedded on its own, so the store can be checked
+	// for vectors of another model before anything is written
+	if err := in.embed(ctx, batches[0]); err != nil {
+		return types.UpsertResponse{}, err
+	}
+	err = CheckModel(ctx, in.Store, batches[0][0].EmbeddingVector, in.Embedder.Model())
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+
+	var resp types.UpsertResponse
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	embedded := make(chan []*types.VectorStoreItem)
+
+	g.Go(func() error {
+		defer close(embedded)
+		embedders, ectx := errgroup.WithContext(gctx)
+		embedders.SetLimit(in.Config.EmbedConcurrency)
+		for i, batch := range batches {
+			embedders.Go(func() error {
+				if i > 0 {
+					if err := in.embed(ectx, batch); err != nil {
+						return err
+					}
+				}
+				for _, chunk := range chunk(batch, in.Config.UpsertBatchSize) {
+					select {
+					case embedded <- chunk:
+					case <-ectx.Done():
+						return ectx.Err()
+					}
+				}
+				return nil
+			})
+		}
+		return embedders.Wait()
+	})
+
+	for range in.Config.UpsertConcurrency {
+		g.Go(func() error {
+			for chunk := range embedded {
+				if err := gctx.Err(); err != nil {
+					return err
+				}
+				res, err := in.Store.Upsert(gctx, chunk)
+				if err != nil {
+					return fmt.Errorf("failed to upsert %d documents: %w", len(chunk), err)
+				}
+
+				mu.Lock()
+				resp.UpsertedCount += res.UpsertedCount
+				err = cp.Record(chunk)
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+				progress.add(len(chunk))
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		if cp.path != "" {
+			err = ResumableError{Err: err, Checkpoint: cp.path, Done: progress.current()}
+		}
+		return resp, err
+	}
+	return resp, cp.Remove()
+}
+
+func (in *Ingester) embed(ctx context.Context, batch []*types.VectorStoreItem) error {
+	texts := make([]string, len(batch))
+	for i, item := range batch {
+		texts[i] = item.EmbeddingText
+	}
+
+	vectors, err := in.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed %d documents: %w", len(batch), err)
+	}
+	for i, vector := range vectors {
+		batch[i].EmbeddingVector = vector
+		batch[i].Metadata.EmbeddingModel = in.Embedder.Model()
+	}
+	return nil
+}
+
+// ResumableError reports a failed load whose progress was saved to a
+// checkpoint
+type ResumableError struct {
+	Err        error
+	Checkpoint string
+	Done       int
+}
+
+func (e ResumableError) Error() string {
+	return fmt.Sprintf("%v (%d documents written; run the same command again to resume from %s)",
+		e.Err, e.Done, e.Checkpoint)
+}
+
+func (e ResumableError) Unwrap() error {
+	return e.Err
+}
+
+// CheckpointName identifies a load by the store it writes to and the IDs of
+// its documents, so only a rerun of the same load resumes from it
+func CheckpointName(cfg types.VectorStoreConfig, items []*types.VectorStoreItem) string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	sort.Strings(ids)
+
+	h := sha256.New()
+	for _, s := range []string{cfg.Backend, cfg.Namespace, cfg.LocalPath, cfg.PineconeIndex,
+		cfg.QdrantURL, cfg.QdrantCollection, cfg.PGVectorDSN, cfg.PGVectorTable} {
+		h.Write([]byte(s + "\x00"))
+	}
+	h.Write([]byte(strings.Join(ids, "\x00")))
+	return strings.ToLower(cfg.Backend) + "-" + hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// estimateTokens approximates the tokens of text conservatively. Cost
+// documents are dense with numbers and identifiers, which tokenize to about
+// three characters per token.
+func estimateTokens(text string) int {
+	return len(text)/3 + 1
+}
+
+// batchByTokens groups items in order into batches of at most maxTokens
+// estimated tokens and maxItems items. An item larger than maxTokens forms a
+// batch of its own.
+func batchByTokens(items []*types.VectorStoreItem, maxTokens,
+	maxItems int) [][]*types.VectorStoreItem {
+
+	var batches [][]*types.VectorStoreItem
+	var batch []*types.VectorStoreItem
+	tokens := 0
+	for _, item := range items {
+		n := estimateTokens(item.EmbeddingText)
+		if len(batch) > 0 && (tokens+n > maxTokens || len(batch) == maxItems) {
+			batches = append(batches, batch)
+			batch, tokens = nil, 0
+		}
+		batch = append(batch, item)
+		tokens += n
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func chunk[T any](s []T, size int) [][]T {
+	var chunks [][]T
+	for size < len(s) {
+		chunks = append(chunks, s[:size:size])
+		s = s[size:]
+	}
+	return append(chunks, s)
+}
+
+// checkpoint appends the IDs of upserted documents to a file, one per line
+type checkpoint struct {
+	path string
+	file *os.File
+	done map[string]bool
+}
+
+func openCheckpoint(dir, name string) (*checkpoint, error) {
+	cp := &checkpoint{done: map[string]bool{}}
+	if dir == "" || name == "" {
+		return cp, nil
+	}
+	cp.path = filepath.Join(dir, name+".checkpoint")
+
+	f, err := os.Open(cp.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	default:
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			cp.done[scanner.Text()] = true
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read checkpoint %s: %w", cp.path, err)
+		}
+	}
+	return cp, nil
+}
+
+// Record appends the IDs of items, creating the checkpoint on first use
+func (c *checkpoint) Record(items []*types.VectorStoreItem) error {
+	if c.path == "" {
+		return nil
+	}
+	if c.file == nil {
+		if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+			return fmt.Errorf("failed to create checkpoint directory: %w", err)
+		}
+		f, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write checkpoint: %w", err)
+		}
+		c.file = f
+	}
+
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(item.ID + "\n")
+	}
+	if _, err := c.file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (c *checkpoint) Close() {
+	if c.file != nil {
+		c.file.Close()
+		c.file = nil
+	}
+}
+
+// Remove deletes the checkpoint of a completed load
+func (c *checkpoint) Remove() error {
+	c.Close()
+	if c.path == "" {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// progress counts written documents across upsert workers
+type progress struct {
+	mu     sync.Mutex
+	report ProgressFunc
+	total  int
+	done   int
+}
+
+func newProgress(report ProgressFunc, total, done int) *progress {
+	if report == nil {
+		report = func(done, total int) {}
+	}
+	p := &progress{report: report, total: total, done: done}
+	report(done, total)
+	return p
+}
+
+func (p *progress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done += n
+	p.report(p.done, p.total)
+}
+
+func (p *progress) current() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done
+}
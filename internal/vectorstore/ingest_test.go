@@ -0,0 +1,158 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingEmbedder embeds every text as the same unit vector and records the
+// batch sizes it was called with
+type countingEmbedder struct {
+	mu      sync.Mutex
+	batches []int
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, len(texts))
+	c.mu.Unlock()
+
+	vectors := make([][]float32, len(texts))
+	for i := range texts {
+		vectors[i] = []float32{1, 0, 0}
+	}
+	return vectors, nil
+}
+
+func (c *countingEmbedder) Model() string {
+	return "counting"
+}
+
+// failingStore fails every upsert after the first failAfter documents
+type failingStore struct {
+	*LocalStore
+	mu        sync.Mutex
+	failAfter int
+	upserted  int
+}
+
+func (f *failingStore) Upsert(ctx context.Context,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.upserted+len(items) > f.failAfter {
+		return types.UpsertResponse{}, fmt.Errorf("service unavailable")
+	}
+	f.upserted += len(items)
+	return f.LocalStore.Upsert(ctx, items)
+}
+
+func ingestItems(n int) []*types.VectorStoreItem {
+	items := make([]*types.VectorStoreItem, n)
+	for i := range items {
+		items[i] = &types.VectorStoreItem{
+			ID:            fmt.Sprintf("doc-%03d", i),
+			EmbeddingText: strings.Repeat("x", 299),
+			Metadata:      types.VectorStoreItemMetadata{StartDate: "2024-01-01"},
+		}
+	}
+	return items
+}
+
+func TestBatchByTokens(t *testing.T) {
+	items := ingestItems(5)
+	items[2].EmbeddingText = strings.Repeat("x", 3000)
+
+	batches := batchByTokens(items, 250, 10)
+	sizes := make([]int, len(batches))
+	for i, b := range batches {
+		sizes[i] = len(b)
+	}
+	assert.Equal(t, []int{2, 1, 2}, sizes, "an oversized item is embedded on its own")
+
+	assert.Len(t, batchByTokens(ingestItems(5), 1000, 2), 3)
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, chunk([]int{1, 2, 3, 4, 5}, 2))
+}
+
+func TestIngest(t *testing.T) {
+	ctx := context.Background()
+	embedder := &countingEmbedder{}
+	store := NewLocalStore(filepath.Join(t.TempDir(), "vectors.json"), "")
+	var reported []int
+
+	ingester := NewIngester(embedder, store, types.IngestConfig{
+		MaxBatchTokens:  1000,
+		UpsertBatchSize: 3,
+		CheckpointDir:   t.TempDir(),
+	})
+	ingester.Progress = func(done, total int) {
+		assert.Equal(t, 25, total)
+		reported = append(reported, done)
+	}
+
+	resp, err := ingester.Ingest(ctx, "load", ingestItems(25))
+	require.NoError(t, err)
+	assert.Equal(t, 25, resp.UpsertedCount)
+	assert.Len(t, embedder.batches, 3, "100 estimated tokens per document, 10 per request")
+	assert.Equal(t, 25, reported[len(reported)-1])
+
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 25, stats.Count)
+	entries, err := os.ReadDir(ingester.Config.CheckpointDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "the checkpoint of a completed load is removed")
+}
+
+func TestIngestResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	local := NewLocalStore(filepath.Join(dir, "vectors.json"), "")
+	cfg := types.IngestConfig{
+		MaxBatchTokens:    1000,
+		UpsertBatchSize:   5,
+		UpsertConcurrency: 1,
+		CheckpointDir:     filepath.Join(dir, "checkpoints"),
+	}
+
+	failing := &failingStore{LocalStore: local, failAfter: 10}
+	_, err := NewIngester(&countingEmbedder{}, failing, cfg).Ingest(ctx, "load", ingestItems(25))
+	var resumable ResumableError
+	require.ErrorAs(t, err, &resumable)
+	assert.Equal(t, 10, resumable.Done)
+	assert.ErrorContains(t, err, "service unavailable")
+	assert.FileExists(t, resumable.Checkpoint)
+
+	embedder := &countingEmbedder{}
+	resp, err := NewIngester(embedder, local, cfg).Ingest(ctx, "load", ingestItems(25))
+	require.NoError(t, err)
+	assert.Equal(t, 15, resp.UpsertedCount, "documents recorded in the checkpoint are skipped")
+
+	stats, err := local.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 25, stats.Count)
+	assert.NoFileExists(t, resumable.Checkpoint)
+}
+
+func TestCheckpointName(t *testing.T) {
+	cfg := types.VectorStoreConfig{Backend: Local, LocalPath: "v.json"}
+	items := ingestItems(3)
+	reversed := []*types.VectorStoreItem{items[2], items[1], items[0]}
+
+	assert.Equal(t, CheckpointName(cfg, items), CheckpointName(cfg, reversed))
+	assert.NotEqual(t, CheckpointName(cfg, items), CheckpointName(cfg, items[:2]))
+	cfg.Namespace = "DAILY"
+	assert.NotEqual(t, CheckpointName(cfg, items), CheckpointName(types.VectorStoreConfig{
+		Backend: Local, LocalPath: "v.json"}, items))
+	assert.True(t, strings.HasPrefix(CheckpointName(cfg, items), "local-"))
+}
@@ -0,0 +1,235 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// LocalStore keeps documents in a single JSON file and answers queries with a
+// brute-force cosine similarity scan. It needs no network access, which
+// makes it suitable for offline use and tests. Documents of every namespace
+// share the file; each store instance only sees its own namespace.
+type LocalStore struct {
+	path      string
+	namespace string
+	mu        sync.Mutex
+}
+
+type localFile struct {
+	Dimension int             `json:"dimension"`
+	Documents []localDocument `json:"documents"`
+}
+
+type localDocument struct {
+	ID          string                        `json:"id"`
+	Namespace   string                        `json:"namespace,omitempty"`
+	Vector      []float32                     `json:"vector"`
+	PageContent string                        `json:"page_content"`
+	Metadata    types.VectorStoreItemMetadata `json:"metadata"`
+}
+
+func NewLocalStore(path, namespace string) *LocalStore {
+	return &LocalStore{path: path, namespace: namespace}
+}
+
+// Upsert inserts items, replacing documents with the same ID in the store's
+// namespace
+func (l *LocalStore) Upsert(ctx context.Context,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.load()
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+
+	index := make(map[string]int, len(f.Documents))
+	for i, d := range f.Documents {
+		if d.Namespace == l.namespace {
+			index[d.ID] = i
+		}
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return types.UpsertResponse{}, err
+		}
+		if f.Dimension == 0 {
+			f.Dimension = len(item.EmbeddingVector)
+		}
+		if len(item.EmbeddingVector) != f.Dimension {
+			return types.UpsertResponse{}, fmt.Errorf(
+				"vector dimension %d does not match the store dimension %d",
+				len(item.EmbeddingVector), f.Dimension)
+		}
+
+		doc := localDocument{
+			ID:          item.ID,
+			Namespace:   l.namespace,
+			Vector:      item.EmbeddingVector,
+			PageContent: item.EmbeddingText,
+			Metadata:    item.Metadata,
+		}
+		if i, ok := index[item.ID]; ok {
+			f.Documents[i] = doc
+		} else {
+			index[item.ID] = len(f.Documents)
+			f.Documents = append(f.Documents, doc)
+		}
+	}
+
+	if err := l.save(f); err != nil {
+		return types.UpsertResponse{}, err
+	}
+	return types.UpsertResponse{UpsertedCount: len(items)}, nil
+}
+
+// Query scores every stored document against vector and returns the topK
+// most similar ones that match filter
+func (l *LocalStore) Query(ctx context.Context, vector []float32, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+
+	l.mu.Lock()
+	f, err := l.load()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if f.Dimension != 0 && len(vector) != f.Dimension {
+		return nil, fmt.Errorf("query dimension %d does not match the store dimension %d",
+			len(vector), f.Dimension)
+	}
+
+	var matches []types.VectorQueryMatch
+	for _, d := range f.Documents {
+		if d.Namespace != l.namespace || !matchesFilter(d.Metadata, filter) {
+			continue
+		}
+		matches = append(matches, types.VectorQueryMatch{
+			ID:          d.ID,
+			Score:       cosineSimilarity(vector, d.Vector),
+			PageContent: d.PageContent,
+			Metadata:    d.Metadata,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if topK > 0 && len(matches) > topK {
+		matches = matches[:topK]
+	}
+	return matches, nil
+}
+
+// Delete removes the documents selected by req from the store's namespace
+func (l *LocalStore) Delete(ctx context.Context, req types.VectorDeleteRequest) error {
+	if req.IsEmpty() {
+		return fmt.Errorf("delete request selects no vectors")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.load()
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		ids[id] = true
+	}
+
+	kept := f.Documents[:0]
+	for _, d := range f.Documents {
+		selected := d.Namespace == l.namespace && (req.All || ids[d.ID] ||
+			(len(req.IDs) == 0 && matchesFilter(d.Metadata, req.Filter)))
+		if !selected {
+			kept = append(kept, d)
+		}
+	}
+	f.Documents = kept
+	if len(f.Documents) == 0 {
+		f.Dimension = 0
+	}
+	return l.save(f)
+}
+
+// Stats returns the number of documents in the store's namespace, the
+// count of every namespace and the vector dimension
+func (l *LocalStore) Stats(ctx context.Context) (types.VectorStoreStats, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := l.load()
+	if err != nil {
+		return types.VectorStoreStats{}, err
+	}
+
+	namespaces := make(map[string]int)
+	for _, d := range f.Documents {
+		namespaces[d.Namespace]++
+	}
+	return types.VectorStoreStats{
+		Backend:    Local,
+		Namespace:  l.namespace,
+		Count:      namespaces[l.namespace],
+		Dimension:  f.Dimension,
+		Namespaces: namespaces,
+	}, nil
+}
+
+func (l *LocalStore) load() (localFile, error) {
+	var f localFile
+	b, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return f, fmt.Errorf("failed to read local vector store: %w", err)
+	}
+	if err := json.Unmarshal(b, &f); err != nil {
+		return f, fmt.Errorf("failed to decode local vector store %s: %w", l.path, err)
+	}
+	return f, nil
+}
+
+// save writes f to a temporary file and renames it over the store, so a
+// failed write never leaves a truncated store behind
+func (l *LocalStore) save(f localFile) error {
+	b, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(l.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create local vector store directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write local vector store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write local vector store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write local vector store: %w", err)
+	}
+	return os.Rename(tmp.Name(), l.path)
+}
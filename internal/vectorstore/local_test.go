@@ -0,0 +1,136 @@
+package vectorstore
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testItems() []*types.VectorStoreItem {
+	item := func(id string, vector []float32, start, dimensions string) *types.VectorStoreItem {
+		return &types.VectorStoreItem{
+			ID:              id,
+			EmbeddingText:   "document " + id,
+			EmbeddingVector: vector,
+			Metadata: types.VectorStoreItemMetadata{
+				StartDate:  start,
+				EndDate:    start,
+				Dimensions: dimensions,
+				Cost:       "1.00",
+			},
+		}
+	}
+	return []*types.VectorStoreItem{
+		item("ec2-jan", []float32{1, 0, 0}, "2024-01-01", "SERVICE"),
+		item("ec2-feb", []float32{0.9, 0.1, 0}, "2024-02-01", "SERVICE"),
+		item("s3-jan", []float32{0, 1, 0}, "2024-01-01", "SERVICE,USAGE_TYPE"),
+	}
+}
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(filepath.Join(t.TempDir(), "nested", "vectors.json"), "")
+
+	resp, err := store.Upsert(ctx, testItems())
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.UpsertedCount)
+
+	t.Run("ranks by cosine similarity", func(t *testing.T) {
+		matches, err := store.Query(ctx, []float32{1, 0, 0}, 2, types.VectorQueryFilter{})
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, "ec2-jan", matches[0].ID)
+		assert.InDelta(t, 1.0, matches[0].Score, 1e-6)
+		assert.Equal(t, "ec2-feb", matches[1].ID)
+		assert.Equal(t, "document ec2-jan", matches[0].PageContent)
+	})
+
+	t.Run("applies the filter", func(t *testing.T) {
+		matches, err := store.Query(ctx, []float32{1, 0, 0}, 10, types.VectorQueryFilter{
+			Start:      "2024-01-01",
+			End:        "2024-02-01",
+			Dimensions: []string{"SERVICE"},
+		})
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "ec2-jan", matches[0].ID)
+	})
+
+	t.Run("upsert replaces documents with the same ID", func(t *testing.T) {
+		items := testItems()[:1]
+		items[0].EmbeddingText = "updated"
+		_, err := store.Upsert(ctx, items)
+		require.NoError(t, err)
+
+		stats, err := store.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, types.VectorStoreStats{Backend: Local, Count: 3, Dimension: 3,
+			Namespaces: map[string]int{"": 3}}, stats)
+	})
+
+	t.Run("rejects vectors of another dimension", func(t *testing.T) {
+		_, err := store.Upsert(ctx, []*types.VectorStoreItem{{ID: "x", EmbeddingVector: []float32{1}}})
+		assert.Error(t, err)
+		_, err = store.Query(ctx, []float32{1}, 1, types.VectorQueryFilter{})
+		assert.Error(t, err)
+	})
+
+	t.Run("deletes by filter, ID and all", func(t *testing.T) {
+		assert.Error(t, store.Delete(ctx, types.VectorDeleteRequest{}))
+
+		require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{
+			Filter: types.VectorQueryFilter{Dimensions: []string{"SERVICE,USAGE_TYPE"}},
+		}))
+		require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{IDs: []string{"ec2-feb"}}))
+
+		stats, err := store.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stats.Count)
+
+		require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{All: true}))
+		stats, err = store.Stats(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, types.VectorStoreStats{Backend: Local, Namespaces: map[string]int{}}, stats)
+	})
+}
+
+func TestLocalStoreNamespaces(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "vectors.json")
+	daily, monthly := NewLocalStore(path, "DAILY"), NewLocalStore(path, "MONTHLY")
+
+	_, err := daily.Upsert(ctx, testItems())
+	require.NoError(t, err)
+	_, err = monthly.Upsert(ctx, testItems()[:1])
+	require.NoError(t, err)
+
+	stats, err := monthly.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.VectorStoreStats{Backend: Local, Namespace: "MONTHLY", Count: 1, Dimension: 3,
+		Namespaces: map[string]int{"DAILY": 3, "MONTHLY": 1}}, stats)
+
+	matches, err := monthly.Query(ctx, []float32{0, 1, 0}, 10, types.VectorQueryFilter{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1, "queries only see their own namespace")
+	assert.Equal(t, "ec2-jan", matches[0].ID)
+
+	require.NoError(t, monthly.Delete(ctx, types.VectorDeleteRequest{All: true}))
+	stats, err = daily.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Count, "deleting a namespace keeps the others")
+	assert.Equal(t, map[string]int{"DAILY": 3}, stats.Namespaces)
+}
+
+func TestMatchesFilter(t *testing.T) {
+	m := types.VectorStoreItemMetadata{StartDate: "2024-01-01", Dimensions: "SERVICE", Granularity: "DAILY",
+		CostAmount: 12.5}
+	assert.True(t, matchesFilter(m, types.VectorQueryFilter{Granularity: "DAILY"}))
+	assert.True(t, matchesFilter(m, types.VectorQueryFilter{MinCost: 12.5}))
+	assert.False(t, matchesFilter(m, types.VectorQueryFilter{MinCost: 13}))
+	assert.False(t, matchesFilter(m, types.VectorQueryFilter{Granularity: "MONTHLY"}))
+	assert.False(t, matchesFilter(m, types.VectorQueryFilter{Start: "2024-01-02"}))
+}
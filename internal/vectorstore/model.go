@@ -0,0 +1,56 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cduggn/ccexplorer/internal/embedding"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// ModelMismatchError is returned when vectors from one embedding model would
+// be stored alongside, or compared with, vectors from another model
+type ModelMismatchError struct {
+	Stored    string
+	Requested string
+}
+
+func (e ModelMismatchError) Error() string {
+	return fmt.Sprintf("vector store holds %s embeddings and cannot be used with %s; "+
+		"select the same embedding model or delete the stored vectors first",
+		e.Stored, e.Requested)
+}
+
+// CheckModel verifies that store is empty or holds vectors produced by model.
+// vector is any embedding from model and is used to sample the store.
+func CheckModel(ctx context.Context, store ports.VectorStore, vector []float32,
+	model string) error {
+
+	stats, err := store.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	if stats.Count == 0 {
+		return nil
+	}
+	if stats.Dimension != 0 && stats.Dimension != len(vector) {
+		return fmt.Errorf("vector store holds %d-dimensional embeddings and cannot be used with %s, which produces %d dimensions",
+			stats.Dimension, model, len(vector))
+	}
+
+	matches, err := store.Query(ctx, vector, 1, types.VectorQueryFilter{})
+	if err != nil {
+		return err
+	}
+	return checkMatches(matches, model)
+}
+
+func checkMatches(matches []types.VectorQueryMatch, model string) error {
+	for _, m := range matches {
+		if stored := embedding.ModelOf(m.Metadata); stored != model {
+			return ModelMismatchError{Stored: stored, Requested: model}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// PGVectorStore stores documents in a PostgreSQL table using the pgvector
+// extension and ranks them by cosine distance. The table is created on the
+// first upsert. Namespaces share the table and are told apart by the
+// namespace column.
+type PGVectorStore struct {
+	db        *sql.DB
+	table     string
+	namespace string
+
+	mu      sync.Mutex
+	created bool
+}
+
+func NewPGVectorStore(dsn, table, namespace string) (*PGVectorStore, error) {
+	if !validIdentifier(table) {
+		return nil, fmt.Errorf("invalid pgvector table name %q", table)
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pgvector database: %w", err)
+	}
+	return &PGVectorStore{db: db, table: table, namespace: namespace}, nil
+}
+
+// Upsert inserts items, replacing rows with the same ID in the store's
+// namespace
+func (p *PGVectorStore) Upsert(ctx context.Context,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
+
+	if len(items) == 0 {
+		return types.UpsertResponse{}, nil
+	}
+	if err := p.ensureTable(ctx, len(items[0].EmbeddingVector)); err != nil {
+		return types.UpsertResponse{}, err
+	}
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s
+		(namespace, id, embedding, page_content, start_date, end_date, granularity, dimensions, tags, keys,
+			attributes, metrics, document, cost, cost_amount, embedding_model)
+		VALUES ($1, $2, $3::vector, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		ON CONFLICT (namespace, id) DO UPDATE SET
+			embedding = EXCLUDED.embedding,
+			page_content = EXCLUDED.page_content,
+			start_date = EXCLUDED.start_date,
+			end_date = EXCLUDED.end_date,
+			granularity = EXCLUDED.granularity,
+			dimensions = EXCLUDED.dimensions,
+			tags = EXCLUDED.tags,
+			keys = EXCLUDED.keys,
+			attributes = EXCLUDED.attributes,
+			metrics = EXCLUDED.metrics,
+			document = EXCLUDED.document,
+			cost = EXCLUDED.cost,
+			cost_amount = EXCLUDED.cost_amount,
+			embedding_model = EXCLUDED.embedding_model`, p.table))
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+	defer stmt.Close()
+
+	for _, item := range items {
+		m := item.Metadata
+		_, err := stmt.ExecContext(ctx, p.namespace, item.ID, vectorLiteral(item.EmbeddingVector),
+			item.EmbeddingText, m.StartDate, m.EndDate, m.Granularity, m.Dimensions, m.Tags, m.Keys,
+			strings.Join(m.Attributes, "\n"), m.Metrics, m.Document, m.Cost, m.CostAmount, m.EmbeddingModel)
+		if err != nil {
+			return types.UpsertResponse{}, fmt.Errorf("failed to upsert %s: %w", item.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return types.UpsertResponse{}, err
+	}
+	return types.UpsertResponse{UpsertedCount: len(items)}, nil
+}
+
+// Query orders rows by cosine distance to vector. The score is the cosine
+// similarity, matching the other backends.
+func (p *PGVectorStore) Query(ctx context.Context, vector []float32, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+
+	where, args := pgvectorWhere(p.namespace, filter, 2)
+	query := fmt.Sprintf(`SELECT id, 1 - (embedding <=> $1::vector), page_content,
+		start_date, end_date, granularity, dimensions, tags, keys, attributes,
+		metrics, document, cost, cost_amount, embedding_model
+		FROM %s%s ORDER BY embedding <=> $1::vector LIMIT %d`, p.table, where, topK)
+
+	rows, err := p.db.QueryContext(ctx, query, append([]any{vectorLiteral(vector)}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []types.VectorQueryMatch
+	for rows.Next() {
+		var m types.VectorQueryMatch
+		var score float64
+		var attributes string
+		err := rows.Scan(&m.ID, &score, &m.PageContent, &m.Metadata.StartDate,
+			&m.Metadata.EndDate, &m.Metadata.Granularity, &m.Metadata.Dimensions,
+			&m.Metadata.Tags, &m.Metadata.Keys, &attributes, &m.Metadata.Metrics,
+			&m.Metadata.Document, &m.Metadata.Cost, &m.Metadata.CostAmount, &m.Metadata.EmbeddingModel)
+		if err != nil {
+			return nil, err
+		}
+		if attributes != "" {
+			m.Metadata.Attributes = strings.Split(attributes, "\n")
+		}
+		m.Score = float32(score)
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// Delete removes the rows of the store's namespace selected by req
+func (p *PGVectorStore) Delete(ctx context.Context, req types.VectorDeleteRequest) error {
+	if req.IsEmpty() {
+		return fmt.Errorf("delete request selects no vectors")
+	}
+
+	filter := req.Filter
+	if req.All || len(req.IDs) > 0 {
+		filter = types.VectorQueryFilter{}
+	}
+	where, args := pgvectorWhere(p.namespace, filter, 1)
+	if !req.All && len(req.IDs) > 0 {
+		where += fmt.Sprintf(" AND id = ANY($%d)", len(args)+1)
+		args = append(args, req.IDs)
+	}
+
+	_, err := p.db.ExecContext(ctx, "DELETE FROM "+p.table+where, args...)
+	return err
+}
+
+// Stats returns the row count of the store's namespace, the count of every
+// namespace and the embedding dimension of the table. A table that has not
+// been created yet is reported as empty.
+func (p *PGVectorStore) Stats(ctx context.Context) (types.VectorStoreStats, error) {
+	stats := types.VectorStoreStats{Backend: PGVector, Namespace: p.namespace}
+	exists, err := p.tableExists(ctx)
+	if err != nil || !exists {
+		return stats, err
+	}
+
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT namespace, count(*), max(vector_dims(embedding)) FROM %s GROUP BY namespace", p.table))
+	if err != nil {
+		return types.VectorStoreStats{}, err
+	}
+	defer rows.Close()
+
+	stats.Namespaces = make(map[string]int)
+	for rows.Next() {
+		var namespace string
+		var count, dimension int
+		if err := rows.Scan(&namespace, &count, &dimension); err != nil {
+			return types.VectorStoreStats{}, err
+		}
+		stats.Namespaces[namespace] = count
+		stats.Dimension = dimension
+	}
+	stats.Count = stats.Namespaces[p.namespace]
+	return stats, rows.Err()
+}
+
+func (p *PGVectorStore) tableExists(ctx context.Context) (bool, error) {
+	var table sql.NullString
+	err := p.db.QueryRowContext(ctx, "SELECT to_regclass($1)::text", p.table).Scan(&table)
+	return table.Valid, err
+}
+
+// ensureTable creates the extension and the table once per store
+func (p *PGVectorStore) ensureTable(ctx context.Context, dimension int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.created {
+		return nil
+	}
+
+	statements := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			namespace text NOT NULL DEFAULT '',
+			id text NOT NULL,
+			embedding vector(%d) NOT NULL,
+			page_content text NOT NULL,
+			start_date text NOT NULL,
+			end_date text NOT NULL,
+			granularity text NOT NULL DEFAULT '',
+			dimensions text NOT NULL DEFAULT '',
+			tags text NOT NULL DEFAULT '',
+			keys text NOT NULL DEFAULT '',
+			attributes text NOT NULL DEFAULT '',
+			metrics text NOT NULL DEFAULT '',
+			document text NOT NULL DEFAULT '',
+			cost text NOT NULL DEFAULT '',
+			cost_amount double precision NOT NULL DEFAULT 0,
+			embedding_model text NOT NULL DEFAULT '',
+			PRIMARY KEY (namespace, id))`, p.table, dimension),
+	}
+	for _, s := range statements {
+		if _, err := p.db.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("failed to prepare pgvector table: %w", err)
+		}
+	}
+	p.created = true
+	return nil
+}
+
+// pgvectorWhere renders filter as a WHERE clause restricted to namespace
+// whose placeholders start at $first
+func pgvectorWhere(namespace string, filter types.VectorQueryFilter, first int) (string, []any) {
+	var conditions []string
+	var args []any
+	add := func(condition string, arg any) {
+		conditions = append(conditions,
+			strings.Replace(condition, "?", "$"+strconv.Itoa(first+len(args)), 1))
+		args = append(args, arg)
+	}
+
+	add("namespace = ?", namespace)
+
+	if filter.Start != "" {
+		add("start_date >= ?", filter.Start)
+	}
+	if filter.End != "" {
+		add("start_date < ?", filter.End)
+	}
+	if len(filter.Dimensions) > 0 {
+		add("dimensions = ?", strings.Join(filter.Dimensions, ","))
+	}
+	if filter.Granularity != "" {
+		add("granularity = ?", filter.Granularity)
+	}
+	if filter.MinCost != 0 {
+		add("cost_amount >= ?", filter.MinCost)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// vectorLiteral formats v in the pgvector text representation
+func vectorLiteral(v []float32) string {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(float64(f), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func validIdentifier(name string) bool {
+	return identifierPattern.MatchString(name)
+}
@@ -0,0 +1,160 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// arrayConverter passes string slices through, as pgx binds them to
+// PostgreSQL arrays
+type arrayConverter struct{}
+
+func (arrayConverter) ConvertValue(v any) (driver.Value, error) {
+	if ids, ok := v.([]string); ok {
+		return ids, nil
+	}
+	return driver.DefaultParameterConverter.ConvertValue(v)
+}
+
+func newMockPGVectorStore(t *testing.T) (*PGVectorStore, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.ValueConverterOption(arrayConverter{}))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, mock.ExpectationsWereMet())
+		db.Close()
+	})
+	return &PGVectorStore{db: db, table: "costs", namespace: "acct-1"}, mock
+}
+
+// sqlPattern matches SQL holding parts in order
+func sqlPattern(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return strings.Join(parts, `[\s\S]*`)
+}
+
+func TestPGVectorUpsert(t *testing.T) {
+	store, mock := newMockPGVectorStore(t)
+	item := &types.VectorStoreItem{
+		ID:              "doc-1",
+		EmbeddingText:   "Amazon S3 cost 12.50 USD",
+		EmbeddingVector: []float32{0.5, -1, 0.25},
+		Metadata: types.VectorStoreItemMetadata{
+			StartDate:      "2024-01-01",
+			EndDate:        "2024-02-01",
+			Granularity:    "MONTHLY",
+			Dimensions:     "SERVICE",
+			Keys:           "Amazon S3",
+			Attributes:     []string{"SERVICE=Amazon S3", "REGION=us-east-1"},
+			Metrics:        "UnblendedCost",
+			Document:       "row",
+			Cost:           "12.50",
+			CostAmount:     12.5,
+			EmbeddingModel: "text-embedding-3-small",
+		},
+	}
+
+	mock.ExpectExec(sqlPattern("CREATE EXTENSION IF NOT EXISTS vector")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(sqlPattern("CREATE TABLE IF NOT EXISTS costs", "embedding vector(3) NOT NULL",
+		"PRIMARY KEY (namespace, id)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for range 2 {
+		mock.ExpectBegin()
+		mock.ExpectPrepare(sqlPattern("INSERT INTO costs", "ON CONFLICT (namespace, id) DO UPDATE SET",
+			"embedding = EXCLUDED.embedding")).
+			ExpectExec().
+			WithArgs("acct-1", "doc-1", "[0.5,-1,0.25]", "Amazon S3 cost 12.50 USD", "2024-01-01",
+				"2024-02-01", "MONTHLY", "SERVICE", "", "Amazon S3", "SERVICE=Amazon S3\nREGION=us-east-1",
+				"UnblendedCost", "row", "12.50", 12.5, "text-embedding-3-small").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	for range 2 {
+		resp, err := store.Upsert(context.Background(), []*types.VectorStoreItem{item})
+		require.NoError(t, err)
+		assert.Equal(t, 1, resp.UpsertedCount)
+	}
+}
+
+func TestPGVectorQuery(t *testing.T) {
+	store, mock := newMockPGVectorStore(t)
+
+	mock.ExpectQuery(sqlPattern("SELECT id, 1 - (embedding <=> $1::vector), page_content",
+		"FROM costs WHERE namespace = $2 AND start_date >= $3 AND granularity = $4",
+		"ORDER BY embedding <=> $1::vector LIMIT 5")).
+		WithArgs("[1,0]", "acct-1", "2024-01-01", "DAILY").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "score", "page_content", "start_date", "end_date",
+			"granularity", "dimensions", "tags", "keys", "attributes", "metrics", "document", "cost",
+			"cost_amount", "embedding_model"}).
+			AddRow("doc-1", 0.9, "Amazon S3", "2024-01-01", "2024-01-02", "DAILY", "SERVICE", "",
+				"Amazon S3", "SERVICE=Amazon S3\nREGION=us-east-1", "UnblendedCost", "row", "3.00", 3.0, "m").
+			AddRow("doc-2", 0.5, "Amazon EC2", "2024-01-01", "2024-01-02", "DAILY", "SERVICE", "",
+				"Amazon EC2", "", "UnblendedCost", "row", "1.00", 1.0, "m"))
+
+	matches, err := store.Query(context.Background(), []float32{1, 0}, 5,
+		types.VectorQueryFilter{Start: "2024-01-01", Granularity: "DAILY"})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "doc-1", matches[0].ID, "rows keep the distance ordering of the query")
+	assert.InDelta(t, 0.9, matches[0].Score, 1e-6)
+	assert.Equal(t, "Amazon S3", matches[0].PageContent)
+	assert.Equal(t, []string{"SERVICE=Amazon S3", "REGION=us-east-1"}, matches[0].Metadata.Attributes)
+	assert.Equal(t, 3.0, matches[0].Metadata.CostAmount)
+	assert.Nil(t, matches[1].Metadata.Attributes)
+}
+
+func TestPGVectorDelete(t *testing.T) {
+	store, mock := newMockPGVectorStore(t)
+
+	mock.ExpectExec(sqlPattern("DELETE FROM costs WHERE namespace = $1 AND id = ANY($2)")).
+		WithArgs("acct-1", []string{"doc-1", "doc-2"}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(sqlPattern("DELETE FROM costs WHERE namespace = $1 AND cost_amount >= $2")).
+		WithArgs("acct-1", 10.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^" + sqlPattern("DELETE FROM costs WHERE namespace = $1") + "$").
+		WithArgs("acct-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	ctx := context.Background()
+	require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{IDs: []string{"doc-1", "doc-2"}}))
+	require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{Filter: types.VectorQueryFilter{MinCost: 10}}))
+	require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{All: true}))
+	assert.Error(t, store.Delete(ctx, types.VectorDeleteRequest{}))
+}
+
+func TestPGVectorStats(t *testing.T) {
+	store, mock := newMockPGVectorStore(t)
+
+	mock.ExpectQuery(sqlPattern("SELECT to_regclass($1)::text")).WithArgs("costs").
+		WillReturnRows(sqlmock.NewRows([]string{"to_regclass"}).AddRow(nil))
+	mock.ExpectQuery(sqlPattern("SELECT to_regclass($1)::text")).WithArgs("costs").
+		WillReturnRows(sqlmock.NewRows([]string{"to_regclass"}).AddRow("costs"))
+	mock.ExpectQuery(sqlPattern("SELECT namespace, count(*), max(vector_dims(embedding)) FROM costs GROUP BY namespace")).
+		WillReturnRows(sqlmock.NewRows([]string{"namespace", "count", "max"}).
+			AddRow("acct-1", 4, 1536).
+			AddRow("", 2, 1536))
+
+	ctx := context.Background()
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.VectorStoreStats{Backend: PGVector, Namespace: "acct-1"}, stats,
+		"a table that does not exist yet is empty")
+
+	stats, err = store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stats.Count)
+	assert.Equal(t, 1536, stats.Dimension)
+	assert.Equal(t, map[string]int{"acct-1": 4, "": 2}, stats.Namespaces)
+}
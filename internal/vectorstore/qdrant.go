@@ -0,0 +1,392 @@
+package vectorstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	http2 "github.com/cduggn/ccexplorer/internal/http"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+)
+
+// qdrantStartEpoch is the payload field holding the start of a document in
+// Unix seconds, which date filters compare as a range
+const qdrantStartEpoch = "start_epoch"
+
+// QdrantStore speaks the Qdrant REST API. The collection is created with
+// cosine distance on the first upsert. Namespaces share the collection and
+// are told apart by the namespace payload field.
+type QdrantStore struct {
+	builder    http2.Builder
+	httpClient *http.Client
+	baseURL    string
+	apiKey     string
+	collection string
+	namespace  string
+	retry      http2.RetryPolicy
+}
+
+type qdrantPoint struct {
+	ID      string         `json:"id"`
+	Vector  []float32      `json:"vector,omitempty"`
+	Payload map[string]any `json:"payload,omitempty"`
+	Score   float32        `json:"score,omitempty"`
+}
+
+type qdrantCollectionInfo struct {
+	PointsCount int `json:"points_count"`
+	Config      struct {
+		Params struct {
+			Vectors struct {
+				Size int `json:"size"`
+			} `json:"vectors"`
+		} `json:"params"`
+	} `json:"config"`
+}
+
+func NewQdrantStore(builder http2.Builder, baseURL, apiKey, collection,
+	namespace string) *QdrantStore {
+	return &QdrantStore{
+		builder:    builder,
+		httpClient: &http.Client{},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		collection: collection,
+		namespace:  namespace,
+		retry:      http2.DefaultRetryPolicy,
+	}
+}
+
+// Upsert writes items as points, creating the collection if needed
+func (q *QdrantStore) Upsert(ctx context.Context,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
+
+	if len(items) == 0 {
+		return types.UpsertResponse{}, nil
+	}
+	if err := q.ensureCollection(ctx, len(items[0].EmbeddingVector)); err != nil {
+		return types.UpsertResponse{}, err
+	}
+
+	points := utils.ConvertSlice(items, func(item *types.VectorStoreItem) qdrantPoint {
+		return qdrantPoint{
+			ID:      qdrantPointID(q.namespace, item.ID),
+			Vector:  item.EmbeddingVector,
+			Payload: qdrantPayload(q.namespace, item),
+		}
+	})
+
+	err := q.do(ctx, http.MethodPut, q.collectionPath("/points?wait=true"),
+		map[string]any{"points": points}, nil)
+	if err != nil {
+		return types.UpsertResponse{}, err
+	}
+	return types.UpsertResponse{UpsertedCount: len(points)}, nil
+}
+
+// Query runs a similarity search with the filter evaluated by Qdrant
+func (q *QdrantStore) Query(ctx context.Context, vector []float32, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+
+	qf, err := buildQdrantFilter(q.namespace, filter)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]any{
+		"vector":       vector,
+		"limit":        topK,
+		"with_payload": true,
+		"filter":       qf,
+	}
+
+	var points []qdrantPoint
+	if err := q.do(ctx, http.MethodPost, q.collectionPath("/points/search"), body, &points); err != nil {
+		return nil, err
+	}
+
+	return utils.Transform(points, func(p qdrantPoint) types.VectorQueryMatch {
+		return types.VectorQueryMatch{
+			ID:          payloadString(p.Payload, "doc_id"),
+			Score:       p.Score,
+			PageContent: payloadString(p.Payload, "page_content"),
+			Metadata: types.VectorStoreItemMetadata{
+				StartDate:      payloadString(p.Payload, "start"),
+				EndDate:        payloadString(p.Payload, "end"),
+				Granularity:    payloadString(p.Payload, "granularity"),
+				Dimensions:     payloadString(p.Payload, "dimensions"),
+				Tags:           payloadString(p.Payload, "tags"),
+				Keys:           payloadString(p.Payload, "keys"),
+				Attributes:     payloadStrings(p.Payload, "attributes"),
+				Metrics:        payloadString(p.Payload, "metrics"),
+				Document:       payloadString(p.Payload, "document"),
+				Cost:           payloadString(p.Payload, "cost"),
+				CostAmount:     payloadFloat(p.Payload, "cost_amount"),
+				EmbeddingModel: payloadString(p.Payload, "embedding_model"),
+			},
+		}
+	}), nil
+}
+
+// Delete removes points of the store's namespace by ID or filter. Deleting
+// everything removes the namespace's points and keeps the collection.
+func (q *QdrantStore) Delete(ctx context.Context, req types.VectorDeleteRequest) error {
+	if req.IsEmpty() {
+		return fmt.Errorf("delete request selects no vectors")
+	}
+
+	body := map[string]any{}
+	switch {
+	case len(req.IDs) > 0:
+		body["points"] = utils.Transform(req.IDs, func(id string) string {
+			return qdrantPointID(q.namespace, id)
+		})
+	case req.All:
+		qf, _ := buildQdrantFilter(q.namespace, types.VectorQueryFilter{})
+		body["filter"] = qf
+	default:
+		qf, err := buildQdrantFilter(q.namespace, req.Filter)
+		if err != nil {
+			return err
+		}
+		body["filter"] = qf
+	}
+	return q.do(ctx, http.MethodPost, q.collectionPath("/points/delete?wait=true"), body, nil)
+}
+
+// Stats returns the point count of the store's namespace and the vector
+// size of the collection. A collection that has not been created yet is
+// reported as empty.
+func (q *QdrantStore) Stats(ctx context.Context) (types.VectorStoreStats, error) {
+	stats := types.VectorStoreStats{Backend: Qdrant, Namespace: q.namespace}
+
+	var info qdrantCollectionInfo
+	err := q.do(ctx, http.MethodGet, q.collectionPath(""), nil, &info)
+	var statusErr http2.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		return stats, nil
+	}
+	if err != nil {
+		return types.VectorStoreStats{}, err
+	}
+
+	qf, _ := buildQdrantFilter(q.namespace, types.VectorQueryFilter{})
+	var count struct {
+		Count int `json:"count"`
+	}
+	err = q.do(ctx, http.MethodPost, q.collectionPath("/points/count"),
+		map[string]any{"filter": qf, "exact": true}, &count)
+	if err != nil {
+		return types.VectorStoreStats{}, err
+	}
+
+	stats.Count = count.Count
+	stats.Dimension = info.Config.Params.Vectors.Size
+	return stats, nil
+}
+
+func (q *QdrantStore) ensureCollection(ctx context.Context, dimension int) error {
+	var info qdrantCollectionInfo
+	err := q.do(ctx, http.MethodGet, q.collectionPath(""), nil, &info)
+	if err == nil {
+		if size := info.Config.Params.Vectors.Size; size != 0 && size != dimension {
+			return fmt.Errorf("vector dimension %d does not match collection %s dimension %d",
+				dimension, q.collection, size)
+		}
+		return nil
+	}
+
+	var statusErr http2.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	err = q.do(ctx, http.MethodPut, q.collectionPath(""), map[string]any{
+		"vectors": map[string]any{"size": dimension, "distance": "Cosine"},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return q.do(ctx, http.MethodPut, q.collectionPath("/index?wait=true"), map[string]any{
+		"field_name":   qdrantStartEpoch,
+		"field_schema": "integer",
+	}, nil)
+}
+
+func (q *QdrantStore) collectionPath(suffix string) string {
+	return "/collections/" + url.PathEscape(q.collection) + suffix
+}
+
+// do sends a request, retrying rate-limited and failed requests, and
+// decodes the "result" field of the response into v
+func (q *QdrantStore) do(ctx context.Context, method, path string, body, v any) error {
+	var payload []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	return q.retry.Do(ctx, func(ctx context.Context) error {
+		return q.send(ctx, method, path, payload, v)
+	})
+}
+
+func (q *QdrantStore) send(ctx context.Context, method, path string, payload []byte, v any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := q.builder.Build(ctx, method, q.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("content-type", "application/json")
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+
+	res, err := q.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return http2.NewStatusError(Qdrant, res)
+	}
+	if v == nil {
+		return nil
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode qdrant response: %w", err)
+	}
+	return json.Unmarshal(envelope.Result, v)
+}
+
+// buildQdrantFilter renders filter as a Qdrant filter restricted to
+// namespace
+func buildQdrantFilter(namespace string, filter types.VectorQueryFilter) (map[string]any, error) {
+	must := []any{
+		map[string]any{"key": "namespace", "match": map[string]any{"value": namespace}},
+	}
+
+	if filter.Start != "" || filter.End != "" {
+		bounds := map[string]any{}
+		for op, date := range map[string]string{"gte": filter.Start, "lt": filter.End} {
+			if date == "" {
+				continue
+			}
+			t, err := parseQdrantTime(date)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+			}
+			bounds[op] = t.Unix()
+		}
+		if gte, ok := bounds["gte"].(int64); ok {
+			if lt, ok := bounds["lt"].(int64); ok && lt <= gte {
+				return nil, fmt.Errorf("end date must be after start date")
+			}
+		}
+		must = append(must, map[string]any{"key": qdrantStartEpoch, "range": bounds})
+	}
+	if len(filter.Dimensions) > 0 {
+		must = append(must, map[string]any{
+			"key":   "dimensions",
+			"match": map[string]any{"value": strings.Join(filter.Dimensions, ",")},
+		})
+	}
+	if filter.Granularity != "" {
+		must = append(must, map[string]any{
+			"key":   "granularity",
+			"match": map[string]any{"value": filter.Granularity},
+		})
+	}
+	if filter.MinCost != 0 {
+		must = append(must, map[string]any{
+			"key":   "cost_amount",
+			"range": map[string]any{"gte": filter.MinCost},
+		})
+	}
+
+	return map[string]any{"must": must}, nil
+}
+
+// qdrantPointID derives a stable UUID from a namespace and document ID,
+// since Qdrant only accepts unsigned integers and UUIDs as point IDs
+func qdrantPointID(namespace, id string) string {
+	sum := sha256.Sum256([]byte(namespace + "\x00" + id))
+	h := hex.EncodeToString(sum[:16])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// parseQdrantTime parses a date or, for hourly documents, an RFC3339
+// timestamp
+func parseQdrantTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+func qdrantPayload(namespace string, item *types.VectorStoreItem) map[string]any {
+	payload := map[string]any{
+		"doc_id":          item.ID,
+		"namespace":       namespace,
+		"page_content":    item.EmbeddingText,
+		"start":           item.Metadata.StartDate,
+		"end":             item.Metadata.EndDate,
+		"granularity":     item.Metadata.Granularity,
+		"dimensions":      item.Metadata.Dimensions,
+		"tags":            item.Metadata.Tags,
+		"keys":            item.Metadata.Keys,
+		"attributes":      item.Metadata.Attributes,
+		"metrics":         item.Metadata.Metrics,
+		"document":        item.Metadata.Document,
+		"cost":            item.Metadata.Cost,
+		"cost_amount":     item.Metadata.CostAmount,
+		"embedding_model": item.Metadata.EmbeddingModel,
+	}
+	if start, err := parseQdrantTime(item.Metadata.StartDate); err == nil {
+		payload[qdrantStartEpoch] = start.Unix()
+	}
+	return payload
+}
+
+func payloadString(payload map[string]any, key string) string {
+	s, _ := payload[key].(string)
+	return s
+}
+
+func payloadStrings(payload map[string]any, key string) []string {
+	values, _ := payload[key].([]any)
+	var s []string
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			s = append(s, str)
+		}
+	}
+	return s
+}
+
+func payloadFloat(payload map[string]any, key string) float64 {
+	f, _ := payload[key].(float64)
+	return f
+}
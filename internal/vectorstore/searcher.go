@@ -0,0 +1,46 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// Searcher embeds free text and queries a vector store with the result
+type Searcher struct {
+	Embedder ports.Embedder
+	Store    ports.VectorStore
+}
+
+func NewSearcher(embedder ports.Embedder, store ports.VectorStore) *Searcher {
+	return &Searcher{
+		Embedder: embedder,
+		Store:    store,
+	}
+}
+
+// Search embeds text and returns the topK most similar stored cost
+// documents. Documents embedded with a different model are rejected, as their
+// similarity scores are meaningless.
+func (s *Searcher) Search(ctx context.Context, text string, topK int,
+	filter types.VectorQueryFilter) ([]types.VectorQueryMatch, error) {
+
+	embeddings, err := s.Embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("failed to embed query: no embedding returned")
+	}
+
+	matches, err := s.Store.Query(ctx, embeddings[0], topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMatches(matches, s.Embedder.Model()); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
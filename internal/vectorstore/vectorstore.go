@@ -0,0 +1,113 @@
+package vectorstore
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/http"
+	"github.com/cduggn/ccexplorer/internal/pinecone"
+	"github.com/cduggn/ccexplorer/internal/ports"
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const (
+	Local    = "local"
+	Pinecone = "pinecone"
+	Qdrant   = "qdrant"
+	PGVector = "pgvector"
+
+	DefaultLocalPath        = "./ccexplorer_vectors.json"
+	DefaultQdrantCollection = "ccexplorer"
+	DefaultPGVectorTable    = "ccexplorer_costs"
+)
+
+// Backends lists the supported vector store backends
+var Backends = []string{Local, Pinecone, Qdrant, PGVector}
+
+// Validate checks that the settings required by the selected backend are
+// present
+func Validate(cfg types.VectorStoreConfig) error {
+	switch strings.ToLower(cfg.Backend) {
+	case Local:
+		if cfg.LocalPath == "" {
+			return fmt.Errorf("local vector store requires a file path")
+		}
+	case Pinecone:
+		if cfg.PineconeIndex == "" || cfg.PineconeAPIKey == "" {
+			return fmt.Errorf("pinecone vector store requires PINECONE_INDEX and PINECONE_API_KEY")
+		}
+	case Qdrant:
+		if cfg.QdrantURL == "" || cfg.QdrantCollection == "" {
+			return fmt.Errorf("qdrant vector store requires QDRANT_URL and a collection name")
+		}
+	case PGVector:
+		if cfg.PGVectorDSN == "" {
+			return fmt.Errorf("pgvector vector store requires PGVECTOR_DSN")
+		}
+		if !validIdentifier(cfg.PGVectorTable) {
+			return fmt.Errorf("invalid pgvector table name %q", cfg.PGVectorTable)
+		}
+	default:
+		return fmt.Errorf("unknown vector store %q, expected one of %s",
+			cfg.Backend, strings.Join(Backends, ", "))
+	}
+	return nil
+}
+
+// New returns the vector store adapter selected by cfg.Backend
+func New(cfg types.VectorStoreConfig) (ports.VectorStore, error) {
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(cfg.Backend) {
+	case Local:
+		return NewLocalStore(cfg.LocalPath, cfg.Namespace), nil
+	case Pinecone:
+		return pinecone.NewVectorStoreClient(http.NewRequestBuilder(),
+			cfg.PineconeIndex, cfg.PineconeAPIKey, cfg.Namespace), nil
+	case Qdrant:
+		return NewQdrantStore(http.NewRequestBuilder(), cfg.QdrantURL,
+			cfg.QdrantAPIKey, cfg.QdrantCollection, cfg.Namespace), nil
+	default:
+		return NewPGVectorStore(cfg.PGVectorDSN, cfg.PGVectorTable, cfg.Namespace)
+	}
+}
+
+func cosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
+
+// matchesFilter applies filter to stored metadata for backends that filter
+// on the client side
+func matchesFilter(m types.VectorStoreItemMetadata, filter types.VectorQueryFilter) bool {
+	if filter.Start != "" && m.StartDate < filter.Start {
+		return false
+	}
+	if filter.End != "" && m.StartDate >= filter.End {
+		return false
+	}
+	if len(filter.Dimensions) > 0 && m.Dimensions != strings.Join(filter.Dimensions, ",") {
+		return false
+	}
+	if filter.Granularity != "" && m.Granularity != filter.Granularity {
+		return false
+	}
+	if filter.MinCost != 0 && m.CostAmount < filter.MinCost {
+		return false
+	}
+	return true
+}
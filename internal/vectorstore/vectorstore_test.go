@@ -0,0 +1,219 @@
+package vectorstore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/embedding"
+	http2 "github.com/cduggn/ccexplorer/internal/http"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.VectorStoreConfig
+		wantErr bool
+	}{
+		{"local", types.VectorStoreConfig{Backend: "local", LocalPath: "v.json"}, false},
+		{"local without path", types.VectorStoreConfig{Backend: "local"}, true},
+		{"pinecone without key", types.VectorStoreConfig{Backend: "pinecone", PineconeIndex: "https://x"}, true},
+		{"qdrant", types.VectorStoreConfig{Backend: "QDRANT", QdrantURL: "http://q", QdrantCollection: "c"}, false},
+		{"pgvector bad table", types.VectorStoreConfig{Backend: "pgvector", PGVectorDSN: "postgres://", PGVectorTable: "x; drop"}, true},
+		{"pgvector", types.VectorStoreConfig{Backend: "pgvector", PGVectorDSN: "postgres://", PGVectorTable: "costs"}, false},
+		{"unknown", types.VectorStoreConfig{Backend: "milvus"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPGVectorWhere(t *testing.T) {
+	where, args := pgvectorWhere("acct-1", types.VectorQueryFilter{
+		Start:       "2024-01-01",
+		End:         "2024-02-01",
+		Dimensions:  []string{"SERVICE", "REGION"},
+		Granularity: "MONTHLY",
+		MinCost:     10,
+	}, 2)
+	assert.Equal(t, " WHERE namespace = $2 AND start_date >= $3 AND start_date < $4"+
+		" AND dimensions = $5 AND granularity = $6 AND cost_amount >= $7", where)
+	assert.Equal(t, []any{"acct-1", "2024-01-01", "2024-02-01", "SERVICE,REGION", "MONTHLY", 10.0}, args)
+
+	where, args = pgvectorWhere("", types.VectorQueryFilter{}, 1)
+	assert.Equal(t, " WHERE namespace = $1", where)
+	assert.Equal(t, []any{""}, args)
+
+	assert.Equal(t, "[0.5,-1,0.25]", vectorLiteral([]float32{0.5, -1, 0.25}))
+}
+
+func TestQdrantStore(t *testing.T) {
+	var requests []string
+	var upserted map[string][]qdrantPoint
+	var searched, deleted map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		assert.Equal(t, "q-key", r.Header.Get("api-key"))
+
+		switch {
+		case r.Method == http.MethodGet && upserted == nil:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"status":{"error":"Not found"}}`)
+		case r.Method == http.MethodGet:
+			_, _ = io.WriteString(w, `{"result":{"points_count":5,"config":{"params":{"vectors":{"size":3}}}}}`)
+		case r.Method == http.MethodPut && r.URL.Path == "/collections/costs/points":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&upserted))
+			_, _ = io.WriteString(w, `{"result":{"status":"completed"}}`)
+		case r.URL.Path == "/collections/costs/points/delete":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&deleted))
+			_, _ = io.WriteString(w, `{"result":{"status":"completed"}}`)
+		case r.URL.Path == "/collections/costs/points/count":
+			_, _ = io.WriteString(w, `{"result":{"count":3}}`)
+		case r.URL.Path == "/collections/costs/points/search":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&searched))
+			_, _ = io.WriteString(w, `{"result":[{"id":"u","score":0.75,"payload":{"doc_id":"ec2-jan","page_content":"doc","start":"2024-01-01","dimensions":"SERVICE"}}]}`)
+		default:
+			_, _ = io.WriteString(w, `{"result":true}`)
+		}
+	}))
+	defer srv.Close()
+
+	store := NewQdrantStore(http2.NewRequestBuilder(), srv.URL+"/", "q-key", "costs", "acct-1")
+	ctx := context.Background()
+
+	resp, err := store.Upsert(ctx, testItems())
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.UpsertedCount)
+	assert.Equal(t, []string{
+		"GET /collections/costs",
+		"PUT /collections/costs",
+		"PUT /collections/costs/index",
+		"PUT /collections/costs/points",
+	}, requests)
+	require.Len(t, upserted["points"], 3)
+	assert.Equal(t, qdrantPointID("acct-1", "ec2-jan"), upserted["points"][0].ID)
+	assert.NotEqual(t, qdrantPointID("", "ec2-jan"), upserted["points"][0].ID)
+	assert.Equal(t, "ec2-jan", upserted["points"][0].Payload["doc_id"])
+	assert.Equal(t, "acct-1", upserted["points"][0].Payload["namespace"])
+
+	matches, err := store.Query(ctx, []float32{1, 0, 0}, 1, types.VectorQueryFilter{})
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "ec2-jan", matches[0].ID)
+	assert.Equal(t, float32(0.75), matches[0].Score)
+	assert.Equal(t, "SERVICE", matches[0].Metadata.Dimensions)
+	assert.Equal(t, map[string]any{"must": []any{
+		map[string]any{"key": "namespace", "match": map[string]any{"value": "acct-1"}},
+	}}, searched["filter"])
+
+	require.NoError(t, store.Delete(ctx, types.VectorDeleteRequest{All: true}))
+	assert.Equal(t, searched["filter"], deleted["filter"], "deleting everything only clears the namespace")
+
+	stats, err := store.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.VectorStoreStats{Backend: Qdrant, Namespace: "acct-1", Count: 3, Dimension: 3}, stats)
+}
+
+func TestQdrantDefaultNamespaceFilter(t *testing.T) {
+	qf, err := buildQdrantFilter("", types.VectorQueryFilter{Granularity: "DAILY"})
+	require.NoError(t, err)
+	must := qf["must"].([]any)
+	require.Len(t, must, 2)
+	assert.Equal(t, map[string]any{"key": "namespace", "match": map[string]any{"value": ""}}, must[0],
+		"the default namespace is matched like any other")
+	assert.Equal(t, map[string]any{"key": "granularity", "match": map[string]any{"value": "DAILY"}}, must[1])
+}
+
+func TestQdrantHourlyRangeFilter(t *testing.T) {
+	item := &types.VectorStoreItem{ID: "ec2-5am", Metadata: types.VectorStoreItemMetadata{
+		StartDate:   "2024-01-01T05:00:00Z",
+		EndDate:     "2024-01-01T06:00:00Z",
+		Granularity: "HOURLY",
+	}}
+	start := qdrantPayload("", item)[qdrantStartEpoch].(int64)
+	assert.Equal(t, int64(1704085200), start)
+
+	qf, err := buildQdrantFilter("", types.VectorQueryFilter{Start: "2024-01-01", End: "2024-01-02"})
+	require.NoError(t, err)
+	bounds := qf["must"].([]any)[1].(map[string]any)["range"].(map[string]any)
+	assert.Equal(t, map[string]any{"gte": int64(1704067200), "lt": int64(1704153600)}, bounds)
+	assert.True(t, start >= bounds["gte"].(int64) && start < bounds["lt"].(int64),
+		"hourly documents fall in the range of their day")
+
+	qf, err = buildQdrantFilter("", types.VectorQueryFilter{Start: "2020-01-01", End: "2025-01-01"})
+	require.NoError(t, err, "ranges of several years are one condition")
+	assert.Len(t, qf["must"].([]any)[1].(map[string]any)["range"], 2)
+
+	_, err = buildQdrantFilter("", types.VectorQueryFilter{Start: "2024-01-02", End: "2024-01-01"})
+	assert.Error(t, err)
+}
+
+type fakeEmbedder struct {
+	model string
+}
+
+func (f fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return [][]float32{{0, 1, 0}}, nil
+}
+
+func (f fakeEmbedder) Model() string {
+	return f.model
+}
+
+func TestSearcher(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir()+"/vectors.json", "")
+	_, err := store.Upsert(ctx, testItems())
+	require.NoError(t, err)
+
+	t.Run("documents without a model were embedded with the default model", func(t *testing.T) {
+		embedder := fakeEmbedder{model: embedding.DefaultOpenAIModel}
+		matches, err := NewSearcher(embedder, store).Search(ctx, "storage", 1, types.VectorQueryFilter{})
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "s3-jan", matches[0].ID)
+	})
+
+	t.Run("rejects matches from another model", func(t *testing.T) {
+		_, err := NewSearcher(fakeEmbedder{model: "nomic-embed-text"}, store).
+			Search(ctx, "storage", 1, types.VectorQueryFilter{})
+		var mismatch ModelMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, embedding.DefaultOpenAIModel, mismatch.Stored)
+	})
+}
+
+func TestCheckModel(t *testing.T) {
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir()+"/vectors.json", "")
+
+	assert.NoError(t, CheckModel(ctx, store, []float32{1, 0, 0}, "hash-3"),
+		"an empty store accepts any model")
+
+	items := testItems()
+	for _, item := range items {
+		item.Metadata.EmbeddingModel = "hash-3"
+	}
+	_, err := store.Upsert(ctx, items)
+	require.NoError(t, err)
+
+	assert.NoError(t, CheckModel(ctx, store, []float32{1, 0, 0}, "hash-3"))
+	assert.ErrorAs(t, CheckModel(ctx, store, []float32{1, 0, 0}, "text-embedding-3-small"),
+		&ModelMismatchError{})
+	assert.ErrorContains(t, CheckModel(ctx, store, []float32{1, 0}, "hash-2"),
+		"3-dimensional")
+}
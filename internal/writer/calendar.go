@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cc "github.com/cduggn/ccexplorer/internal/types"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	echartstypes "github.com/go-echarts/go-echarts/v2/types"
+)
+
+const (
+	// MaxCalendarDays is the longest period a calendar chart covers
+	MaxCalendarDays = 366
+	// calendarContributors is the number of keys listed per day
+	calendarContributors = 5
+)
+
+// Contribution is the amount of one key on one day
+type Contribution struct {
+	Name   string
+	Amount float64
+}
+
+// CalendarDay holds the spend of one day and its largest contributors, in
+// descending order of amount
+type CalendarDay struct {
+	Date  string
+	Total float64
+	Top   []Contribution
+}
+
+// NewCalendarDays returns the daily totals of the first requested metric
+// with the top contributing keys of each day
+func NewCalendarDays(r cc.InputType, contributors int) []CalendarDay {
+	p := newPivot(r.Services, groupLabels(r.Dimensions, r.Tags),
+		pivotMetric(r.Metrics, r.Services))
+
+	days := make([]CalendarDay, len(p.Periods))
+	for i, period := range p.Periods {
+		day := CalendarDay{Date: period, Total: p.PeriodTotals[i]}
+		for _, row := range p.Rows {
+			if row.Amounts[i] != 0 {
+				day.Top = append(day.Top, Contribution{Name: strings.Join(row.Keys, " / "), Amount: row.Amounts[i]})
+			}
+		}
+		sort.SliceStable(day.Top, func(a, b int) bool { return day.Top[a].Amount > day.Top[b].Amount })
+		if len(day.Top) > contributors {
+			day.Top = day.Top[:contributors]
+		}
+		days[i] = day
+	}
+	return days
+}
+
+// calendarData is a heatmap item on a calendar; Top is read by the tooltip
+type calendarData struct {
+	Value []any   `json:"value"`
+	Top   [][]any `json:"top,omitempty"`
+}
+
+// calendarTooltip shows the amount of a day, followed by its largest
+// contributors on the total calendar
+func calendarTooltip(unit string) string {
+	return fmt.Sprintf(`function (p) {
+	var lines = [p.value[0] + ': ' + p.value[1].toFixed(2) + ' %s'];
+	(p.data.top || []).forEach(function (t) {
+		lines.push(t[0] + ': ' + t[1].toFixed(2));
+	});
+	return lines.join('<br/>');
+}`, strings.ReplaceAll(unit, "'", ""))
+}
+
+// buildCalendarCharts draws the total spend per day on a calendar, with the
+// top contributors of each day in the tooltip, followed by a calendar per
+// key for the series of s
+func buildCalendarCharts(r cc.InputType, s Series) []*charts.HeatMap {
+	days := NewCalendarDays(r, calendarContributors)
+	calendarRange := []string{r.Start, lastCalendarDay(r.End)}
+
+	total := make([]calendarData, len(days))
+	for i, d := range days {
+		total[i] = calendarData{Value: []any{d.Date, roundCents(d.Total)}}
+		for _, c := range d.Top {
+			total[i].Top = append(total[i].Top, []any{c.Name, roundCents(c.Amount)})
+		}
+	}
+	heatmaps := []*charts.HeatMap{
+		buildCalendarChart(r, s, "Daily spend", calendarRange, total),
+	}
+
+	for i, name := range s.Names {
+		if name == OtherName {
+			continue
+		}
+		data := make([]calendarData, len(s.Periods))
+		for j, period := range s.Periods {
+			data[j] = calendarData{Value: []any{period, roundCents(s.Values[i][j])}}
+		}
+		heatmaps = append(heatmaps, buildCalendarChart(r, s, name, calendarRange, data))
+	}
+	return heatmaps
+}
+
+func buildCalendarChart(r cc.InputType, s Series, title string, calendarRange []string,
+	data []calendarData) *charts.HeatMap {
+	var highest float64
+	for _, d := range data {
+		highest = max(highest, d.Value[1].(float64))
+	}
+
+	heatmap := charts.NewHeatMap()
+	heatmap.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    fmt.Sprintf("%s (%s %s)", title, s.Metric, s.Unit),
+			Subtitle: fmt.Sprintf("Start: %s, End: %s", r.Start, r.End),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      opts.Bool(true),
+			Formatter: opts.FuncOpts(calendarTooltip(s.Unit)),
+		}),
+		charts.WithVisualMapOpts(opts.VisualMap{
+			Calculable: opts.Bool(true),
+			Min:        0,
+			Max:        float32(highest),
+			Orient:     "horizontal",
+			Left:       "center",
+			Bottom:     "0",
+			InRange:    &opts.VisualMapInRange{Color: []string{"#ebedf0", "#fdae61", "#d73027"}},
+		}),
+		charts.WithInitializationOpts(opts.Initialization{Width: "1200px", Height: "260px"}),
+	)
+	heatmap.AddCalendar(&opts.Calendar{
+		Top:      "70",
+		Left:     "40",
+		Right:    "20",
+		CellSize: "auto",
+		Range:    calendarRange,
+		ItemStyle: &opts.ItemStyle{
+			BorderWidth: 0.5,
+		},
+	})
+
+	series := charts.SingleSeries{Name: title, Type: echartstypes.ChartHeatMap, Data: data}
+	series.ConfigureSeriesOpts(charts.WithCoordinateSystem("calendar"))
+	heatmap.MultiSeries = append(heatmap.MultiSeries, series)
+	return heatmap
+}
+
+// lastCalendarDay returns the day before the exclusive end date
+func lastCalendarDay(end string) string {
+	t, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return end
+	}
+	return t.AddDate(0, 0, -1).Format("2006-01-02")
+}
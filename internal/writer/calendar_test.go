@@ -0,0 +1,64 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dailyInput() types.CostAndUsageOutputType {
+	services := map[int]types.Service{}
+	for day := 1; day <= 2; day++ {
+		for i, name := range []string{"Amazon EC2", "Amazon S3", "AWS Lambda", "Amazon RDS", "Amazon SQS", "Amazon SNS"} {
+			services[len(services)] = types.Service{
+				Start:   fmt.Sprintf("2024-01-%02d", day),
+				Keys:    []string{name},
+				Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: float64(day * (6 - i)), Unit: "USD"}},
+			}
+		}
+	}
+	return types.CostAndUsageOutputType{
+		Services:    services,
+		Granularity: "DAILY",
+		Start:       "2024-01-01",
+		End:         "2024-01-03",
+		Dimensions:  []string{"SERVICE"},
+		Metrics:     []string{"UnblendedCost"},
+	}
+}
+
+func TestNewCalendarDays(t *testing.T) {
+	input := dailyInput()
+	days := NewCalendarDays(utils.ConvertToChartInputType(input, utils.ConvertMapToSlice(input.Services)), 5)
+
+	require.Len(t, days, 2)
+	assert.Equal(t, "2024-01-02", days[1].Date)
+	assert.InDelta(t, 42, days[1].Total, 1e-9)
+	assert.Equal(t, []Contribution{
+		{Name: "Amazon EC2", Amount: 12},
+		{Name: "Amazon S3", Amount: 10},
+		{Name: "AWS Lambda", Amount: 8},
+		{Name: "Amazon RDS", Amount: 6},
+		{Name: "Amazon SQS", Amount: 4},
+	}, days[1].Top, "the five largest contributors of the day")
+}
+
+func TestCalendarChart(t *testing.T) {
+	input := dailyInput()
+	input.Output.Path = Stdout
+	input.Chart = types.ChartConfig{Type: ChartCalendar, TopN: 2}
+
+	output, err := NewCostUsageToChartTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, (&ChartRenderer{stdout: &buf}).Render(output))
+	assert.Contains(t, buf.String(), `"range":["2024-01-01","2024-01-02"]`)
+	assert.Contains(t, buf.String(), `{"value":["2024-01-02",42],"top":[["Amazon EC2",12],`)
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte(`"type":"heatmap"`)),
+		"a calendar of the total and one per top key")
+}
@@ -3,41 +3,190 @@ package writer
 import (
 	"fmt"
 	cc "github.com/cduggn/ccexplorer/internal/types"
-	"github.com/cduggn/ccexplorer/internal/utils"
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/components"
 	"github.com/go-echarts/go-echarts/v2/opts"
+	echartstypes "github.com/go-echarts/go-echarts/v2/types"
 
-	"io"
+	"math"
+	"strings"
 )
 
-var (
-	chartFileName = "ccexplorer_chart.html"
-)
+func (Builder) NewCharts(r cc.InputType) (*components.Page,
+	error) {
+	page := components.NewPage()
+	page.PageTitle = "Cost and Usage Report"
 
-func WriteToChart(p *components.Page) error {
+	series := NewSeries(r, r.Chart.TopN)
+	switch r.Chart.Type {
+	case ChartBar:
+		page.AddCharts(buildStackedBarChart(r, series))
+	case ChartLine:
+		page.AddCharts(buildLineChart(r, series, series.Values, "Cost per key", false))
+	case ChartArea:
+		page.AddCharts(buildLineChart(r, series, series.Values, "Stacked cost per key", true))
+	case ChartCumulative:
+		page.AddCharts(buildLineChart(r, series, series.Cumulative(), "Cumulative cost", true))
+	case ChartPareto:
+		page.AddCharts(buildParetoChart(r, series))
+	case ChartDashboard:
+		page.SetLayout(components.PageFlexLayout)
+		page.AddCharts(
+			buildStackedBarChart(r, series),
+			buildLineChart(r, series, series.Cumulative(), "Cumulative cost", true),
+			buildParetoChart(r, series),
+		)
+		for _, chart := range buildPieCharts(r) {
+			page.AddCharts(chart)
+		}
+	case ChartCalendar:
+		for _, chart := range buildCalendarCharts(r, series) {
+			page.AddCharts(chart)
+		}
+	case ChartTreemap:
+		page.AddCharts(buildTreemapChart(r, NewHierarchy(r, r.Chart.TopN)))
+	case ChartSunburst:
+		page.AddCharts(buildSunburstChart(r, NewHierarchy(r, r.Chart.TopN)))
+	case ChartSankey:
+		page.AddCharts(buildSankeyChart(r, NewHierarchy(r, r.Chart.TopN)))
+	case ChartMap:
+		page.AddCharts(buildRegionMapChart(r, NewRegionMap(r, r.Chart.TopN)))
+	default:
+		for _, chart := range buildPieCharts(r) {
+			page.AddCharts(chart)
+		}
+	}
 
-	f, err := utils.NewFile(OutputDir, chartFileName)
+	// map charts open offline with the bundled assets; --assetsDir inlines
+	// the assets of any chart
+	var err error
+	switch {
+	case r.Chart.AssetsDir != "":
+		err = inlineAssets(page, dirAssets(r.Chart.AssetsDir))
+	case r.Chart.Type == ChartMap:
+		err = inlineAssets(page, embeddedAsset)
+	}
 	if err != nil {
-		return cc.Error{
-			Msg: "Failed creating chart HTML file: " + err.Error(),
+		return nil, err
+	}
+	return page, nil
+}
+
+// legendToggle inverts the legend selection, so that a single series can be
+// shown by deselecting it and toggling
+const legendToggle = `function (ecModel, api) { api.dispatchAction({ type: 'legendInverseSelect' }); }`
+
+// seriesChartOptions returns the options shared by the bar, line and area
+// charts: a scrollable legend with a toggle, and a zoom slider over the x axis
+func seriesChartOptions(r cc.InputType, s Series, title string) []charts.GlobalOpts {
+	return []charts.GlobalOpts{
+		charts.WithTitleOpts(opts.Title{
+			Title: fmt.Sprintf("%s (%s %s)", title, s.Metric, s.Unit),
+			Subtitle: fmt.Sprintf("Granularity: %s Start: %s, End: %s",
+				r.Granularity, r.Start, r.End),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{
+			Show:         opts.Bool(true),
+			Type:         "scroll",
+			SelectedMode: "multiple",
+			Top:          "bottom",
+		}),
+		charts.WithToolboxOpts(opts.Toolbox{
+			Show:  opts.Bool(true),
+			Right: "5%",
+			Feature: &opts.ToolBoxFeature{
+				SaveAsImage: &opts.ToolBoxFeatureSaveAsImage{Show: opts.Bool(true), Title: "Save"},
+				UserDefined: map[string]opts.ToolBoxFeatureUserDefined{
+					"myLegendToggle": {
+						Show:    opts.Bool(true),
+						Title:   "Toggle legend",
+						Icon:    "path://M4,4 L28,4 L28,28 L4,28 Z M10,16 L22,16",
+						OnClick: opts.FuncOpts(legendToggle),
+					},
+				},
+			},
+		}),
+		charts.WithDataZoomOpts(
+			opts.DataZoom{Type: "slider", Start: 0, End: 100},
+			opts.DataZoom{Type: "inside", Start: 0, End: 100},
+		),
+		charts.WithInitializationOpts(opts.Initialization{Width: "1200px", Height: "600px"}),
+	}
+}
+
+func buildStackedBarChart(r cc.InputType, s Series) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(seriesChartOptions(r, s, "Cost per period")...)
+	bar.SetXAxis(s.Periods)
+	for i, name := range s.Names {
+		bar.AddSeries(name, barData(s.Values[i]),
+			charts.WithBarChartOpts(opts.BarChart{Stack: "total"}))
+	}
+	return bar
+}
+
+func buildLineChart(r cc.InputType, s Series, values [][]float64, title string,
+	stacked bool) *charts.Line {
+	line := charts.NewLine()
+	line.SetGlobalOptions(seriesChartOptions(r, s, title)...)
+	line.SetXAxis(s.Periods)
+	for i, name := range s.Names {
+		var options []charts.SeriesOpts
+		if stacked {
+			options = append(options,
+				charts.WithLineChartOpts(opts.LineChart{Stack: "total"}),
+				charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.6}))
 		}
+		line.AddSeries(name, lineData(values[i]), options...)
 	}
-	defer f.Close()
-	return p.Render(io.MultiWriter(f))
+	return line
 }
 
-func (Builder) NewCharts(r cc.InputType) (*components.Page,
-	error) {
-	page := components.NewPage()
-	page.PageTitle = "Cost and Usage Report"
+// buildParetoChart charts the total of each key as bars in descending order,
+// and their cumulative share of the grand total as a line on a second axis
+func buildParetoChart(r cc.InputType, s Series) *charts.Bar {
+	names, totals, shares := s.Pareto()
 
-	p := buildPieCharts(r)
-	for _, chart := range p {
-		page.AddCharts(chart)
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(seriesChartOptions(r, s, "Pareto")...)
+	bar.SetXAxis(names).
+		AddSeries("Total", barData(totals))
+	bar.ExtendYAxis(opts.YAxis{
+		Name:      "Cumulative %",
+		Min:       0,
+		Max:       100,
+		AxisLabel: &opts.AxisLabel{Formatter: "{value} %"},
+	})
+
+	line := charts.NewLine()
+	line.SetXAxis(names).
+		AddSeries("Cumulative %", lineData(shares),
+			charts.WithLineChartOpts(opts.LineChart{YAxisIndex: 1}))
+	bar.Overlap(line)
+	return bar
+}
+
+// barData rounds amounts to cents; values are interfaces so that zero
+// amounts are kept
+func barData(values []float64) []opts.BarData {
+	data := make([]opts.BarData, len(values))
+	for i, v := range values {
+		data[i] = opts.BarData{Value: roundCents(v)}
+	}
+	return data
+}
+
+func lineData(values []float64) []opts.LineData {
+	data := make([]opts.LineData, len(values))
+	for i, v := range values {
+		data[i] = opts.LineData{Value: roundCents(v)}
 	}
+	return data
+}
 
-	return page, nil
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
 }
 
 func buildPieCharts(r cc.InputType) []*charts.Pie {
@@ -113,3 +262,116 @@ func PopulatePieDate(services []cc.Service, key int) []opts.
 func CreateTitle(dimension string) string {
 	return fmt.Sprintf("Pie chart for dimension: [ %s ]", dimension)
 }
+
+// hierarchyChartOptions returns the options shared by the treemap, sunburst
+// and Sankey charts, with a tooltip showing the cost and share of the total
+func hierarchyChartOptions(r cc.InputType, h Hierarchy, title string) []charts.GlobalOpts {
+	return []charts.GlobalOpts{
+		charts.WithTitleOpts(opts.Title{
+			Title: fmt.Sprintf("%s (%s %s)", title, h.Metric, h.Unit),
+			Subtitle: fmt.Sprintf("%s - Granularity: %s Start: %s, End: %s",
+				strings.Join(h.Labels, " > "), r.Granularity, r.Start, r.End),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      opts.Bool(true),
+			Formatter: opts.FuncOpts(shareTooltip(h.Unit, h.Total)),
+		}),
+		charts.WithInitializationOpts(opts.Initialization{Width: "1200px", Height: "800px"}),
+	}
+}
+
+// shareTooltip formats the name, cost and share of the total of a node or
+// flow
+func shareTooltip(unit string, total float64) string {
+	return fmt.Sprintf(`function (p) {
+	var share = %g > 0 ? (p.value / %g * 100).toFixed(1) + '%%' : '-';
+	return p.name + '<br/>' + p.value.toFixed(2) + ' %s (' + share + ')';
+}`, total, total, strings.ReplaceAll(unit, "'", ""))
+}
+
+// buildTreemapChart nests the keys of the second level in those of the
+// first; clicking a node zooms into it and the breadcrumb zooms out
+func buildTreemapChart(r cc.InputType, h Hierarchy) *charts.TreeMap {
+	treemap := charts.NewTreeMap()
+	treemap.SetGlobalOptions(hierarchyChartOptions(r, h, "Treemap")...)
+
+	series := charts.SingleSeries{Name: h.Metric, Type: echartstypes.ChartTreeMap, Data: h.Nodes}
+	series.ConfigureSeriesOpts(charts.WithTreeMapOpts(opts.TreeMapChart{
+		Animation:  opts.Bool(true),
+		Roam:       opts.Bool(false),
+		UpperLabel: &opts.UpperLabel{Show: opts.Bool(true)},
+	}))
+	treemap.MultiSeries = append(treemap.MultiSeries, series)
+	return treemap
+}
+
+// buildSunburstChart draws the first level in the inner ring; clicking a
+// node makes it the centre and clicking the centre goes back up
+func buildSunburstChart(r cc.InputType, h Hierarchy) *charts.Sunburst {
+	sunburst := charts.NewSunburst()
+	sunburst.SetGlobalOptions(hierarchyChartOptions(r, h, "Sunburst")...)
+
+	series := charts.SingleSeries{Name: h.Metric, Type: echartstypes.ChartSunburst, Data: h.Nodes}
+	series.ConfigureSeriesOpts(
+		charts.WithSunburstOpts(opts.SunburstChart{NodeClick: "rootToNode", Animation: opts.Bool(true)}),
+		charts.WithLabelOpts(opts.Label{Show: opts.Bool(true), Formatter: "{b}"}),
+	)
+	sunburst.MultiSeries = append(sunburst.MultiSeries, series)
+	return sunburst
+}
+
+// sankeyDrillDown shows only the flows into and out of a clicked node;
+// clicking the node again or another element restores every flow
+const sankeyDrillDown = `(function (chart) {
+	var series = chart.getOption().series[0];
+	var nodes = series.data, links = series.links, focus = null;
+	var reach = function (from, forward) {
+		var found = new Set([from]), grew = true;
+		while (grew) {
+			grew = false;
+			links.forEach(function (l) {
+				var a = forward ? l.source : l.target, b = forward ? l.target : l.source;
+				if (found.has(a) && !found.has(b)) { found.add(b); grew = true; }
+			});
+		}
+		return found;
+	};
+	chart.on('click', function (p) {
+		if (p.dataType !== 'node' || p.name === focus) {
+			focus = null;
+			chart.setOption({ series: [{ data: nodes, links: links }] });
+			return;
+		}
+		focus = p.name;
+		var down = reach(focus, true), up = reach(focus, false);
+		chart.setOption({ series: [{
+			data: nodes.filter(function (n) { return down.has(n.name) || up.has(n.name); }),
+			links: links.filter(function (l) {
+				return (down.has(l.source) && down.has(l.target)) || (up.has(l.source) && up.has(l.target));
+			})
+		}] });
+	});
+})(%MY_ECHARTS%);`
+
+// buildSankeyChart draws the flows from the keys of each level to those of
+// the next, such as LINKED_ACCOUNT to SERVICE to USAGE_TYPE
+func buildSankeyChart(r cc.InputType, h Hierarchy) *charts.Sankey {
+	names, flows := h.Sankey()
+	nodes := make([]opts.SankeyNode, len(names))
+	for i, name := range names {
+		nodes[i] = opts.SankeyNode{Name: name}
+	}
+	links := make([]opts.SankeyLink, len(flows))
+	for i, f := range flows {
+		links[i] = opts.SankeyLink{Source: f.Source, Target: f.Target, Value: float32(roundCents(f.Value))}
+	}
+
+	sankey := charts.NewSankey()
+	sankey.SetGlobalOptions(hierarchyChartOptions(r, h, "Sankey")...)
+	sankey.AddSeries(h.Metric, nodes, links,
+		charts.WithLabelOpts(opts.Label{Show: opts.Bool(true)}),
+		charts.WithLineStyleOpts(opts.LineStyle{Color: "source", Curveness: 0.5, Opacity: 0.4}),
+	)
+	sankey.AddJSFuncs(sankeyDrillDown)
+	return sankey
+}
@@ -33,8 +33,7 @@ func NewCSVWriter(f io.Writer, header []string) (*csv.Writer, error) {
 }
 
 func NewCSVFile(dir string, file string) (*os.File, error) {
-	path := utils.BuildOutputFilePath(dir, file)
-	f, err := os.Create(path)
+	f, err := utils.NewFile(dir, file)
 	if err != nil {
 		return nil, types.Error{
 			Msg: "Error creating CSV file: " + err.Error()}
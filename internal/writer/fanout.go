@@ -0,0 +1,118 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+)
+
+// fileOutput is implemented by outputs that may be written to a file
+type fileOutput interface {
+	// OutputFile returns the file written, or "" for stdout
+	OutputFile() string
+}
+
+// OutputFile implements fileOutput for CSV output
+func (o *CSVOutput) OutputFile() string { return outputFile(o.Filename) }
+
+// OutputFile implements fileOutput for chart output
+func (o *ChartOutput) OutputFile() string { return outputFile(o.Filename) }
+
+// OutputFile implements fileOutput for JSON output
+func (o *JSONOutput) OutputFile() string { return outputFile(o.Filename) }
+
+// OutputFile implements fileOutput for Markdown output
+func (o *MarkdownOutput) OutputFile() string { return outputFile(o.Filename) }
+
+// OutputFile implements fileOutput for XLSX output
+func (o *XLSXOutput) OutputFile() string { return outputFile(o.Filename) }
+
+func outputFile(path string) string {
+	if path == Stdout {
+		return ""
+	}
+	return path
+}
+
+// writeFile transforms and renders input, and returns the file written, if
+// any
+func writeFile[TInput, TOutput any](w *CompositeWriter[TInput, TOutput], input TInput) (string, error) {
+	output, err := w.Transform(input)
+	if err != nil {
+		return "", err
+	}
+	if err := w.Render(output); err != nil {
+		return "", err
+	}
+	if f, ok := any(output).(fileOutput); ok {
+		return f.OutputFile(), nil
+	}
+	return "", nil
+}
+
+// costUsageWriteFunc returns the function writing a report in a print format
+func costUsageWriteFunc(format, sortBy string) func(types.CostAndUsageOutputType) (string, error) {
+	return func(report types.CostAndUsageOutputType) (string, error) {
+		switch utils.ToPrintWriterType(format) {
+		case types.CSV:
+			return writeFile(NewCostUsageCSVWriter(sortBy), report)
+		case types.Chart:
+			return writeFile(NewCostUsageChartWriter(sortBy), report)
+		case types.Pinecone, types.Vector:
+			return writeFile(NewCostUsageVectorWriter(), report)
+		case types.JSON:
+			return writeFile(NewCostUsageJSONWriter(sortBy, false), report)
+		case types.NDJSON:
+			return writeFile(NewCostUsageJSONWriter(sortBy, true), report)
+		case types.Markdown:
+			return writeFile(NewCostUsageMarkdownWriter(sortBy), report)
+		case types.XLSX:
+			return writeFile(NewCostUsageXLSXWriter(sortBy), report)
+		default:
+			return writeFile(NewCostUsageTableWriter(sortBy), report)
+		}
+	}
+}
+
+// WriteCostAndUsage writes one report in each print format concurrently and
+// returns the files written, in the order of the formats. The errors of all
+// formats are joined. With several formats only the table is written to
+// stdout, and json and markdown go to files.
+func WriteCostAndUsage(formats []string, sortBy string,
+	report types.CostAndUsageOutputType) ([]string, error) {
+
+	if len(formats) > 1 && report.Output.Dir == "" {
+		report.Output.Dir = DefaultOutputDir
+	}
+
+	files := make([]string, len(formats))
+	errs := make([]error, len(formats))
+	var wg sync.WaitGroup
+	for i, format := range formats {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			file, err := costUsageWriteFunc(format, sortBy)(report)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", format, err)
+				return
+			}
+			files[i] = file
+		}()
+	}
+	wg.Wait()
+
+	var written []string
+	for _, file := range files {
+		if file != "" {
+			written = append(written, file)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return written, types.Error{Msg: "Error writing report. " + err.Error()}
+	}
+	return written, nil
+}
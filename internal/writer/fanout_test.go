@@ -0,0 +1,38 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCostAndUsage(t *testing.T) {
+	report := costReportInput()
+	report.Output = types.OutputConfig{Dir: t.TempDir()}
+
+	files, err := WriteCostAndUsage([]string{"csv", "json", "ndjson"}, "cost", report)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for i, ext := range []string{".csv", ".json", ".ndjson"} {
+		assert.Equal(t, report.Output.Dir, filepath.Dir(files[i]))
+		assert.True(t, strings.HasSuffix(files[i], ext), files[i])
+		assert.FileExists(t, files[i])
+	}
+}
+
+func TestWriteCostAndUsageErrors(t *testing.T) {
+	notDir := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(notDir, nil, 0600))
+	report := costReportInput()
+	report.Output = types.OutputConfig{Dir: notDir}
+
+	files, err := WriteCostAndUsage([]string{"csv", "json"}, "cost", report)
+	assert.Empty(t, files)
+	assert.ErrorContains(t, err, "csv: Error creating CSV file")
+	assert.ErrorContains(t, err, "json: Error creating JSON file")
+}
@@ -0,0 +1,238 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// ForecastPoint holds the actual and forecast costs of one period. Mean,
+// Lower and Upper include the actual cost of a period that is partly past,
+// such as the current month of a monthly forecast.
+type ForecastPoint struct {
+	Period      string
+	Actual      float64
+	Mean        float64
+	Lower       float64
+	Upper       float64
+	HasActual   bool
+	HasForecast bool
+}
+
+// NewForecastPoints joins the actual costs of the month with the forecast,
+// one point per period in date order. Monthly periods are keyed by month, so
+// that the month to date and the rest of the month forecast add up. The last
+// actual point before the forecast also starts the forecast, so that the
+// lines join.
+func NewForecastPoints(input types.ForecastPrintData) (points []ForecastPoint, unit string) {
+	key := func(start string) string {
+		if input.Request.Granularity == "MONTHLY" && len(start) >= 7 {
+			return start[:7]
+		}
+		return start
+	}
+
+	byPeriod := map[string]*ForecastPoint{}
+	point := func(start string) *ForecastPoint {
+		k := key(start)
+		if p, ok := byPeriod[k]; ok {
+			return p
+		}
+		p := &ForecastPoint{Period: k}
+		byPeriod[k] = p
+		return p
+	}
+
+	for _, a := range input.Actuals {
+		p := point(a.TimePeriod.Start)
+		p.Actual += a.Amount
+		p.HasActual = true
+		unit = a.Unit
+	}
+	report := NewForecastReport(input, time.Time{})
+	if report.Total.Unit != "" {
+		unit = report.Total.Unit
+	}
+	for _, r := range report.Periods {
+		p := point(r.Start)
+		p.Mean += r.Mean
+		p.Lower += r.LowerBound
+		p.Upper += r.UpperBound
+		p.HasForecast = true
+	}
+
+	for _, p := range byPeriod {
+		points = append(points, *p)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Period < points[j].Period })
+
+	for i := range points {
+		p := &points[i]
+		if p.HasActual && p.HasForecast {
+			p.Mean += p.Actual
+			p.Lower += p.Actual
+			p.Upper += p.Actual
+		}
+	}
+	for i := 1; i < len(points); i++ {
+		prev := &points[i-1]
+		if points[i].HasForecast && !points[i].HasActual && prev.HasActual && !prev.HasForecast {
+			prev.Mean, prev.Lower, prev.Upper = prev.Actual, prev.Actual, prev.Actual
+			prev.HasForecast = true
+		}
+	}
+	return points, unit
+}
+
+// ForecastToCSVTransformer transforms forecasts to CSV rows, followed by
+// the total
+type ForecastToCSVTransformer struct{}
+
+// NewForecastToCSVTransformer creates a new transformer for forecast CSV output
+func NewForecastToCSVTransformer() *ForecastToCSVTransformer {
+	return &ForecastToCSVTransformer{}
+}
+
+// Transform implements the Transformer interface for forecast CSV output
+func (t *ForecastToCSVTransformer) Transform(input types.ForecastPrintData) (*CSVOutput, error) {
+	report := NewForecastReport(input, time.Time{})
+	headers := []string{"Start", "End", "Mean Value", "Prediction Interval LowerBound",
+		"Prediction Interval UpperBound", "Unit"}
+
+	format := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	var rows [][]string
+	for _, r := range report.Periods {
+		rows = append(rows, []string{r.Start, r.End, format(r.Mean),
+			format(r.LowerBound), format(r.UpperBound), r.Unit})
+	}
+	rows = append(rows, []string{"Total", "", format(report.Total.Amount), "", "", report.Total.Unit})
+
+	filename := OutputPath(input.Output, forecastFileNameFields(input, time.Now()), ".csv", false)
+	return NewCSVOutput(headers, rows, filename), nil
+}
+
+// ForecastToChartTransformer transforms forecasts to a chart of the actual
+// costs of the month, the forecast mean and its prediction interval
+type ForecastToChartTransformer struct{}
+
+// NewForecastToChartTransformer creates a new transformer for forecast chart output
+func NewForecastToChartTransformer() *ForecastToChartTransformer {
+	return &ForecastToChartTransformer{}
+}
+
+// Transform implements the Transformer interface for forecast chart output
+func (t *ForecastToChartTransformer) Transform(input types.ForecastPrintData) (*ChartOutput, error) {
+	page := components.NewPage()
+	page.PageTitle = "Cost Forecast"
+	page.AddCharts(buildForecastChart(input))
+
+	filename := OutputPath(input.Output, forecastFileNameFields(input, time.Now()), ".html", false)
+	return NewChartOutput(page, "Cost Forecast", filename), nil
+}
+
+// forecastTooltip lists the actual and forecast costs of a period, and the
+// bounds of the prediction interval, which is drawn as the interval width
+// stacked on the lower bound
+const forecastTooltip = `function (params) {
+	var lines = [params[0].axisValue], lower = null;
+	params.forEach(function (p) {
+		if (typeof p.value !== 'number') { return; }
+		if (p.seriesName === 'Lower bound') { lower = p.value; return; }
+		if (p.seriesName === 'Prediction interval') {
+			if (lower !== null) { lines.push(p.marker + p.seriesName + ': ' + lower.toFixed(2) + ' - ' + (lower + p.value).toFixed(2)); }
+			return;
+		}
+		lines.push(p.marker + p.seriesName + ': ' + p.value.toFixed(2));
+	});
+	return lines.join('<br/>');
+}`
+
+func buildForecastChart(input types.ForecastPrintData) *charts.Line {
+	points, unit := NewForecastPoints(input)
+
+	periods := make([]string, len(points))
+	var actual, mean, lower, interval []opts.LineData
+	value := func(ok bool, v float64) opts.LineData {
+		if !ok {
+			return opts.LineData{Value: "-"}
+		}
+		return opts.LineData{Value: roundCents(v)}
+	}
+	for i, p := range points {
+		periods[i] = p.Period
+		actual = append(actual, value(p.HasActual, p.Actual))
+		mean = append(mean, value(p.HasForecast, p.Mean))
+		lower = append(lower, value(p.HasForecast, p.Lower))
+		interval = append(interval, value(p.HasForecast, p.Upper-p.Lower))
+	}
+
+	subtitle := fmt.Sprintf("Granularity: %s Start: %s, End: %s, Prediction interval: %d%%",
+		input.Request.Granularity, input.Request.Time.Start, input.Request.Time.End,
+		input.Request.PredictionIntervalLevel)
+	if len(input.Filters) > 0 {
+		subtitle += ", Filtered by: " + strings.Join(input.Filters, " | ")
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{
+			Title:    fmt.Sprintf("Cost forecast (%s)", unit),
+			Subtitle: subtitle,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      opts.Bool(true),
+			Trigger:   "axis",
+			Formatter: opts.FuncOpts(forecastTooltip),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: opts.Bool(true),
+			Top:  "bottom",
+			Data: []string{"Actual", "Forecast", "Prediction interval"},
+		}),
+		charts.WithDataZoomOpts(
+			opts.DataZoom{Type: "slider", Start: 0, End: 100},
+			opts.DataZoom{Type: "inside", Start: 0, End: 100},
+		),
+		charts.WithInitializationOpts(opts.Initialization{Width: "1200px", Height: "600px"}),
+	)
+	line.SetXAxis(periods)
+
+	hidden := opts.LineStyle{Color: "transparent"}
+	line.AddSeries("Lower bound", lower,
+		charts.WithLineChartOpts(opts.LineChart{Stack: "interval", ShowSymbol: opts.Bool(false)}),
+		charts.WithLineStyleOpts(hidden))
+	line.AddSeries("Prediction interval", interval,
+		charts.WithLineChartOpts(opts.LineChart{Stack: "interval", ShowSymbol: opts.Bool(false)}),
+		charts.WithLineStyleOpts(hidden),
+		charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: 0.25}))
+	line.AddSeries("Actual", actual)
+
+	forecastOptions := []charts.SeriesOpts{
+		charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed"}),
+	}
+	if input.Budget > 0 {
+		// keep the budget in view when it is above every forecast
+		line.SetGlobalOptions(charts.WithYAxisOpts(opts.YAxis{
+			Max: opts.FuncOpts(fmt.Sprintf(
+				"function (value) { return Math.ceil(Math.max(value.max, %g) * 1.1); }", input.Budget)),
+		}))
+		forecastOptions = append(forecastOptions,
+			charts.WithMarkLineNameYAxisItemOpts(opts.MarkLineNameYAxisItem{
+				Name:  "Budget",
+				YAxis: input.Budget,
+			}),
+			charts.WithMarkLineStyleOpts(opts.MarkLineStyle{
+				Symbol:    []string{"none", "none"},
+				Label:     &opts.Label{Show: opts.Bool(true), Formatter: "Budget {c}"},
+				LineStyle: &opts.LineStyle{Color: "#c23531", Type: "solid", Width: 2},
+			}))
+	}
+	line.AddSeries("Forecast", mean, forecastOptions...)
+	return line
+}
@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func forecastInput(granularity string, periods ...[2]string) types.ForecastPrintData {
+	input := types.ForecastPrintData{
+		Forecast: &costexplorer.GetCostForecastOutput{
+			Total: &cetypes.MetricValue{Amount: aws.String("60"), Unit: aws.String("USD")},
+		},
+		Request: types.GetCostForecastRequest{
+			Time:                    types.Time{Start: periods[0][0], End: periods[len(periods)-1][1]},
+			Granularity:             granularity,
+			Metric:                  "UNBLENDED_COST",
+			PredictionIntervalLevel: 80,
+		},
+	}
+	for _, p := range periods {
+		input.Forecast.ForecastResultsByTime = append(input.Forecast.ForecastResultsByTime, cetypes.ForecastResult{
+			TimePeriod:                   &cetypes.DateInterval{Start: aws.String(p[0]), End: aws.String(p[1])},
+			MeanValue:                    aws.String("30"),
+			PredictionIntervalLowerBound: aws.String("20"),
+			PredictionIntervalUpperBound: aws.String("45"),
+		})
+	}
+	return input
+}
+
+func TestNewForecastPointsMonthly(t *testing.T) {
+	input := forecastInput("MONTHLY", [2]string{"2024-03-20", "2024-04-01"}, [2]string{"2024-04-01", "2024-05-01"})
+	input.Actuals = []types.ForecastActual{
+		{TimePeriod: types.DateInterval{Start: "2024-03-01", End: "2024-03-20"}, Amount: 50, Unit: "USD"},
+	}
+
+	points, unit := NewForecastPoints(input)
+	assert.Equal(t, "USD", unit)
+	assert.Equal(t, []ForecastPoint{
+		{Period: "2024-03", Actual: 50, Mean: 80, Lower: 70, Upper: 95, HasActual: true, HasForecast: true},
+		{Period: "2024-04", Mean: 30, Lower: 20, Upper: 45, HasForecast: true},
+	}, points, "the month to date is added to the forecast of the rest of the month")
+}
+
+func TestNewForecastPointsDaily(t *testing.T) {
+	input := forecastInput("DAILY", [2]string{"2024-03-03", "2024-03-04"})
+	input.Actuals = []types.ForecastActual{
+		{TimePeriod: types.DateInterval{Start: "2024-03-01", End: "2024-03-02"}, Amount: 25, Unit: "USD"},
+		{TimePeriod: types.DateInterval{Start: "2024-03-02", End: "2024-03-03"}, Amount: 28, Unit: "USD"},
+	}
+
+	points, _ := NewForecastPoints(input)
+	assert.Equal(t, []ForecastPoint{
+		{Period: "2024-03-01", Actual: 25, HasActual: true},
+		{Period: "2024-03-02", Actual: 28, Mean: 28, Lower: 28, Upper: 28, HasActual: true, HasForecast: true},
+		{Period: "2024-03-03", Mean: 30, Lower: 20, Upper: 45, HasForecast: true},
+	}, points, "the forecast starts from the last actual cost")
+}
+
+func TestForecastOutputs(t *testing.T) {
+	input := forecastInput("MONTHLY", [2]string{"2024-03-01", "2024-04-01"}, [2]string{"2024-04-01", "2024-05-01"})
+	input.Output.Path = Stdout
+
+	csvOutput, err := NewForecastToCSVTransformer().Transform(input)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-03-01", "2024-04-01", "30.00", "20.00", "45.00", "USD"}, csvOutput.Rows[0],
+		"the lower bound precedes the upper bound")
+	assert.Equal(t, []string{"Total", "", "60.00", "", "", "USD"}, csvOutput.Rows[2])
+
+	input.Budget = 40
+	chart, err := NewForecastToChartTransformer().Transform(input)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, (&ChartRenderer{stdout: &buf}).Render(chart))
+	assert.Contains(t, buf.String(), `"name":"Prediction interval"`)
+	assert.Contains(t, buf.String(), `"name":"Budget","yAxis":40`)
+}
+
+func TestForecastToRows(t *testing.T) {
+	rows := ForecastToRows(types.ForecastStdoutType{Forecast: []types.ForecastResults{{
+		MeanValue:                    "30",
+		PredictionIntervalLowerBound: "20",
+		PredictionIntervalUpperBound: "45",
+		TimePeriod:                   types.DateInterval{Start: "2024-03-01", End: "2024-04-01"},
+	}}})
+	assert.Equal(t, "20", rows[0][3], "the lower bound is under its header")
+	assert.Equal(t, "45", rows[0][4])
+}
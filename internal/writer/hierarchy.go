@@ -0,0 +1,135 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// HierarchyNode holds the total of a key and of the keys grouped under it.
+// It marshals as the data of echarts treemap and sunburst series.
+type HierarchyNode struct {
+	Name     string           `json:"name"`
+	Value    float64          `json:"value"`
+	Children []*HierarchyNode `json:"children,omitempty"`
+}
+
+// Hierarchy holds the totals of the first requested metric as a tree with
+// one level per key, such as SERVICE and then USAGE_TYPE. Nodes are sorted by
+// value in descending order.
+type Hierarchy struct {
+	Metric string
+	Unit   string
+	// Labels name the levels of the tree
+	Labels []string
+	Nodes  []*HierarchyNode
+	Total  float64
+}
+
+// SankeyFlow is the total flowing from a key of one level to a key of the
+// next level
+type SankeyFlow struct {
+	Source string
+	Target string
+	Value  float64
+}
+
+// NewHierarchy builds the tree of the first requested metric, summed over
+// the periods. When topN is positive, the children of a node beyond its topN
+// largest are summed into an Other node.
+func NewHierarchy(r types.InputType, topN int) Hierarchy {
+	p := newPivot(r.Services, groupLabels(r.Dimensions, r.Tags),
+		pivotMetric(r.Metrics, r.Services))
+	h := Hierarchy{Metric: p.Metric, Unit: p.Unit, Labels: p.Labels, Total: p.Total}
+
+	root := &HierarchyNode{}
+	for _, row := range p.Rows {
+		node := root
+		node.Value += row.Total
+		for _, key := range row.Keys {
+			node = node.child(key)
+			node.Value += row.Total
+		}
+	}
+	root.sort(topN)
+	h.Nodes = root.Children
+	return h
+}
+
+func (n *HierarchyNode) child(name string) *HierarchyNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	c := &HierarchyNode{Name: name}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+func (n *HierarchyNode) sort(topN int) {
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		return n.Children[i].Value > n.Children[j].Value
+	})
+	if topN > 0 && len(n.Children) > topN {
+		other := &HierarchyNode{Name: OtherName}
+		for _, c := range n.Children[topN:] {
+			other.Value += c.Value
+		}
+		n.Children = append(n.Children[:topN], other)
+	}
+	for _, c := range n.Children {
+		c.sort(topN)
+	}
+}
+
+// Sankey returns the nodes of each level and the flows between levels. A
+// name found on more than one level is suffixed with the level label, as
+// Sankey node names must be unique.
+func (h Hierarchy) Sankey() (nodes []string, flows []SankeyFlow) {
+	depths := map[string]map[int]bool{}
+	var visit func(children []*HierarchyNode, depth int)
+	visit = func(children []*HierarchyNode, depth int) {
+		for _, c := range children {
+			if depths[c.Name] == nil {
+				depths[c.Name] = map[int]bool{}
+			}
+			depths[c.Name][depth] = true
+			visit(c.Children, depth+1)
+		}
+	}
+	visit(h.Nodes, 0)
+
+	name := func(n *HierarchyNode, depth int) string {
+		if len(depths[n.Name]) > 1 {
+			return fmt.Sprintf("%s (%s)", n.Name, groupLabel(h.Labels, depth))
+		}
+		return n.Name
+	}
+
+	seen := map[string]bool{}
+	index := map[[2]string]int{}
+	var link func(parent *HierarchyNode, depth int)
+	link = func(parent *HierarchyNode, depth int) {
+		source := name(parent, depth)
+		if !seen[source] {
+			seen[source] = true
+			nodes = append(nodes, source)
+		}
+		for _, c := range parent.Children {
+			target := name(c, depth+1)
+			if i, ok := index[[2]string{source, target}]; ok {
+				flows[i].Value += c.Value
+			} else {
+				index[[2]string{source, target}] = len(flows)
+				flows = append(flows, SankeyFlow{Source: source, Target: target, Value: c.Value})
+			}
+			link(c, depth+1)
+		}
+	}
+	for _, n := range h.Nodes {
+		link(n, 0)
+	}
+	return nodes, flows
+}
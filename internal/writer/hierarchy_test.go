@@ -0,0 +1,76 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func hierarchyInput() types.InputType {
+	service := func(account, name, usage string, amount float64) types.Service {
+		return types.Service{
+			Start:   "2024-01-01",
+			Keys:    []string{account, name, usage},
+			Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: amount, Unit: "USD"}},
+		}
+	}
+	return types.InputType{
+		Dimensions: []string{"LINKED_ACCOUNT", "SERVICE", "USAGE_TYPE"},
+		Services: []types.Service{
+			service("111", "Amazon EC2", "BoxUsage", 30),
+			service("111", "Amazon S3", "TimedStorage", 10),
+			service("222", "Amazon EC2", "BoxUsage", 15),
+			service("222", "Amazon EC2", "EBS:VolumeUsage", 5),
+			service("222", "AWS Lambda", "Request", 1),
+		},
+	}
+}
+
+func TestNewHierarchy(t *testing.T) {
+	h := NewHierarchy(hierarchyInput(), 0)
+	assert.Equal(t, "UnblendedCost", h.Metric)
+	assert.Equal(t, "USD", h.Unit)
+	assert.InDelta(t, 61, h.Total, 1e-9)
+	assert.Equal(t, []*HierarchyNode{
+		{Name: "111", Value: 40, Children: []*HierarchyNode{
+			{Name: "Amazon EC2", Value: 30, Children: []*HierarchyNode{{Name: "BoxUsage", Value: 30}}},
+			{Name: "Amazon S3", Value: 10, Children: []*HierarchyNode{{Name: "TimedStorage", Value: 10}}},
+		}},
+		{Name: "222", Value: 21, Children: []*HierarchyNode{
+			{Name: "Amazon EC2", Value: 20, Children: []*HierarchyNode{
+				{Name: "BoxUsage", Value: 15},
+				{Name: "EBS:VolumeUsage", Value: 5},
+			}},
+			{Name: "AWS Lambda", Value: 1, Children: []*HierarchyNode{{Name: "Request", Value: 1}}},
+		}},
+	}, h.Nodes)
+
+	h = NewHierarchy(hierarchyInput(), 1)
+	assert.Equal(t, []*HierarchyNode{
+		{Name: "111", Value: 40, Children: []*HierarchyNode{
+			{Name: "Amazon EC2", Value: 30, Children: []*HierarchyNode{{Name: "BoxUsage", Value: 30}}},
+			{Name: OtherName, Value: 10},
+		}},
+		{Name: OtherName, Value: 21},
+	}, h.Nodes, "the children beyond the top N are summed as Other")
+}
+
+func TestHierarchySankey(t *testing.T) {
+	input := hierarchyInput()
+	input.Services = append(input.Services, types.Service{
+		Start:   "2024-01-01",
+		Keys:    []string{"333", "BoxUsage", "BoxUsage"},
+		Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: 2, Unit: "USD"}},
+	})
+
+	nodes, flows := NewHierarchy(input, 0).Sankey()
+	assert.Equal(t, []string{"111", "Amazon EC2", "BoxUsage (USAGE_TYPE)", "Amazon S3", "TimedStorage",
+		"222", "EBS:VolumeUsage", "AWS Lambda", "Request", "333", "BoxUsage (SERVICE)"}, nodes,
+		"names found on two levels are suffixed with the level")
+	assert.Contains(t, flows, SankeyFlow{Source: "Amazon EC2", Target: "BoxUsage (USAGE_TYPE)", Value: 45},
+		"flows between the same keys are summed")
+	assert.Contains(t, flows, SankeyFlow{Source: "333", Target: "BoxUsage (SERVICE)", Value: 2})
+	assert.Contains(t, flows, SankeyFlow{Source: "BoxUsage (SERVICE)", Target: "BoxUsage (USAGE_TYPE)", Value: 2})
+	assert.Len(t, flows, 10)
+}
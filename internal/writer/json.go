@@ -0,0 +1,312 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+)
+
+// CostUsageToJSONTransformer transforms cost and usage data to the
+// documented JSON report
+type CostUsageToJSONTransformer struct {
+	sortBy string
+	ndjson bool
+	now    func() time.Time
+}
+
+// NewCostUsageToJSONTransformer creates a transformer writing a JSON
+// document, or one row per line when ndjson is set
+func NewCostUsageToJSONTransformer(sortBy string, ndjson bool) *CostUsageToJSONTransformer {
+	return &CostUsageToJSONTransformer{sortBy: sortBy, ndjson: ndjson, now: time.Now}
+}
+
+// Transform implements the Transformer interface for JSON output
+func (t *CostUsageToJSONTransformer) Transform(input types.CostAndUsageOutputType) (*JSONOutput, error) {
+	report := NewCostReport(input, t.sortBy, t.now())
+	ext := ".json"
+	if t.ndjson {
+		ext = ".ndjson"
+	}
+	output := &JSONOutput{NDJSON: t.ndjson}
+	output.Filename = OutputPath(input.Output, costUsageFileNameFields(input, t.now()), ext, true)
+	if !t.ndjson {
+		output.Values = []any{report}
+		return output, nil
+	}
+
+	for _, row := range report.Services {
+		row.Schema = report.Schema
+		row.Granularity = report.Request.Granularity
+		output.Values = append(output.Values, row)
+	}
+	return output, nil
+}
+
+// NewCostReport converts cost and usage data into the JSON report. Rows are
+// sorted by the first metric, or by date when sortBy is date, and then by
+// period and keys so that the order is stable.
+func NewCostReport(input types.CostAndUsageOutputType, sortBy string,
+	generatedAt time.Time) types.CostReport {
+
+	groupBy := groupLabels(input.Dimensions, input.Tags)
+	if sortBy != "date" {
+		sortBy = "cost"
+	}
+
+	report := types.CostReport{
+		Schema:      types.CostReportSchema,
+		GeneratedAt: generatedAt.UTC().Format(time.RFC3339),
+		Request: types.CostReportRequest{
+			Start:            input.Start,
+			End:              input.End,
+			Granularity:      input.Granularity,
+			GroupBy:          groupBy,
+			Metrics:          nonNil(input.Metrics),
+			Filters:          nonNil(input.Filters),
+			ExcludeDiscounts: input.ExcludeDiscounts,
+			SortBy:           sortBy,
+		},
+		Services: []types.CostReportRow{},
+		Totals:   []types.ReportAmount{},
+	}
+
+	totals := map[string]*types.ReportAmount{}
+	for _, s := range utils.ConvertMapToSlice(input.Services) {
+		row := types.CostReportRow{
+			Start: s.Start,
+			End:   s.End,
+			Keys:  nonNil(s.Keys),
+			Group: make(map[string]string, len(s.Keys)),
+		}
+		for i, key := range s.Keys {
+			label := groupLabel(groupBy, i)
+			row.Group[label] = groupValue(label, key)
+		}
+
+		for _, m := range s.Metrics {
+			row.Metrics = append(row.Metrics, types.ReportAmount{
+				Metric: m.Name, Amount: m.NumericAmount, Unit: m.Unit,
+			})
+			total, ok := totals[m.Name]
+			if !ok {
+				total = &types.ReportAmount{Metric: m.Name, Unit: m.Unit}
+				totals[m.Name] = total
+			}
+			total.Amount += m.NumericAmount
+		}
+		sortAmounts(row.Metrics, input.Metrics)
+		report.Services = append(report.Services, row)
+	}
+
+	for _, total := range totals {
+		total.Amount = math.Round(total.Amount*1e8) / 1e8
+		report.Totals = append(report.Totals, *total)
+	}
+	sortAmounts(report.Totals, input.Metrics)
+	sortRows(report.Services, sortBy)
+	return report
+}
+
+// groupLabels names the keys of the results: dimensions such as SERVICE,
+// and tags as TAG:<key>
+func groupLabels(dimensions, tags []string) []string {
+	labels := append([]string{}, dimensions...)
+	for _, t := range tags {
+		labels = append(labels, "TAG:"+t)
+	}
+	return labels
+}
+
+// groupLabel returns the label of the i-th key, or KEY<n> when the request
+// does not name it
+func groupLabel(labels []string, i int) string {
+	if i < len(labels) {
+		return labels[i]
+	}
+	return fmt.Sprintf("KEY%d", i+1)
+}
+
+// groupValue strips the <key>$ prefix Cost Explorer adds to tag values
+func groupValue(label, key string) string {
+	if tag, ok := strings.CutPrefix(label, "TAG:"); ok {
+		return strings.TrimPrefix(key, tag+"$")
+	}
+	return key
+}
+
+// sortAmounts orders amounts as the requested metrics, then by name
+func sortAmounts(amounts []types.ReportAmount, metrics []string) {
+	rank := func(name string) int {
+		if i := slices.Index(metrics, name); i >= 0 {
+			return i
+		}
+		return len(metrics)
+	}
+	sort.SliceStable(amounts, func(i, j int) bool {
+		if ri, rj := rank(amounts[i].Metric), rank(amounts[j].Metric); ri != rj {
+			return ri < rj
+		}
+		return amounts[i].Metric < amounts[j].Metric
+	})
+}
+
+func sortRows(rows []types.CostReportRow, sortBy string) {
+	amount := func(r types.CostReportRow) float64 {
+		if len(r.Metrics) == 0 {
+			return 0
+		}
+		return r.Metrics[0].Amount
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if sortBy == "date" && a.Start != b.Start {
+			return a.Start > b.Start
+		}
+		if amount(a) != amount(b) {
+			return amount(a) > amount(b)
+		}
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return slices.Compare(a.Keys, b.Keys) < 0
+	})
+}
+
+// ForecastToJSONTransformer transforms forecasts to the documented JSON
+// report
+type ForecastToJSONTransformer struct {
+	ndjson bool
+	now    func() time.Time
+}
+
+// NewForecastToJSONTransformer creates a transformer writing a JSON
+// document, or one period per line when ndjson is set
+func NewForecastToJSONTransformer(ndjson bool) *ForecastToJSONTransformer {
+	return &ForecastToJSONTransformer{ndjson: ndjson, now: time.Now}
+}
+
+// Transform implements the Transformer interface for JSON output
+func (t *ForecastToJSONTransformer) Transform(input types.ForecastPrintData) (*JSONOutput, error) {
+	report := NewForecastReport(input, t.now())
+	ext := ".json"
+	if t.ndjson {
+		ext = ".ndjson"
+	}
+	output := &JSONOutput{NDJSON: t.ndjson}
+	output.Filename = OutputPath(input.Output, forecastFileNameFields(input, t.now()), ext, true)
+	if !t.ndjson {
+		output.Values = []any{report}
+		return output, nil
+	}
+
+	for _, period := range report.Periods {
+		period.Schema = report.Schema
+		output.Values = append(output.Values, period)
+	}
+	return output, nil
+}
+
+// NewForecastReport converts a forecast into the JSON report
+func NewForecastReport(input types.ForecastPrintData, generatedAt time.Time) types.ForecastReport {
+	var filters []string
+	for _, d := range input.Request.Filter.Dimensions {
+		filters = append(filters, d.Key+"="+strings.Join(d.Value, ","))
+	}
+
+	report := types.ForecastReport{
+		Schema:      types.ForecastReportSchema,
+		GeneratedAt: generatedAt.UTC().Format(time.RFC3339),
+		Request: types.ForecastReportRequest{
+			Start:                   input.Request.Time.Start,
+			End:                     input.Request.Time.End,
+			Granularity:             input.Request.Granularity,
+			Metric:                  input.Request.Metric,
+			PredictionIntervalLevel: input.Request.PredictionIntervalLevel,
+			Filters:                 nonNil(filters),
+		},
+		Periods: []types.ForecastReportRow{},
+	}
+	if input.Forecast == nil {
+		return report
+	}
+
+	if total := input.Forecast.Total; total != nil {
+		report.Total = types.ReportAmount{
+			Metric: input.Request.Metric,
+			Amount: utils.ConvertToFloat(stringValue(total.Amount)),
+			Unit:   stringValue(total.Unit),
+		}
+	}
+	for _, r := range input.Forecast.ForecastResultsByTime {
+		row := types.ForecastReportRow{
+			Mean:       utils.ConvertToFloat(stringValue(r.MeanValue)),
+			LowerBound: utils.ConvertToFloat(stringValue(r.PredictionIntervalLowerBound)),
+			UpperBound: utils.ConvertToFloat(stringValue(r.PredictionIntervalUpperBound)),
+			Unit:       report.Total.Unit,
+		}
+		if r.TimePeriod != nil {
+			row.Start = stringValue(r.TimePeriod.Start)
+			row.End = stringValue(r.TimePeriod.End)
+		}
+		report.Periods = append(report.Periods, row)
+	}
+	return report
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// nonNil returns s, or an empty slice so that it is encoded as []
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// JSONRenderer writes JSON output to a file or stdout
+type JSONRenderer struct {
+	stdout io.Writer
+}
+
+// NewJSONRenderer creates a new JSON renderer
+func NewJSONRenderer() *JSONRenderer {
+	return &JSONRenderer{stdout: os.Stdout}
+}
+
+// Render implements the Renderer interface for JSON output
+func (r *JSONRenderer) Render(data *JSONOutput) error {
+	w, closeFile, err := createOutput(data.Filename, r.stdout)
+	if err != nil {
+		return types.Error{Msg: "Error creating JSON file: " + err.Error()}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if !data.NDJSON {
+		enc.SetIndent("", "  ")
+	}
+	for _, v := range data.Values {
+		if err := enc.Encode(v); err != nil {
+			closeFile()
+			return types.Error{Msg: "Error writing JSON: " + err.Error()}
+		}
+	}
+	if err := closeFile(); err != nil {
+		return types.Error{Msg: "Error writing JSON: " + err.Error()}
+	}
+	return nil
+}
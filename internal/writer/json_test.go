@@ -0,0 +1,163 @@
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
+	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var generatedAt = time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)
+
+func costReportInput() types.CostAndUsageOutputType {
+	service := func(start, key, tag string, amount float64) types.Service {
+		return types.Service{
+			Start: start,
+			End:   start[:8] + "28",
+			Keys:  []string{key, "Project$" + tag},
+			Metrics: []types.Metrics{
+				{Name: "UsageQuantity", NumericAmount: 1, Unit: "N/A"},
+				{Name: "UnblendedCost", NumericAmount: amount, Unit: "USD"},
+			},
+		}
+	}
+	return types.CostAndUsageOutputType{
+		Services: map[int]types.Service{
+			0: service("2024-01-01", "Amazon S3", "web", 5),
+			1: service("2024-02-01", "Amazon EC2", "web", 20.1),
+			2: service("2024-01-01", "Amazon EC2", "api", 20.1),
+		},
+		Granularity:    "MONTHLY",
+		Start:          "2024-01-01",
+		End:            "2024-03-01",
+		Dimensions:     []string{"SERVICE"},
+		Tags:           []string{"Project"},
+		Metrics:        []string{"UnblendedCost", "UsageQuantity"},
+		Filters:        []string{"REGION=eu-west-1"},
+		OpenAIAPIKey:   "sk-secret",
+		PineconeAPIKey: "pc-secret",
+	}
+}
+
+func TestNewCostReport(t *testing.T) {
+	report := NewCostReport(costReportInput(), "cost", generatedAt)
+
+	assert.Equal(t, types.CostReportSchema, report.Schema)
+	assert.Equal(t, "2024-03-02T10:00:00Z", report.GeneratedAt)
+	assert.Equal(t, types.CostReportRequest{
+		Start:       "2024-01-01",
+		End:         "2024-03-01",
+		Granularity: "MONTHLY",
+		GroupBy:     []string{"SERVICE", "TAG:Project"},
+		Metrics:     []string{"UnblendedCost", "UsageQuantity"},
+		Filters:     []string{"REGION=eu-west-1"},
+		SortBy:      "cost",
+	}, report.Request)
+
+	require.Len(t, report.Services, 3)
+	assert.Equal(t, types.CostReportRow{
+		Start: "2024-01-01",
+		End:   "2024-01-28",
+		Keys:  []string{"Amazon EC2", "Project$api"},
+		Group: map[string]string{"SERVICE": "Amazon EC2", "TAG:Project": "api"},
+		Metrics: []types.ReportAmount{
+			{Metric: "UnblendedCost", Amount: 20.1, Unit: "USD"},
+			{Metric: "UsageQuantity", Amount: 1, Unit: "N/A"},
+		},
+	}, report.Services[0], "equal costs are ordered by period")
+	assert.Equal(t, "2024-02-01", report.Services[1].Start)
+	assert.Equal(t, "Amazon S3", report.Services[2].Group["SERVICE"])
+
+	assert.Equal(t, []types.ReportAmount{
+		{Metric: "UnblendedCost", Amount: 45.2, Unit: "USD"},
+		{Metric: "UsageQuantity", Amount: 3, Unit: "N/A"},
+	}, report.Totals)
+
+	byDate := NewCostReport(costReportInput(), "date", generatedAt)
+	assert.Equal(t, "date", byDate.Request.SortBy)
+	assert.Equal(t, "2024-02-01", byDate.Services[0].Start)
+	assert.Equal(t, "Amazon EC2", byDate.Services[1].Group["SERVICE"])
+}
+
+func TestCostUsageToJSON(t *testing.T) {
+	transformer := NewCostUsageToJSONTransformer("cost", true)
+	transformer.now = func() time.Time { return generatedAt }
+	output, err := transformer.Transform(costReportInput())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, (&JSONRenderer{stdout: &buf}).Render(output))
+	assert.NotContains(t, buf.String(), "secret", "API keys are never written")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	var row map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, types.CostReportSchema, row["schema"])
+	assert.Equal(t, "MONTHLY", row["granularity"])
+	assert.Equal(t, map[string]any{"SERVICE": "Amazon EC2", "TAG:Project": "api"}, row["group"])
+
+	transformer = NewCostUsageToJSONTransformer("cost", false)
+	output, err = transformer.Transform(types.CostAndUsageOutputType{})
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, (&JSONRenderer{stdout: &buf}).Render(output))
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+	assert.Equal(t, []any{}, report["services"], "an empty report has no null arrays")
+	assert.Equal(t, []any{}, report["totals"])
+}
+
+func TestNewForecastReport(t *testing.T) {
+	input := types.ForecastPrintData{
+		Forecast: &costexplorer.GetCostForecastOutput{
+			Total: &cetypes.MetricValue{Amount: aws.String("120.5"), Unit: aws.String("USD")},
+			ForecastResultsByTime: []cetypes.ForecastResult{{
+				TimePeriod:                   &cetypes.DateInterval{Start: aws.String("2024-03-01"), End: aws.String("2024-04-01")},
+				MeanValue:                    aws.String("120.5"),
+				PredictionIntervalLowerBound: aws.String("100"),
+				PredictionIntervalUpperBound: aws.String("140"),
+			}},
+		},
+		Request: types.GetCostForecastRequest{
+			Time:                    types.Time{Start: "2024-03-01", End: "2024-04-01"},
+			Granularity:             "MONTHLY",
+			Metric:                  "UNBLENDED_COST",
+			PredictionIntervalLevel: 95,
+			Filter: types.Filter{Dimensions: []types.Dimension{
+				{Key: "SERVICE", Value: []string{"Amazon EC2", "Amazon S3"}},
+			}},
+		},
+	}
+
+	report := NewForecastReport(input, generatedAt)
+	assert.Equal(t, types.ForecastReport{
+		Schema:      types.ForecastReportSchema,
+		GeneratedAt: "2024-03-02T10:00:00Z",
+		Request: types.ForecastReportRequest{
+			Start:                   "2024-03-01",
+			End:                     "2024-04-01",
+			Granularity:             "MONTHLY",
+			Metric:                  "UNBLENDED_COST",
+			PredictionIntervalLevel: 95,
+			Filters:                 []string{"SERVICE=Amazon EC2,Amazon S3"},
+		},
+		Total: types.ReportAmount{Metric: "UNBLENDED_COST", Amount: 120.5, Unit: "USD"},
+		Periods: []types.ForecastReportRow{{
+			Start:      "2024-03-01",
+			End:        "2024-04-01",
+			Mean:       120.5,
+			LowerBound: 100,
+			UpperBound: 140,
+			Unit:       "USD",
+		}},
+	}, report)
+}
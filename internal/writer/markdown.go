@@ -0,0 +1,90 @@
+package writer
+
+import (
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// MarkdownOutput represents a table formatted as Markdown
+type MarkdownOutput struct {
+	Headers []string
+	Rows    [][]string
+	Footer  []string
+	// Filename is the file written, or - for stdout
+	Filename string
+}
+
+// CostUsageToMarkdownTransformer transforms cost and usage data to a
+// Markdown table in the rows or pivot layout
+type CostUsageToMarkdownTransformer struct {
+	table *CostUsageToTableTransformer
+}
+
+// NewCostUsageToMarkdownTransformer creates a new transformer for Markdown
+// output
+func NewCostUsageToMarkdownTransformer(sortBy string) *CostUsageToMarkdownTransformer {
+	return &CostUsageToMarkdownTransformer{table: NewCostUsageToTableTransformer(sortBy)}
+}
+
+// Transform implements the Transformer interface for Markdown output
+func (t *CostUsageToMarkdownTransformer) Transform(input types.CostAndUsageOutputType) (*MarkdownOutput, error) {
+	data, err := t.table.Transform(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// the rows layout divides the table with empty rows
+	rows := slices.DeleteFunc(slices.Clone(data.Rows), func(row []string) bool {
+		return !slices.ContainsFunc(row, func(cell string) bool { return cell != "" })
+	})
+
+	footer := data.Footer
+	if footer == nil {
+		// the total below the Rounded column, as in the stdout table
+		footer = make([]string, len(data.Headers))
+		footer[4], footer[5] = "Cost", data.Total
+	}
+	return &MarkdownOutput{
+		Headers:  data.Headers,
+		Rows:     rows,
+		Footer:   footer,
+		Filename: OutputPath(input.Output, costUsageFileNameFields(input, time.Now()), ".md", true),
+	}, nil
+}
+
+// MarkdownRenderer writes Markdown tables to a file or stdout
+type MarkdownRenderer struct {
+	stdout io.Writer
+}
+
+// NewMarkdownRenderer creates a new Markdown renderer
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{stdout: os.Stdout}
+}
+
+// Render implements the Renderer interface for Markdown output
+func (r *MarkdownRenderer) Render(data *MarkdownOutput) error {
+	w, closeFile, err := createOutput(data.Filename, r.stdout)
+	if err != nil {
+		return types.Error{Msg: "Error creating Markdown file: " + err.Error()}
+	}
+
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(toTableRow(data.Headers))
+	for _, row := range data.Rows {
+		t.AppendRow(toTableRow(row))
+	}
+	t.AppendFooter(toTableRow(data.Footer))
+	t.RenderMarkdown()
+
+	if err := closeFile(); err != nil {
+		return types.Error{Msg: "Error writing Markdown: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"bytes"
+	_ "embed"
+	"io"
+	"os"
+	"path/filepath"
+
+	cc "github.com/cduggn/ccexplorer/internal/types"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/go-echarts/go-echarts/v2/render"
+)
+
+var (
+	// echartsJS is the echarts library, which charts otherwise load from the
+	// go-echarts assets host
+	//go:embed assets/echarts.min.js
+	echartsJS []byte
+
+	// worldGeoJSON holds the country outlines of the world map
+	//go:embed assets/world.json
+	worldGeoJSON []byte
+)
+
+// offlineRenderer renders a page with its scripts inlined in the head, so
+// that the HTML opens without network access
+type offlineRenderer struct {
+	render.Renderer
+	scripts [][]byte
+}
+
+// Render implements the render.Renderer interface
+func (r offlineRenderer) Render(w io.Writer) error {
+	_, err := w.Write(r.RenderContent())
+	return err
+}
+
+// RenderContent implements the render.Renderer interface
+func (r offlineRenderer) RenderContent() []byte {
+	var head bytes.Buffer
+	for _, script := range r.scripts {
+		head.WriteString("<script type=\"text/javascript\">\n")
+		head.Write(script)
+		head.WriteString("\n</script>\n")
+	}
+	head.WriteString("</head>")
+	return bytes.Replace(r.Renderer.RenderContent(), []byte("</head>"), head.Bytes(), 1)
+}
+
+// embeddedAsset returns the bundled copy of a go-echarts asset inlined in
+// map charts
+func embeddedAsset(asset string) ([]byte, error) {
+	switch asset {
+	case opts.EchartsJS:
+		return echartsJS, nil
+	case worldMapAsset:
+		registerMap := append([]byte("echarts.registerMap('world', "), worldGeoJSON...)
+		return append(registerMap, ");"...), nil
+	}
+	return nil, cc.Error{Msg: "No bundled chart asset " + asset}
+}
+
+// dirAssets reads go-echarts assets from a copy of the assets host in dir
+func dirAssets(dir string) func(string) ([]byte, error) {
+	return func(asset string) ([]byte, error) {
+		script, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(asset)))
+		if err != nil {
+			return nil, cc.Error{Msg: "Failed reading chart asset: " + err.Error()}
+		}
+		return script, nil
+	}
+}
+
+// inlineAssets drops the script assets of the page, which go-echarts loads
+// from its assets host, and inlines the scripts read by read instead, so
+// that the page opens offline
+func inlineAssets(page *components.Page, read func(asset string) ([]byte, error)) error {
+	var scripts [][]byte
+	for _, asset := range page.JSAssets.Values {
+		script, err := read(asset)
+		if err != nil {
+			return err
+		}
+		scripts = append(scripts, script)
+	}
+	page.ClearPresetJSAssets()
+	page.Renderer = offlineRenderer{Renderer: page.Renderer, scripts: scripts}
+	return nil
+}
@@ -0,0 +1,124 @@
+package writer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+const (
+	// DefaultOutputDir holds files written without --output or --outputDir
+	DefaultOutputDir = "./writer"
+	// DefaultFileName is the template of files written without --output
+	DefaultFileName = "ccexplorer_{groupBy}_{start}_{end}_{timestamp}"
+	// Stdout is the --output value that writes to stdout
+	Stdout = "-"
+
+	timestampFormat = "20060102T150405"
+)
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// FileNameFields are the values of the placeholders of a file name template
+type FileNameFields struct {
+	Start     string
+	End       string
+	GroupBy   []string
+	Timestamp time.Time
+}
+
+// ExpandFileName replaces {start}, {end}, {groupBy} and {timestamp} in a
+// file name template. Values are reduced to characters that are safe in file
+// names, so a TAG:Project group becomes TAG_Project.
+func ExpandFileName(template string, f FileNameFields) string {
+	groupBy := "all"
+	if len(f.GroupBy) > 0 {
+		groupBy = strings.Join(f.GroupBy, "-")
+	}
+	return strings.NewReplacer(
+		"{start}", safeFileName(f.Start),
+		"{end}", safeFileName(f.End),
+		"{groupBy}", safeFileName(groupBy),
+		"{timestamp}", f.Timestamp.Format(timestampFormat),
+	).Replace(template)
+}
+
+func safeFileName(s string) string {
+	return unsafeFileNameChars.ReplaceAllString(s, "_")
+}
+
+// OutputPath returns the file a report is written to, or Stdout. A report
+// without --output goes to a generated file with extension ext, or to stdout
+// when toStdout is set and no --outputDir is given.
+func OutputPath(o types.OutputConfig, f FileNameFields, ext string, toStdout bool) string {
+	switch {
+	case o.Path == Stdout:
+		return Stdout
+	case o.Path != "":
+		path := ExpandFileName(o.Path, f)
+		if o.Dir != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(o.Dir, path)
+		}
+		return path
+	case toStdout && o.Dir == "":
+		return Stdout
+	}
+
+	dir := o.Dir
+	if dir == "" {
+		dir = DefaultOutputDir
+	}
+	return filepath.Join(dir, ExpandFileName(DefaultFileName, f)+ext)
+}
+
+// costUsageFileNameFields returns the placeholder values of a cost and usage
+// report
+func costUsageFileNameFields(input types.CostAndUsageOutputType, now time.Time) FileNameFields {
+	return FileNameFields{Start: input.Start, End: input.End, GroupBy: groupLabels(input.Dimensions, input.Tags), Timestamp: now}
+}
+
+// forecastFileNameFields returns the placeholder values of a forecast
+func forecastFileNameFields(input types.ForecastPrintData, now time.Time) FileNameFields {
+	return FileNameFields{
+		Start:     input.Request.Time.Start,
+		End:       input.Request.Time.End,
+		GroupBy:   []string{"forecast"},
+		Timestamp: now,
+	}
+}
+
+// createOutput opens the destination of a report, creating the directory of
+// a file when it is missing. The returned function closes a file and does
+// nothing for stdout.
+func createOutput(path string, stdout io.Writer) (io.Writer, func() error, error) {
+	if path == Stdout || path == "" {
+		return stdout, func() error { return nil }, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, f.Close, nil
+}
+
+// WritesToStdout reports whether a report of the print type is written to
+// stdout rather than to a file or a vector store
+func WritesToStdout(printType types.PrintWriterType, o types.OutputConfig) bool {
+	switch printType {
+	case types.Stdout:
+		return true
+	case types.JSON, types.NDJSON, types.Markdown:
+		return OutputPath(o, FileNameFields{}, "", true) == Stdout
+	case types.CSV, types.Chart, types.XLSX:
+		return o.Path == Stdout
+	}
+	return false
+}
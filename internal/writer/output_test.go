@@ -0,0 +1,67 @@
+package writer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fileNameFields = FileNameFields{
+	Start:     "2024-01-01",
+	End:       "2024-02-01T00:00:00Z",
+	GroupBy:   []string{"SERVICE", "TAG:Project"},
+	Timestamp: time.Date(2024, 2, 3, 4, 5, 6, 0, time.UTC),
+}
+
+func TestExpandFileName(t *testing.T) {
+	assert.Equal(t, "ccexplorer_SERVICE-TAG_Project_2024-01-01_2024-02-01T00_00_00Z_20240203T040506",
+		ExpandFileName(DefaultFileName, fileNameFields))
+	assert.Equal(t, "costs-all.csv", ExpandFileName("costs-{groupBy}.csv", FileNameFields{}))
+}
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   types.OutputConfig
+		toStdout bool
+		want     string
+	}{
+		{"generated name", types.OutputConfig{}, false,
+			filepath.Join(DefaultOutputDir, "ccexplorer_SERVICE-TAG_Project_2024-01-01_2024-02-01T00_00_00Z_20240203T040506.csv")},
+		{"generated name in directory", types.OutputConfig{Dir: "reports"}, false,
+			filepath.Join("reports", "ccexplorer_SERVICE-TAG_Project_2024-01-01_2024-02-01T00_00_00Z_20240203T040506.csv")},
+		{"template", types.OutputConfig{Path: "costs-{start}.csv"}, false, "costs-2024-01-01.csv"},
+		{"template in directory", types.OutputConfig{Path: "costs.csv", Dir: "reports"}, false, filepath.Join("reports", "costs.csv")},
+		{"absolute path", types.OutputConfig{Path: "/tmp/costs.csv", Dir: "reports"}, false, "/tmp/costs.csv"},
+		{"stdout", types.OutputConfig{Path: Stdout, Dir: "reports"}, false, Stdout},
+		{"stdout by default", types.OutputConfig{}, true, Stdout},
+		{"directory instead of stdout", types.OutputConfig{Dir: "reports"}, true,
+			filepath.Join("reports", "ccexplorer_SERVICE-TAG_Project_2024-01-01_2024-02-01T00_00_00Z_20240203T040506.csv")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, OutputPath(tt.output, fileNameFields, ".csv", tt.toStdout))
+		})
+	}
+}
+
+func TestCSVRenderer(t *testing.T) {
+	data := &CSVOutput{Headers: []string{"Service", "Amount"}, Rows: [][]string{{"Amazon S3", "1.00"}}}
+
+	var buf bytes.Buffer
+	data.Filename = Stdout
+	require.NoError(t, (&CSVRenderer{stdout: &buf}).Render(data))
+	assert.Equal(t, "Service,Amount\nAmazon S3,1.00\n", buf.String())
+
+	data.Filename = filepath.Join(t.TempDir(), "reports", "costs.csv")
+	require.NoError(t, (&CSVRenderer{stdout: &buf}).Render(data), "the directory is created when missing")
+	written, err := os.ReadFile(data.Filename)
+	require.NoError(t, err)
+	assert.Equal(t, buf.String(), string(written))
+}
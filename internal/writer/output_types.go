@@ -12,6 +12,8 @@ type TableOutput struct {
 	Footer  []string
 	Title   string
 	Total   string
+	// KeyColumns is the number of leading key columns of a pivot table
+	KeyColumns int
 }
 
 // CSVOutput represents data formatted for CSV export
@@ -30,9 +32,19 @@ type ChartOutput struct {
 
 // VectorOutput represents data formatted for vector database storage
 type VectorOutput struct {
-	Items       []*types.VectorStoreItem
-	IndexName   string
-	BatchSize   int
+	Items     []*types.VectorStoreItem
+	Store     types.VectorStoreConfig
+	Embedding types.EmbeddingConfig
+	Ingest    types.IngestConfig
+}
+
+// JSONOutput represents a report encoded as one JSON document, or as one
+// JSON value per line
+type JSONOutput struct {
+	Values []any
+	NDJSON bool
+	// Filename is the file written, or - for stdout
+	Filename string
 }
 
 // ForecastTableOutput represents forecast data for table display
@@ -92,11 +104,13 @@ func NewChartOutput(page *components.Page, title, filename string) *ChartOutput
 }
 
 // NewVectorOutput creates a new VectorOutput instance
-func NewVectorOutput(items []*types.VectorStoreItem, indexName string) *VectorOutput {
+func NewVectorOutput(items []*types.VectorStoreItem, store types.VectorStoreConfig,
+	embedding types.EmbeddingConfig, ingest types.IngestConfig) *VectorOutput {
 	return &VectorOutput{
 		Items:     items,
-		IndexName: indexName,
-		BatchSize: 25, // default batch size
+		Store:     store,
+		Embedding: embedding,
+		Ingest:    ingest,
 	}
 }
 
@@ -0,0 +1,179 @@
+package writer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+)
+
+const (
+	// LayoutRows writes one row per key per period
+	LayoutRows = "rows"
+	// LayoutPivot writes one row per key and one column per period
+	LayoutPivot = "pivot"
+)
+
+// Pivot holds the amounts of one metric with one row per key and one column
+// per period, sorted by row total in descending order
+type Pivot struct {
+	Metric string
+	Unit   string
+	// Labels name the key columns
+	Labels  []string
+	Periods []string
+	Rows    []PivotRow
+	// PeriodTotals holds the total of each period
+	PeriodTotals []float64
+	Total        float64
+}
+
+// PivotRow holds the amounts of one key
+type PivotRow struct {
+	Keys    []string
+	Amounts []float64
+	Total   float64
+}
+
+// NewPivot pivots the first requested metric of the results
+func NewPivot(input types.CostAndUsageOutputType) Pivot {
+	services := utils.ConvertMapToSlice(input.Services)
+	return newPivot(services, groupLabels(input.Dimensions, input.Tags),
+		pivotMetric(input.Metrics, services))
+}
+
+func newPivot(services []types.Service, labels []string, metric string) Pivot {
+	p := Pivot{Metric: metric, Labels: labels}
+
+	for _, s := range services {
+		if !slices.Contains(p.Periods, s.Start) {
+			p.Periods = append(p.Periods, s.Start)
+		}
+		for len(p.Labels) < len(s.Keys) {
+			p.Labels = append(p.Labels, groupLabel(p.Labels, len(p.Labels)))
+		}
+	}
+	sort.Strings(p.Periods)
+	p.PeriodTotals = make([]float64, len(p.Periods))
+
+	rows := map[string]*PivotRow{}
+	var order []string
+	for _, s := range services {
+		keys := make([]string, len(p.Labels))
+		for i, key := range s.Keys {
+			keys[i] = groupValue(p.Labels[i], key)
+		}
+		id := fmt.Sprintf("%q", keys)
+		row, ok := rows[id]
+		if !ok {
+			row = &PivotRow{Keys: keys, Amounts: make([]float64, len(p.Periods))}
+			rows[id] = row
+			order = append(order, id)
+		}
+
+		period := slices.Index(p.Periods, s.Start)
+		for _, m := range s.Metrics {
+			if m.Name != p.Metric {
+				continue
+			}
+			if p.Unit == "" {
+				p.Unit = m.Unit
+			}
+			row.Amounts[period] += m.NumericAmount
+			row.Total += m.NumericAmount
+			p.PeriodTotals[period] += m.NumericAmount
+			p.Total += m.NumericAmount
+		}
+	}
+
+	for _, id := range order {
+		p.Rows = append(p.Rows, *rows[id])
+	}
+	sort.SliceStable(p.Rows, func(i, j int) bool {
+		if p.Rows[i].Total != p.Rows[j].Total {
+			return p.Rows[i].Total > p.Rows[j].Total
+		}
+		return slices.Compare(p.Rows[i].Keys, p.Rows[j].Keys) < 0
+	})
+	return p
+}
+
+func pivotMetric(metrics []string, services []types.Service) string {
+	if len(metrics) > 0 {
+		return metrics[0]
+	}
+	for _, s := range services {
+		if len(s.Metrics) > 0 {
+			return s.Metrics[0].Name
+		}
+	}
+	return ""
+}
+
+// Headers returns the key, period, Total and % of Total columns, followed by
+// a Change column for each period after the first
+func (p Pivot) Headers() []string {
+	headers := append([]string{}, p.Labels...)
+	headers = append(headers, p.Periods...)
+	headers = append(headers, "Total", "% of Total")
+	for _, period := range p.Periods[min(1, len(p.Periods)):] {
+		headers = append(headers, "Change "+period)
+	}
+	return headers
+}
+
+// Share returns the percentage of the grand total, and false when the grand
+// total is zero
+func (p Pivot) Share(amount float64) (float64, bool) {
+	if p.Total == 0 {
+		return 0, false
+	}
+	return amount / p.Total * 100, true
+}
+
+// Change returns the percentage change from the previous period to period,
+// and false when there is no previous period or it is zero
+func Change(amounts []float64, period int) (float64, bool) {
+	if period < 1 || period >= len(amounts) || amounts[period-1] == 0 {
+		return 0, false
+	}
+	return (amounts[period] - amounts[period-1]) / amounts[period-1] * 100, true
+}
+
+// Strings formats the rows of the pivot, followed by the totals row
+func (p Pivot) Strings() (rows [][]string, footer []string) {
+	format := func(labels []string, amounts []float64, total float64) []string {
+		row := append([]string{}, labels...)
+		for _, a := range amounts {
+			row = append(row, fmt.Sprintf("%.2f", a))
+		}
+		row = append(row, fmt.Sprintf("%.2f", total))
+		row = append(row, percent(p.Share(total)))
+		for i := 1; i < len(amounts); i++ {
+			if change, ok := Change(amounts, i); ok {
+				row = append(row, fmt.Sprintf("%+.1f%%", change))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		return row
+	}
+
+	for _, r := range p.Rows {
+		rows = append(rows, format(r.Keys, r.Amounts, r.Total))
+	}
+	labels := make([]string, len(p.Labels))
+	if len(labels) > 0 {
+		labels[0] = "Total"
+	}
+	return rows, format(labels, p.PeriodTotals, p.Total)
+}
+
+func percent(value float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", value)
+}
@@ -0,0 +1,93 @@
+package writer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestNewPivot(t *testing.T) {
+	p := NewPivot(costReportInput())
+
+	assert.Equal(t, "UnblendedCost", p.Metric)
+	assert.Equal(t, "USD", p.Unit)
+	assert.Equal(t, []string{"2024-01-01", "2024-02-01"}, p.Periods)
+	assert.Equal(t, []string{"SERVICE", "TAG:Project", "2024-01-01", "2024-02-01", "Total", "% of Total", "Change 2024-02-01"},
+		p.Headers())
+	assert.Equal(t, []PivotRow{
+		{Keys: []string{"Amazon EC2", "api"}, Amounts: []float64{20.1, 0}, Total: 20.1},
+		{Keys: []string{"Amazon EC2", "web"}, Amounts: []float64{0, 20.1}, Total: 20.1},
+		{Keys: []string{"Amazon S3", "web"}, Amounts: []float64{5, 0}, Total: 5},
+	}, p.Rows, "rows are sorted by total, then by keys")
+	assert.InDeltaSlice(t, []float64{25.1, 20.1}, p.PeriodTotals, 1e-9)
+	assert.InDelta(t, 45.2, p.Total, 1e-9)
+
+	rows, footer := p.Strings()
+	assert.Equal(t, []string{"Amazon EC2", "api", "20.10", "0.00", "20.10", "44.5%", "-100.0%"}, rows[0])
+	assert.Equal(t, []string{"Amazon EC2", "web", "0.00", "20.10", "20.10", "44.5%", "-"}, rows[1],
+		"there is no change from zero")
+	assert.Equal(t, []string{"Total", "", "25.10", "20.10", "45.20", "100.0%", "-19.9%"}, footer)
+}
+
+func TestPivotChangePerPeriod(t *testing.T) {
+	input := costReportInput()
+	input.Services[3] = types.Service{
+		Start:   "2024-03-01",
+		End:     "2024-03-28",
+		Keys:    []string{"Amazon EC2", "Project$web"},
+		Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: 30.15, Unit: "USD"}},
+	}
+	p := NewPivot(input)
+
+	assert.Equal(t, []string{"2024-01-01", "2024-02-01", "2024-03-01"}, p.Periods)
+	assert.Equal(t, []string{"SERVICE", "TAG:Project", "2024-01-01", "2024-02-01", "2024-03-01",
+		"Total", "% of Total", "Change 2024-02-01", "Change 2024-03-01"}, p.Headers())
+
+	rows, footer := p.Strings()
+	assert.Equal(t, []string{"Amazon EC2", "web", "0.00", "20.10", "30.15", "50.25", "66.7%", "-", "+50.0%"},
+		rows[0], "each period is compared with the period before it")
+	assert.Equal(t, []string{"Total", "", "25.10", "20.10", "30.15", "75.35", "100.0%", "-19.9%", "+50.0%"}, footer)
+
+	input.Layout = LayoutPivot
+	xlsx, err := NewCostUsageToXLSXTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	assert.Equal(t, []any{66.7, nil, 50.0}, xlsx.Rows[0][6:], "the share and both changes")
+}
+
+func TestPivotOutputs(t *testing.T) {
+	input := costReportInput()
+	input.Layout = LayoutPivot
+	input.Output.Path = Stdout
+
+	table, err := NewCostUsageToTableTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	assert.Equal(t, 2, table.KeyColumns)
+	assert.Equal(t, "UnblendedCost (USD)", table.Title)
+
+	csvOutput, err := NewCostUsageToCSVTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	assert.Len(t, csvOutput.Rows, 4, "the CSV ends with the totals row")
+
+	markdown, err := NewCostUsageToMarkdownTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, (&MarkdownRenderer{stdout: &buf}).Render(markdown))
+	assert.Contains(t, buf.String(), "| SERVICE | TAG:Project | 2024-01-01 | 2024-02-01 | Total | % of Total | Change 2024-02-01 |")
+	assert.Contains(t, buf.String(), "| Total |  | 25.10 | 20.10 | 45.20 | 100.0% | -19.9% |")
+
+	xlsx, err := NewCostUsageToXLSXTransformer("cost").Transform(input)
+	require.NoError(t, err)
+	buf.Reset()
+	require.NoError(t, (&XLSXRenderer{stdout: &buf}).Render(xlsx))
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	rows, err := f.GetRows(xlsxSheet)
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+	assert.Equal(t, []string{"Amazon S3", "web", "5", "0", "5", "11.1", "-100"}, rows[3])
+	assert.Equal(t, "45.2", rows[4][4])
+}
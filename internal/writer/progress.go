@@ -0,0 +1,49 @@
+package writer
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
+	"github.com/jedib0t/go-pretty/v6/progress"
+)
+
+// newProgressBar renders the progress of a vector store load to w. stop
+// marks the load as finished, or failed, and waits for the final render.
+func newProgressBar(w io.Writer, message string) (report vectorstore.ProgressFunc,
+	stop func(failed bool)) {
+
+	pw := progress.NewWriter()
+	pw.SetOutputWriter(w)
+	pw.SetAutoStop(true)
+	pw.SetUpdateFrequency(100 * time.Millisecond)
+	pw.SetStyle(progress.StyleBlocks)
+	pw.ShowETA(true)
+
+	tracker := &progress.Tracker{Message: message, Units: progress.UnitsDefault}
+	pw.AppendTracker(tracker)
+	go pw.Render()
+
+	report = func(done, total int) {
+		tracker.UpdateTotal(int64(total))
+		tracker.SetValue(int64(done))
+	}
+	stop = func(failed bool) {
+		if failed {
+			tracker.MarkAsErrored()
+		} else {
+			tracker.MarkAsDone()
+		}
+		for pw.IsRenderInProgress() {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return report, stop
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
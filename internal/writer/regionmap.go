@@ -0,0 +1,304 @@
+package writer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	cc "github.com/cduggn/ccexplorer/internal/types"
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	echartstypes "github.com/go-echarts/go-echarts/v2/types"
+)
+
+// RegionLabel is the group by dimension of AWS region codes
+const RegionLabel = "REGION"
+
+// Region names an AWS region and locates it on the world map
+type Region struct {
+	Name      string
+	Longitude float64
+	Latitude  float64
+}
+
+// awsRegions locates each AWS region at the city, or in the area, it is
+// named after
+var awsRegions = map[string]Region{
+	"af-south-1":     {"Africa (Cape Town)", 18.42, -33.92},
+	"ap-east-1":      {"Asia Pacific (Hong Kong)", 114.17, 22.32},
+	"ap-east-2":      {"Asia Pacific (Taipei)", 121.56, 25.03},
+	"ap-northeast-1": {"Asia Pacific (Tokyo)", 139.69, 35.68},
+	"ap-northeast-2": {"Asia Pacific (Seoul)", 126.98, 37.57},
+	"ap-northeast-3": {"Asia Pacific (Osaka)", 135.50, 34.69},
+	"ap-south-1":     {"Asia Pacific (Mumbai)", 72.88, 19.08},
+	"ap-south-2":     {"Asia Pacific (Hyderabad)", 78.49, 17.39},
+	"ap-southeast-1": {"Asia Pacific (Singapore)", 103.82, 1.35},
+	"ap-southeast-2": {"Asia Pacific (Sydney)", 151.21, -33.87},
+	"ap-southeast-3": {"Asia Pacific (Jakarta)", 106.85, -6.21},
+	"ap-southeast-4": {"Asia Pacific (Melbourne)", 144.96, -37.81},
+	"ap-southeast-5": {"Asia Pacific (Malaysia)", 101.69, 3.14},
+	"ap-southeast-6": {"Asia Pacific (New Zealand)", 174.76, -36.85},
+	"ap-southeast-7": {"Asia Pacific (Thailand)", 100.50, 13.76},
+	"ca-central-1":   {"Canada (Central)", -73.57, 45.50},
+	"ca-west-1":      {"Canada West (Calgary)", -114.07, 51.05},
+	"cn-north-1":     {"China (Beijing)", 116.41, 39.90},
+	"cn-northwest-1": {"China (Ningxia)", 106.26, 38.47},
+	"eu-central-1":   {"Europe (Frankfurt)", 8.68, 50.11},
+	"eu-central-2":   {"Europe (Zurich)", 8.54, 47.38},
+	"eu-north-1":     {"Europe (Stockholm)", 18.07, 59.33},
+	"eu-south-1":     {"Europe (Milan)", 9.19, 45.46},
+	"eu-south-2":     {"Europe (Spain)", -0.88, 41.65},
+	"eu-west-1":      {"Europe (Ireland)", -6.26, 53.35},
+	"eu-west-2":      {"Europe (London)", -0.13, 51.51},
+	"eu-west-3":      {"Europe (Paris)", 2.35, 48.86},
+	"il-central-1":   {"Israel (Tel Aviv)", 34.78, 32.09},
+	"me-central-1":   {"Middle East (UAE)", 55.27, 25.20},
+	"me-south-1":     {"Middle East (Bahrain)", 50.56, 26.07},
+	"mx-central-1":   {"Mexico (Central)", -100.39, 20.59},
+	"sa-east-1":      {"South America (Sao Paulo)", -46.63, -23.55},
+	"us-east-1":      {"US East (N. Virginia)", -77.49, 39.04},
+	"us-east-2":      {"US East (Ohio)", -83.00, 39.96},
+	"us-gov-east-1":  {"AWS GovCloud (US-East)", -82.50, 40.40},
+	"us-gov-west-1":  {"AWS GovCloud (US-West)", -121.18, 45.60},
+	"us-west-1":      {"US West (N. California)", -121.96, 37.35},
+	"us-west-2":      {"US West (Oregon)", -119.70, 45.84},
+}
+
+// LookupRegion returns the name and location of an AWS region code
+func LookupRegion(code string) (Region, bool) {
+	region, ok := awsRegions[code]
+	return region, ok
+}
+
+// RegionCost holds the cost of one region
+type RegionCost struct {
+	Code string
+	Region
+	Total float64
+	// Amounts holds the cost of each split key, indexed like RegionMap.Splits
+	Amounts []float64
+}
+
+// RegionMap holds the cost per region of the first requested metric, in
+// descending order of cost, split by the other group by key when there is one
+type RegionMap struct {
+	Metric string
+	Unit   string
+	// SplitLabel names the key the regions are split by, if any
+	SplitLabel string
+	// Splits holds the split keys in descending order of total
+	Splits  []string
+	Regions []RegionCost
+	// Unmapped holds the regions without a location, such as global
+	Unmapped []RegionCost
+	Total    float64
+}
+
+// NewRegionMap builds the cost per region of the REGION group by key. When
+// topN is positive, the split keys beyond the topN largest are summed as
+// Other.
+func NewRegionMap(r cc.InputType, topN int) RegionMap {
+	p := newPivot(r.Services, groupLabels(r.Dimensions, r.Tags),
+		pivotMetric(r.Metrics, r.Services))
+	m := RegionMap{Metric: p.Metric, Unit: p.Unit, Total: p.Total}
+
+	regionKey := max(slices.Index(p.Labels, RegionLabel), 0)
+	splitKey := -1
+	if len(p.Labels) > 1 {
+		splitKey = 1 - min(regionKey, 1)
+		m.SplitLabel = p.Labels[splitKey]
+	}
+
+	split := map[string]int{}
+	if splitKey >= 0 {
+		totals := map[string]float64{}
+		for _, row := range p.Rows {
+			key := row.Keys[splitKey]
+			if _, ok := totals[key]; !ok {
+				m.Splits = append(m.Splits, key)
+			}
+			totals[key] += row.Total
+		}
+		sort.SliceStable(m.Splits, func(i, j int) bool { return totals[m.Splits[i]] > totals[m.Splits[j]] })
+		if topN > 0 && len(m.Splits) > topN {
+			for _, key := range m.Splits[topN:] {
+				split[key] = topN
+			}
+			m.Splits = append(m.Splits[:topN], OtherName)
+		}
+		for i, key := range m.Splits {
+			if _, ok := split[key]; !ok {
+				split[key] = i
+			}
+		}
+	}
+
+	costs := map[string]*RegionCost{}
+	var order []string
+	for _, row := range p.Rows {
+		code := row.Keys[regionKey]
+		c, ok := costs[code]
+		if !ok {
+			c = &RegionCost{Code: code, Amounts: make([]float64, len(m.Splits))}
+			c.Region, _ = LookupRegion(code)
+			costs[code] = c
+			order = append(order, code)
+		}
+		c.Total += row.Total
+		if splitKey >= 0 {
+			c.Amounts[split[row.Keys[splitKey]]] += row.Total
+		}
+	}
+
+	for _, code := range order {
+		if _, ok := LookupRegion(code); ok {
+			m.Regions = append(m.Regions, *costs[code])
+		} else {
+			m.Unmapped = append(m.Unmapped, *costs[code])
+		}
+	}
+	sort.SliceStable(m.Regions, func(i, j int) bool { return m.Regions[i].Total > m.Regions[j].Total })
+	return m
+}
+
+// regionData is a bubble on the world map; the other fields are read by the
+// tooltip
+type regionData struct {
+	Name        string    `json:"name"`
+	Value       []float64 `json:"value"`
+	Region      string    `json:"region"`
+	Key         string    `json:"key,omitempty"`
+	RegionTotal float64   `json:"regionTotal"`
+	Share       float64   `json:"share"`
+}
+
+// regionTooltip shows the region name and code, the cost of the bubble and
+// its share of the total cost
+func regionTooltip(unit string) string {
+	return fmt.Sprintf(`function (p) {
+	var lines = [p.data.region + ' (' + p.name + ')'];
+	if (p.data.key) {
+		lines.push(p.marker + p.data.key + ': ' + p.value[2].toFixed(2) + ' %[1]s');
+		lines.push('Region: ' + p.data.regionTotal.toFixed(2) + ' %[1]s');
+	} else {
+		lines.push('Cost: ' + p.value[2].toFixed(2) + ' %[1]s');
+	}
+	lines.push('Share: ' + p.data.share.toFixed(1) + '%%');
+	return lines.join('<br/>');
+}`, strings.ReplaceAll(unit, "'", ""))
+}
+
+// regionBubbleSize is the diameter in pixels of the largest bubble
+const regionBubbleSize = 60
+
+// worldMapAsset is the script of the go-echarts assets host that registers
+// the world map
+const worldMapAsset = "maps/world.js"
+
+// geoRoam lets the map be zoomed and panned, which the geo component options
+// of go-echarts do not cover
+const geoRoam = `%MY_ECHARTS%.setOption({ geo: { roam: true } });`
+
+// buildRegionMapChart draws a bubble per region on the world map, with its
+// area proportional to the cost of the region, or a bubble per region and
+// split key when the regions are split
+func buildRegionMapChart(r cc.InputType, m RegionMap) *charts.Geo {
+	subtitle := fmt.Sprintf("Start: %s, End: %s", r.Start, r.End)
+	if len(m.Unmapped) > 0 {
+		var unmapped []string
+		for _, c := range m.Unmapped {
+			unmapped = append(unmapped, fmt.Sprintf("%s %.2f", c.Code, c.Total))
+		}
+		subtitle += ", Not on the map: " + strings.Join(unmapped, ", ")
+	}
+	title := fmt.Sprintf("Cost by region (%s %s)", m.Metric, m.Unit)
+	if m.SplitLabel != "" {
+		title = fmt.Sprintf("Cost by region and %s (%s %s)", m.SplitLabel, m.Metric, m.Unit)
+	}
+
+	share := func(amount float64) float64 {
+		if m.Total == 0 {
+			return 0
+		}
+		return amount / m.Total * 100
+	}
+	bubble := func(c RegionCost, key string, amount float64) regionData {
+		return regionData{
+			Name:        c.Code,
+			Value:       []float64{c.Longitude, c.Latitude, roundCents(amount)},
+			Region:      c.Name,
+			Key:         key,
+			RegionTotal: roundCents(c.Total),
+			Share:       share(amount),
+		}
+	}
+
+	names := []string{"Total"}
+	data := make([][]regionData, 1)
+	if m.SplitLabel == "" {
+		for _, c := range m.Regions {
+			data[0] = append(data[0], bubble(c, "", c.Total))
+		}
+	} else {
+		names = m.Splits
+		data = make([][]regionData, len(m.Splits))
+		for _, c := range m.Regions {
+			for i, amount := range c.Amounts {
+				if amount != 0 {
+					data[i] = append(data[i], bubble(c, m.Splits[i], amount))
+				}
+			}
+		}
+	}
+	var largest float64
+	for _, series := range data {
+		for _, d := range series {
+			largest = max(largest, d.Value[2])
+		}
+	}
+	symbolSize := opts.FuncOpts(fmt.Sprintf(
+		"function (value) { return Math.max(%d * Math.sqrt(value[2] / %g), 4); }",
+		regionBubbleSize, max(largest, 0.01)))
+
+	geo := charts.NewGeo()
+	geo.SetGlobalOptions(
+		charts.WithTitleOpts(opts.Title{Title: title, Subtitle: subtitle}),
+		charts.WithTooltipOpts(opts.Tooltip{
+			Show:      opts.Bool(true),
+			Trigger:   "item",
+			Formatter: opts.FuncOpts(regionTooltip(m.Unit)),
+		}),
+		charts.WithLegendOpts(opts.Legend{
+			Show: opts.Bool(m.SplitLabel != ""),
+			Type: "scroll",
+			Top:  "bottom",
+		}),
+		charts.WithGeoComponentOpts(opts.GeoComponent{
+			Map:       "world",
+			ItemStyle: &opts.ItemStyle{Color: "#eef0f3", BorderColor: "#b8bec8"},
+		}),
+		charts.WithInitializationOpts(opts.Initialization{Width: "1200px", Height: "700px"}),
+	)
+	geo.AddJSFuncs(geoRoam)
+	geo.JSAssets.Add(worldMapAsset)
+
+	for i, name := range names {
+		// keys only found in unmapped regions have no bubbles
+		if len(data[i]) == 0 {
+			continue
+		}
+		series := charts.SingleSeries{
+			Name:        name,
+			Type:        echartstypes.ChartScatter,
+			CoordSystem: echartstypes.ChartGeo,
+			Data:        data[i],
+			SymbolSize:  symbolSize,
+		}
+		series.ConfigureSeriesOpts(
+			charts.WithItemStyleOpts(opts.ItemStyle{Opacity: 0.75, BorderColor: "#fff", BorderWidth: 1}),
+		)
+		geo.MultiSeries = append(geo.MultiSeries, series)
+	}
+	return geo
+}
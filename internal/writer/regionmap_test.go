@@ -0,0 +1,113 @@
+package writer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func regionInput() types.CostAndUsageOutputType {
+	service := func(region, name string, amount float64) types.Service {
+		return types.Service{
+			Start:   "2024-06-01",
+			Keys:    []string{region, name},
+			Metrics: []types.Metrics{{Name: "UnblendedCost", NumericAmount: amount, Unit: "USD"}},
+		}
+	}
+	return types.CostAndUsageOutputType{
+		Services: map[int]types.Service{
+			0: service("us-east-1", "Amazon EC2", 60),
+			1: service("us-east-1", "Amazon S3", 12),
+			2: service("eu-west-1", "Amazon EC2", 15),
+			3: service("eu-west-1", "AWS Lambda", 5),
+			4: service("global", "Amazon Route 53", 10),
+		},
+		Granularity: "MONTHLY",
+		Start:       "2024-06-01",
+		End:         "2024-07-01",
+		Dimensions:  []string{"REGION", "SERVICE"},
+		Metrics:     []string{"UnblendedCost"},
+	}
+}
+
+func TestLookupRegion(t *testing.T) {
+	region, ok := LookupRegion("eu-west-1")
+	require.True(t, ok)
+	assert.Equal(t, "Europe (Ireland)", region.Name)
+	assert.InDelta(t, -6.26, region.Longitude, 0.01)
+
+	_, ok = LookupRegion("global")
+	assert.False(t, ok)
+}
+
+func TestNewRegionMap(t *testing.T) {
+	input := regionInput()
+	chartInput := utils.ConvertToChartInputType(input, utils.ConvertMapToSlice(input.Services))
+
+	m := NewRegionMap(chartInput, 2)
+	assert.Equal(t, "SERVICE", m.SplitLabel)
+	assert.Equal(t, []string{"Amazon EC2", "Amazon S3", OtherName}, m.Splits,
+		"the services beyond the top N are summed")
+	assert.InDelta(t, 102, m.Total, 1e-9)
+
+	require.Len(t, m.Regions, 2)
+	assert.Equal(t, "us-east-1", m.Regions[0].Code)
+	assert.Equal(t, "US East (N. Virginia)", m.Regions[0].Name)
+	assert.Equal(t, []float64{60, 12, 0}, m.Regions[0].Amounts)
+	assert.Equal(t, []float64{15, 0, 5}, m.Regions[1].Amounts)
+
+	require.Len(t, m.Unmapped, 1)
+	assert.Equal(t, "global", m.Unmapped[0].Code)
+	assert.InDelta(t, 10, m.Unmapped[0].Total, 1e-9)
+
+	chartInput.Dimensions = []string{"SERVICE", "REGION"}
+	for _, s := range chartInput.Services {
+		s.Keys[0], s.Keys[1] = s.Keys[1], s.Keys[0]
+	}
+	m = NewRegionMap(chartInput, 0)
+	assert.Equal(t, "SERVICE", m.SplitLabel, "the region key is found in any position")
+	assert.Equal(t, "us-east-1", m.Regions[0].Code)
+}
+
+func TestRegionMapChart(t *testing.T) {
+	input := regionInput()
+	input.Chart.Type = ChartMap
+	input.Output.Path = Stdout
+
+	render := func(input types.CostAndUsageOutputType) string {
+		output, err := NewCostUsageToChartTransformer("cost").Transform(input)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, (&ChartRenderer{stdout: &buf}).Render(output))
+		return buf.String()
+	}
+
+	html := render(input)
+	assert.NotContains(t, html, "<script src=", "the page opens offline")
+	assert.Contains(t, html, "echarts.registerMap('world', ")
+	assert.Contains(t, html, `"coordinateSystem":"geo"`)
+	assert.Contains(t, html, `"region":"Europe (Ireland)"`)
+	assert.Contains(t, html, "Not on the map: global 10.00")
+	assert.NotContains(t, html, `"name":"Amazon Route 53"`, "keys without a mapped region have no series")
+
+	input.Chart.AssetsDir = t.TempDir()
+	_, err := NewCostUsageToChartTransformer("cost").Transform(input)
+	assert.ErrorContains(t, err, "Failed reading chart asset")
+
+	require.NoError(t, os.WriteFile(filepath.Join(input.Chart.AssetsDir, "echarts.min.js"),
+		[]byte("var echarts = {};"), 0600))
+	require.NoError(t, os.Mkdir(filepath.Join(input.Chart.AssetsDir, "maps"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(input.Chart.AssetsDir, "maps", "world.js"),
+		[]byte("echarts.registerMap('world', {});"), 0600))
+
+	html = render(input)
+	assert.NotContains(t, html, "<script src=", "the page opens offline")
+	assert.Contains(t, html, "var echarts = {};", "--assetsDir overrides the bundled assets")
+	assert.Contains(t, html, "echarts.registerMap('world', {});")
+}
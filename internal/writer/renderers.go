@@ -7,10 +7,9 @@ import (
 	"io"
 	"os"
 
-	"github.com/cduggn/ccexplorer/internal/http"
 	"github.com/cduggn/ccexplorer/internal/types"
-	"github.com/cduggn/ccexplorer/internal/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
 	"log/slog"
 )
 
@@ -35,6 +34,9 @@ func (r *StdoutTableRenderer) Render(data *TableOutput) error {
 }
 
 func (r *StdoutTableRenderer) renderCostUsageTable(data *TableOutput) error {
+	if data.Footer != nil {
+		return r.renderPivotTable(data)
+	}
 	t := table.NewWriter()
 	t.SetOutputMirror(os.Stdout)
 	t.SetColumnConfigs([]table.ColumnConfig{
@@ -70,6 +72,37 @@ func (r *StdoutTableRenderer) renderCostUsageTable(data *TableOutput) error {
 	return nil
 }
 
+// renderPivotTable renders a pivot table, whose footer is the totals row
+func (r *StdoutTableRenderer) renderPivotTable(data *TableOutput) error {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleColoredGreenWhiteOnBlack)
+	t.SetTitle(data.Title)
+
+	// amounts are right-aligned after the key columns
+	var configs []table.ColumnConfig
+	for i := data.KeyColumns + 1; i <= len(data.Headers); i++ {
+		configs = append(configs, table.ColumnConfig{Number: i, Align: text.AlignRight, AlignFooter: text.AlignRight})
+	}
+	t.SetColumnConfigs(configs)
+	t.AppendHeader(toTableRow(data.Headers))
+	for _, row := range data.Rows {
+		t.AppendRow(toTableRow(row))
+	}
+	t.AppendFooter(toTableRow(data.Footer))
+
+	t.Render()
+	return nil
+}
+
+func toTableRow(cells []string) table.Row {
+	row := make(table.Row, len(cells))
+	for i, cell := range cells {
+		row[i] = cell
+	}
+	return row
+}
+
 // ForecastTableRenderer renders forecast table data to stdout  
 type ForecastTableRenderer struct{}
 
@@ -111,57 +144,68 @@ func (r *ForecastTableRenderer) Render(data *ForecastTableOutput) error {
 	return nil
 }
 
-// CSVRenderer renders CSV data to files
-type CSVRenderer struct{}
+// CSVRenderer renders CSV data to a file or stdout
+type CSVRenderer struct {
+	stdout io.Writer
+}
 
 // NewCSVRenderer creates a new CSV renderer
 func NewCSVRenderer() *CSVRenderer {
-	return &CSVRenderer{}
+	return &CSVRenderer{stdout: os.Stdout}
 }
 
 // Render implements the Renderer interface for CSV files
 func (r *CSVRenderer) Render(data *CSVOutput) error {
-	filePath := utils.BuildOutputFilePath(OutputDir, data.Filename)
-	file, err := os.Create(filePath)
+	file, closeFile, err := createOutput(data.Filename, r.stdout)
 	if err != nil {
 		return types.Error{Msg: "Error creating CSV file: " + err.Error()}
 	}
-	defer file.Close()
 
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
 
 	// Write header
 	if err := writer.Write(data.Headers); err != nil {
+		closeFile()
 		return types.Error{Msg: "Error writing CSV header: " + err.Error()}
 	}
 
 	// Write data rows
 	if err := writer.WriteAll(data.Rows); err != nil {
+		closeFile()
 		return types.Error{Msg: "Error writing CSV data: " + err.Error()}
 	}
 
+	if err := closeFile(); err != nil {
+		return types.Error{Msg: "Error writing CSV file: " + err.Error()}
+	}
 	return nil
 }
 
-// ChartRenderer renders chart data to HTML files
-type ChartRenderer struct{}
+// ChartRenderer renders chart data to an HTML file or stdout
+type ChartRenderer struct {
+	stdout io.Writer
+}
 
 // NewChartRenderer creates a new chart renderer
 func NewChartRenderer() *ChartRenderer {
-	return &ChartRenderer{}
+	return &ChartRenderer{stdout: os.Stdout}
 }
 
 // Render implements the Renderer interface for charts
 func (r *ChartRenderer) Render(data *ChartOutput) error {
-	filePath := utils.BuildOutputFilePath(OutputDir, data.Filename)
-	file, err := os.Create(filePath)
+	file, closeFile, err := createOutput(data.Filename, r.stdout)
 	if err != nil {
 		return types.Error{Msg: "Failed creating chart HTML file: " + err.Error()}
 	}
-	defer file.Close()
 
-	return data.Page.Render(io.MultiWriter(file))
+	if err := data.Page.Render(file); err != nil {
+		closeFile()
+		return types.Error{Msg: "Error writing chart: " + err.Error()}
+	}
+	if err := closeFile(); err != nil {
+		return types.Error{Msg: "Error writing chart: " + err.Error()}
+	}
+	return nil
 }
 
 // VectorRenderer renders vector data to vector databases
@@ -174,35 +218,17 @@ func NewVectorRenderer() *VectorRenderer {
 
 // Render implements the Renderer interface for vector databases
 func (r *VectorRenderer) Render(data *VectorOutput) error {
-	// Create the vector store client - this would need to be passed in or configured
-	client := NewVectorStoreClient(
-		http.NewRequestBuilder(),
-		data.IndexName,
-		"", // API keys would need to be provided
-		"",
-	)
-
-	// Create embeddings for the vector items
-	vectors, err := client.CreateEmbeddings(data.Items)
+	client, err := NewVectorStoreClient(data.Embedding, data.Store, data.Ingest)
 	if err != nil {
-		return types.Error{Msg: "Error creating embeddings: " + err.Error()}
-	}
-
-	// Update items with embedding vectors
-	for index, vector := range vectors {
-		if index < len(data.Items) {
-			data.Items[index].EmbeddingVector = vector.Embedding
-			data.Items[index].ID = utils.EncodeString(data.Items[index].EmbeddingText)
-		}
+		return types.Error{Msg: "Error configuring vector store: " + err.Error()}
 	}
 
-	// Convert to Pinecone format and upsert
-	pineconeData := utils.ConvertToPineconeStruct(data.Items)
-	resp, err := client.Upsert(context.Background(), pineconeData)
+	resp, err := embedAndUpsert(context.Background(), client, data.Items)
 	if err != nil {
-		return types.Error{Msg: "Error upserting to vector store: " + err.Error()}
+		return err
 	}
 
-	slog.Info(fmt.Sprintf("Upserted %d items to vector store", resp.UpsertedCount))
+	slog.Info(fmt.Sprintf("Upserted %d items to %s vector store",
+		resp.UpsertedCount, data.Store.Backend))
 	return nil
-}
\ No newline at end of file
+}
@@ -160,8 +160,8 @@ func ForecastToRows(r types.ForecastStdoutType) []table.Row {
 	var rows []table.Row
 	for _, v := range r.Forecast {
 		tempRow := table.Row{v.TimePeriod.Start,
-			v.TimePeriod.End, v.MeanValue, v.PredictionIntervalUpperBound,
-			v.PredictionIntervalLowerBound}
+			v.TimePeriod.End, v.MeanValue, v.PredictionIntervalLowerBound,
+			v.PredictionIntervalUpperBound}
 
 		rows = append(rows, tempRow)
 	}
@@ -0,0 +1,109 @@
+package writer
+
+import (
+	"strings"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+)
+
+// Chart types of --chartType
+const (
+	ChartPie        = "pie"
+	ChartBar        = "bar"
+	ChartLine       = "line"
+	ChartArea       = "area"
+	ChartCumulative = "cumulative"
+	ChartPareto     = "pareto"
+	ChartDashboard  = "dashboard"
+	ChartTreemap    = "treemap"
+	ChartSunburst   = "sunburst"
+	ChartSankey     = "sankey"
+	ChartCalendar   = "calendar"
+	ChartMap        = "map"
+)
+
+// ChartTypes lists the valid values of --chartType
+var ChartTypes = []string{ChartPie, ChartBar, ChartLine, ChartArea,
+	ChartCumulative, ChartPareto, ChartDashboard, ChartTreemap, ChartSunburst,
+	ChartSankey, ChartCalendar, ChartMap}
+
+const (
+	// DefaultTopN is the number of keys charted before the others are
+	// summed as Other
+	DefaultTopN = 10
+	// OtherName names the series summing the keys beyond the top N
+	OtherName = "Other"
+)
+
+// Series holds the amounts of one metric per key and period, with the keys
+// sorted by total in descending order
+type Series struct {
+	Metric  string
+	Unit    string
+	Periods []string
+	// Names holds the keys of each series, joined with " / "
+	Names []string
+	// Values holds the amount of each series per period
+	Values [][]float64
+	Totals []float64
+	Total  float64
+}
+
+// NewSeries builds the series of the first requested metric. When topN is
+// positive, the keys beyond the topN largest are summed into an Other series.
+func NewSeries(r types.InputType, topN int) Series {
+	p := newPivot(r.Services, groupLabels(r.Dimensions, r.Tags),
+		pivotMetric(r.Metrics, r.Services))
+	s := Series{Metric: p.Metric, Unit: p.Unit, Periods: p.Periods, Total: p.Total}
+
+	for i, row := range p.Rows {
+		if topN > 0 && i >= topN {
+			if i == topN {
+				s.Names = append(s.Names, OtherName)
+				s.Values = append(s.Values, make([]float64, len(p.Periods)))
+				s.Totals = append(s.Totals, 0)
+			}
+			other := len(s.Names) - 1
+			for j, amount := range row.Amounts {
+				s.Values[other][j] += amount
+			}
+			s.Totals[other] += row.Total
+			continue
+		}
+		s.Names = append(s.Names, strings.Join(row.Keys, " / "))
+		s.Values = append(s.Values, row.Amounts)
+		s.Totals = append(s.Totals, row.Total)
+	}
+	return s
+}
+
+// Cumulative returns the running totals of each series over the periods
+func (s Series) Cumulative() [][]float64 {
+	cumulative := make([][]float64, len(s.Values))
+	for i, values := range s.Values {
+		cumulative[i] = make([]float64, len(values))
+		sum := 0.0
+		for j, v := range values {
+			sum += v
+			cumulative[i][j] = sum
+		}
+	}
+	return cumulative
+}
+
+// Pareto returns the series names and totals in descending order of total,
+// with Other last, and the cumulative percentage of the grand total
+func (s Series) Pareto() (names []string, totals []float64, shares []float64) {
+	names = append(names, s.Names...)
+	totals = append(totals, s.Totals...)
+	sum := 0.0
+	for _, total := range totals {
+		sum += total
+		share := 0.0
+		if s.Total != 0 {
+			share = sum / s.Total * 100
+		}
+		shares = append(shares, share)
+	}
+	return names, totals, shares
+}
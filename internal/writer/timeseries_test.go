@@ -0,0 +1,52 @@
+package writer
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSeries(t *testing.T) {
+	input := costReportInput()
+	chartInput := utils.ConvertToChartInputType(input, utils.ConvertMapToSlice(input.Services))
+
+	s := NewSeries(chartInput, 0)
+	assert.Equal(t, "UnblendedCost", s.Metric)
+	assert.Equal(t, "USD", s.Unit)
+	assert.Equal(t, []string{"2024-01-01", "2024-02-01"}, s.Periods)
+	assert.Equal(t, []string{"Amazon EC2 / api", "Amazon EC2 / web", "Amazon S3 / web"}, s.Names)
+
+	s = NewSeries(chartInput, 1)
+	assert.Equal(t, []string{"Amazon EC2 / api", OtherName}, s.Names)
+	assert.Equal(t, [][]float64{{20.1, 0}, {5, 20.1}}, s.Values, "the keys beyond the top N are summed")
+	assert.InDeltaSlice(t, []float64{20.1, 25.1}, s.Totals, 1e-9)
+	assert.Equal(t, [][]float64{{20.1, 20.1}, {5, 25.1}}, s.Cumulative())
+
+	names, totals, shares := s.Pareto()
+	assert.Equal(t, s.Names, names)
+	assert.InDeltaSlice(t, s.Totals, totals, 1e-9)
+	assert.InDeltaSlice(t, []float64{20.1 / 45.2 * 100, 100}, shares, 1e-9)
+}
+
+func TestChartTypes(t *testing.T) {
+	input := costReportInput()
+	input.Output.Path = Stdout
+	for _, chartType := range ChartTypes {
+		input.Chart.Type = chartType
+		input.Chart.TopN = 2
+		output, err := NewCostUsageToChartTransformer("cost").Transform(input)
+		require.NoError(t, err, chartType)
+
+		var buf bytes.Buffer
+		require.NoError(t, (&ChartRenderer{stdout: &buf}).Render(output), chartType)
+		assert.Contains(t, buf.String(), "echarts.init", chartType)
+		if slices.Contains([]string{ChartBar, ChartLine, ChartArea, ChartCumulative, ChartPareto, ChartDashboard}, chartType) {
+			assert.Contains(t, buf.String(), "legendInverseSelect", chartType)
+			assert.Contains(t, buf.String(), `"Other"`, chartType)
+		}
+	}
+}
@@ -2,11 +2,15 @@ package writer
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	costexplorertypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
+	"github.com/cduggn/ccexplorer/internal/codec"
 	"github.com/cduggn/ccexplorer/internal/types"
 	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
 )
 
 // CostUsageToTableTransformer transforms cost and usage data to table format
@@ -23,6 +27,9 @@ func NewCostUsageToTableTransformer(sortBy string) *CostUsageToTableTransformer
 
 // Transform implements the Transformer interface for cost and usage data
 func (t *CostUsageToTableTransformer) Transform(input types.CostAndUsageOutputType) (*TableOutput, error) {
+	if input.Layout == LayoutPivot {
+		return pivotTable(input), nil
+	}
 	sortedServices := t.sortFunc(input.Services)
 	
 	headers := []string{
@@ -30,6 +37,13 @@ func (t *CostUsageToTableTransformer) Transform(input types.CostAndUsageOutputTy
 		"Metric Name", "Amount", "Rounded",
 		"Unit", "Granularity", "Start", "End",
 	}
+	bands, err := costBands(input)
+	if err != nil {
+		return nil, err
+	}
+	if bands != nil {
+		headers = slices.Insert(headers, 7, "Cost Band")
+	}
 	
 	var rows [][]string
 	var total float64
@@ -41,7 +55,7 @@ func (t *CostUsageToTableTransformer) Transform(input types.CostAndUsageOutputTy
 				total += metric.NumericAmount
 			}
 			
-			return []string{
+			row := []string{
 				fmt.Sprintf("%d", index+1),
 				service.Keys[0],
 				utils.ReturnIfPresent(service.Keys),
@@ -53,6 +67,10 @@ func (t *CostUsageToTableTransformer) Transform(input types.CostAndUsageOutputTy
 				service.Start,
 				service.End,
 			}
+			if bands != nil {
+				row = slices.Insert(row, 7, bands.Band(metric.NumericAmount))
+			}
+			return row
 		})
 		
 		// Add periodic divider rows
@@ -67,6 +85,18 @@ func (t *CostUsageToTableTransformer) Transform(input types.CostAndUsageOutputTy
 	return NewTableOutput(headers, rows, totalFormatted), nil
 }
 
+// pivotTable returns the pivot layout of a table, with the totals row as
+// its footer
+func pivotTable(input types.CostAndUsageOutputType) *TableOutput {
+	p := NewPivot(input)
+	rows, footer := p.Strings()
+	output := NewTableOutput(p.Headers(), rows, fmt.Sprintf("%.2f", p.Total))
+	output.Footer = footer
+	output.Title = fmt.Sprintf("%s (%s)", p.Metric, p.Unit)
+	output.KeyColumns = len(p.Labels)
+	return output
+}
+
 // CostUsageToCSVTransformer transforms cost and usage data to CSV format
 type CostUsageToCSVTransformer struct {
 	sortFunc func(map[int]types.Service) []types.Service
@@ -81,14 +111,43 @@ func NewCostUsageToCSVTransformer(sortBy string) *CostUsageToCSVTransformer {
 
 // Transform implements the Transformer interface for CSV output
 func (t *CostUsageToCSVTransformer) Transform(input types.CostAndUsageOutputType) (*CSVOutput, error) {
+	filename := OutputPath(input.Output, costUsageFileNameFields(input, time.Now()), ".csv", false)
+	if input.Layout == LayoutPivot {
+		table := pivotTable(input)
+		return NewCSVOutput(table.Headers, append(table.Rows, table.Footer), filename), nil
+	}
+
 	headers := []string{
 		"Dimension/Tag", "Dimension/Tag", "Metric",
 		"Granularity", "Start", "End", "USD Amount", "Unit",
 	}
+	bands, err := costBands(input)
+	if err != nil {
+		return nil, err
+	}
+	if bands == nil {
+		rows := utils.ConvertServiceMapToArray(input.Services, input.Granularity)
+		return NewCSVOutput(headers, rows, filename), nil
+	}
+
+	headers = append(headers, "Cost Band")
+	var rows [][]string
+	for _, service := range utils.ConvertMapToSlice(input.Services) {
+		for i, row := range utils.ConvertServiceToSlice(service, input.Granularity) {
+			rows = append(rows, append(row, bands.Band(service.Metrics[i].NumericAmount)))
+		}
+	}
 	
-	rows := utils.ConvertServiceMapToArray(input.Services, input.Granularity)
-	
-	return NewCSVOutput(headers, rows, "ccexplorer.csv"), nil
+	return NewCSVOutput(headers, rows, filename), nil
+}
+
+// costBands returns the encoder labelling the Cost Band column, or nil when
+// the column is not shown
+func costBands(input types.CostAndUsageOutputType) (codec.Encode, error) {
+	if !input.Binning.ShowColumn {
+		return nil, nil
+	}
+	return codec.ForResults(input.Binning, input)
 }
 
 // CostUsageToChartTransformer transforms cost and usage data to chart format
@@ -115,7 +174,8 @@ func (t *CostUsageToChartTransformer) Transform(input types.CostAndUsageOutputTy
 		return nil, err
 	}
 	
-	return NewChartOutput(page, "Cost and Usage Report", "ccexplorer_chart.html"), nil
+	filename := OutputPath(input.Output, costUsageFileNameFields(input, time.Now()), ".html", false)
+	return NewChartOutput(page, "Cost and Usage Report", filename), nil
 }
 
 // CostUsageToVectorTransformer transforms cost and usage data to vector format
@@ -128,13 +188,12 @@ func NewCostUsageToVectorTransformer() *CostUsageToVectorTransformer {
 
 // Transform implements the Transformer interface for vector output
 func (t *CostUsageToVectorTransformer) Transform(input types.CostAndUsageOutputType) (*VectorOutput, error) {
-	client := NewVectorStoreClient(nil, input.PineconeIndex, input.PineconeAPIKey, input.OpenAIAPIKey)
-	items, err := client.CreateVectorStoreInput(input)
+	items, err := vectorstore.NewDocumentBuilder().ConvertToVectorStoreItem(input)
 	if err != nil {
 		return nil, err
 	}
-	
-	return NewVectorOutput(items, input.PineconeIndex), nil
+
+	return NewVectorOutput(items, input.VectorStore, input.Embedding, input.Ingest), nil
 }
 
 // ForecastToTableTransformer transforms forecast data to table format
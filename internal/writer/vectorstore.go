@@ -2,65 +2,76 @@ package writer
 
 import (
 	"context"
-	"github.com/cduggn/ccexplorer/internal/http"
-	pinecone2 "github.com/cduggn/ccexplorer/internal/pinecone"
+	"os"
+
+	"github.com/cduggn/ccexplorer/internal/embedding"
 	"github.com/cduggn/ccexplorer/internal/types"
-	gogpt "github.com/sashabaranov/go-openai"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/cduggn/ccexplorer/internal/vectorstore"
 )
 
 type VectorStore interface {
 	CreateVectorStoreInput(r types.CostAndUsageOutputType) ([]*types.VectorStoreItem, error)
-	CreateEmbeddings(items []*types.VectorStoreItem) ([]gogpt.Embedding, error)
-	Upsert(context context.Context, data []pinecone2.PineconeStruct) (resp types.UpsertResponse, err error)
+	Ingest(ctx context.Context, items []*types.VectorStoreItem) (types.UpsertResponse, error)
 }
 
 type VectorStoreClient struct {
-	apikey         string
-	indexUrl       string
-	openAIAPIKey   string
-	requestbuilder http.Builder
-
-	client *pinecone2.ClientAPI
+	documents *vectorstore.DocumentBuilder
+	ingester  *vectorstore.Ingester
+	config    types.VectorStoreConfig
 }
 
-func NewVectorStoreClient(builder http.Builder, openAIAPIKey,
-	indexUrl,
-	pineconeAPIKey string) VectorStore {
-	return &VectorStoreClient{
-		apikey:         openAIAPIKey,
-		indexUrl:       indexUrl,
-		openAIAPIKey:   pineconeAPIKey,
-		requestbuilder: builder,
-		client: pinecone2.NewVectorStoreClient(builder, indexUrl,
-			pineconeAPIKey, openAIAPIKey),
+func NewVectorStoreClient(embeddingConfig types.EmbeddingConfig,
+	config types.VectorStoreConfig, ingestConfig types.IngestConfig) (VectorStore, error) {
+
+	embedder, err := embedding.New(embeddingConfig)
+	if err != nil {
+		return nil, err
+	}
+	store, err := vectorstore.New(config)
+	if err != nil {
+		return nil, err
 	}
+	return &VectorStoreClient{
+		documents: vectorstore.NewDocumentBuilder(),
+		ingester:  vectorstore.NewIngester(embedder, store, ingestConfig),
+		config:    config,
+	}, nil
 }
 
 func (v *VectorStoreClient) CreateVectorStoreInput(r types.CostAndUsageOutputType) ([]*types.VectorStoreItem, error) {
-	items := v.client.ConvertToVectorStoreItem(r)
+	return v.documents.ConvertToVectorStoreItem(r)
+}
+
+// Ingest embeds items in batches and writes them to the vector store,
+// resuming from the checkpoint of an earlier failed run of the same load
+func (v *VectorStoreClient) Ingest(ctx context.Context,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
 
-	return items, nil
+	ingester := *v.ingester
+	showProgress := ingester.Config.Progress && isTerminal(os.Stderr)
+	if showProgress {
+		report, stop := newProgressBar(os.Stderr, "Loading cost documents")
+		ingester.Progress = report
+		resp, err := ingester.Ingest(ctx, vectorstore.CheckpointName(v.config, items), items)
+		stop(err != nil)
+		return resp, err
+	}
+	return ingester.Ingest(ctx, vectorstore.CheckpointName(v.config, items), items)
 }
 
-func (v *VectorStoreClient) CreateEmbeddings(items []*types.VectorStoreItem) (
-	[]gogpt.Embedding,
-	error) {
+// embedAndUpsert derives the IDs of items from their text, then embeds them
+// and writes them to the vector store
+func embedAndUpsert(ctx context.Context, client VectorStore,
+	items []*types.VectorStoreItem) (types.UpsertResponse, error) {
 
-	batch := make([]string, len(items))
-	for index, item := range items {
-		batch[index] = item.EmbeddingText
+	for _, item := range items {
+		item.ID = utils.EncodeString(item.EmbeddingText)
 	}
 
-	vectors, err := v.client.LLMClient.GenerateEmbeddings(batch)
+	resp, err := client.Ingest(ctx, items)
 	if err != nil {
-		return nil, err
+		return types.UpsertResponse{}, types.Error{Msg: "Error loading vector store: " + err.Error()}
 	}
-
-	return vectors, nil
-}
-
-func (v *VectorStoreClient) Upsert(context context.Context,
-	items []pinecone2.PineconeStruct) (resp types.UpsertResponse, err error) {
-
-	return v.client.Upsert(context, items)
+	return resp, nil
 }
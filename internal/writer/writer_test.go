@@ -0,0 +1,26 @@
+package writer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPrintWriter(t *testing.T) {
+	report := costReportInput()
+	report.Output = types.OutputConfig{Path: filepath.Join(t.TempDir(), "costs.md")}
+
+	p, err := NewPrintWriter(types.Markdown, "costAndUsage")
+	require.NoError(t, err)
+	require.NoError(t, p.Write("cost", report))
+	assert.FileExists(t, report.Output.Path, "the printer writes to --output")
+
+	_, err = NewPrintWriter(types.XLSX, "forecast")
+	assert.ErrorContains(t, err, "only supported for cost and usage reports")
+
+	_, err = NewPrintWriter(types.OpenAPI, "forecast")
+	assert.ErrorContains(t, err, "Invalid print type")
+}
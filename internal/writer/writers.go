@@ -9,7 +9,13 @@ type CostUsageTableWriter = CompositeWriter[types.CostAndUsageOutputType, *Table
 type CostUsageCSVWriter = CompositeWriter[types.CostAndUsageOutputType, *CSVOutput]
 type CostUsageChartWriter = CompositeWriter[types.CostAndUsageOutputType, *ChartOutput]
 type CostUsageVectorWriter = CompositeWriter[types.CostAndUsageOutputType, *VectorOutput]
+type CostUsageJSONWriter = CompositeWriter[types.CostAndUsageOutputType, *JSONOutput]
+type CostUsageMarkdownWriter = CompositeWriter[types.CostAndUsageOutputType, *MarkdownOutput]
+type CostUsageXLSXWriter = CompositeWriter[types.CostAndUsageOutputType, *XLSXOutput]
 type ForecastTableWriter = CompositeWriter[types.ForecastPrintData, *ForecastTableOutput]
+type ForecastJSONWriter = CompositeWriter[types.ForecastPrintData, *JSONOutput]
+type ForecastCSVWriter = CompositeWriter[types.ForecastPrintData, *CSVOutput]
+type ForecastChartWriter = CompositeWriter[types.ForecastPrintData, *ChartOutput]
 
 // Factory functions for creating specific writer types
 
@@ -41,6 +47,27 @@ func NewCostUsageVectorWriter() *CostUsageVectorWriter {
 	return NewCompositeWriter[types.CostAndUsageOutputType, *VectorOutput](transformer, renderer)
 }
 
+// NewCostUsageJSONWriter creates a writer for cost usage JSON or NDJSON output
+func NewCostUsageJSONWriter(sortBy string, ndjson bool) *CostUsageJSONWriter {
+	transformer := NewCostUsageToJSONTransformer(sortBy, ndjson)
+	renderer := NewJSONRenderer()
+	return NewCompositeWriter[types.CostAndUsageOutputType, *JSONOutput](transformer, renderer)
+}
+
+// NewCostUsageMarkdownWriter creates a writer for cost usage Markdown output
+func NewCostUsageMarkdownWriter(sortBy string) *CostUsageMarkdownWriter {
+	transformer := NewCostUsageToMarkdownTransformer(sortBy)
+	renderer := NewMarkdownRenderer()
+	return NewCompositeWriter[types.CostAndUsageOutputType, *MarkdownOutput](transformer, renderer)
+}
+
+// NewCostUsageXLSXWriter creates a writer for cost usage XLSX output
+func NewCostUsageXLSXWriter(sortBy string) *CostUsageXLSXWriter {
+	transformer := NewCostUsageToXLSXTransformer(sortBy)
+	renderer := NewXLSXRenderer()
+	return NewCompositeWriter[types.CostAndUsageOutputType, *XLSXOutput](transformer, renderer)
+}
+
 // NewForecastTableWriter creates a writer for forecast table output
 func NewForecastTableWriter() *ForecastTableWriter {
 	transformer := NewForecastToTableTransformer()
@@ -48,6 +75,27 @@ func NewForecastTableWriter() *ForecastTableWriter {
 	return NewCompositeWriter[types.ForecastPrintData, *ForecastTableOutput](transformer, renderer)
 }
 
+// NewForecastCSVWriter creates a writer for forecast CSV output
+func NewForecastCSVWriter() *ForecastCSVWriter {
+	transformer := NewForecastToCSVTransformer()
+	renderer := NewCSVRenderer()
+	return NewCompositeWriter[types.ForecastPrintData, *CSVOutput](transformer, renderer)
+}
+
+// NewForecastChartWriter creates a writer for forecast chart output
+func NewForecastChartWriter() *ForecastChartWriter {
+	transformer := NewForecastToChartTransformer()
+	renderer := NewChartRenderer()
+	return NewCompositeWriter[types.ForecastPrintData, *ChartOutput](transformer, renderer)
+}
+
+// NewForecastJSONWriter creates a writer for forecast JSON or NDJSON output
+func NewForecastJSONWriter(ndjson bool) *ForecastJSONWriter {
+	transformer := NewForecastToJSONTransformer(ndjson)
+	renderer := NewJSONRenderer()
+	return NewCompositeWriter[types.ForecastPrintData, *JSONOutput](transformer, renderer)
+}
+
 // Legacy compatibility types - these wrap the new generic writers to maintain the old interface
 type GenericStdoutPrinter struct {
 	variant string
@@ -61,7 +109,20 @@ type GenericChartPrinter struct {
 	variant string
 }
 
-type GenericPineconePrinter struct {
+type GenericVectorPrinter struct {
+	variant string
+}
+
+type GenericJSONPrinter struct {
+	variant string
+	ndjson  bool
+}
+
+type GenericMarkdownPrinter struct {
+	variant string
+}
+
+type GenericXLSXPrinter struct {
 	variant string
 }
 
@@ -92,6 +153,9 @@ func NewGenericCsvPrinter(variant string) *GenericCsvPrinter {
 // Write implements the legacy Printer interface for CSV
 func (p *GenericCsvPrinter) Write(f interface{}, c interface{}) error {
 	switch p.variant {
+	case "forecast":
+		writer := NewForecastCSVWriter()
+		return writer.Write(f.(types.ForecastPrintData))
 	case "costAndUsage":
 		sortBy := f.(string)
 		writer := NewCostUsageCSVWriter(sortBy)
@@ -108,6 +172,9 @@ func NewGenericChartPrinter(variant string) *GenericChartPrinter {
 // Write implements the legacy Printer interface for charts
 func (p *GenericChartPrinter) Write(f interface{}, c interface{}) error {
 	switch p.variant {
+	case "forecast":
+		writer := NewForecastChartWriter()
+		return writer.Write(f.(types.ForecastPrintData))
 	case "costAndUsage":
 		sortBy := f.(string)
 		writer := NewCostUsageChartWriter(sortBy)
@@ -116,17 +183,69 @@ func (p *GenericChartPrinter) Write(f interface{}, c interface{}) error {
 	return nil
 }
 
-// NewGenericPineconePrinter creates a new Pinecone printer with backward compatibility
-func NewGenericPineconePrinter(variant string) *GenericPineconePrinter {
-	return &GenericPineconePrinter{variant: variant}
+// NewGenericVectorPrinter creates a new vector store printer with backward compatibility
+func NewGenericVectorPrinter(variant string) *GenericVectorPrinter {
+	return &GenericVectorPrinter{variant: variant}
 }
 
 // Write implements the legacy Printer interface for vector databases
-func (p *GenericPineconePrinter) Write(f interface{}, c interface{}) error {
+func (p *GenericVectorPrinter) Write(f interface{}, c interface{}) error {
 	switch p.variant {
 	case "costAndUsage":
 		writer := NewCostUsageVectorWriter()
 		return writer.Write(c.(types.CostAndUsageOutputType))
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// NewGenericJSONPrinter creates a new JSON printer; ndjson writes one value
+// per line
+func NewGenericJSONPrinter(variant string, ndjson bool) *GenericJSONPrinter {
+	return &GenericJSONPrinter{variant: variant, ndjson: ndjson}
+}
+
+// Write implements the legacy Printer interface for JSON
+func (p *GenericJSONPrinter) Write(f interface{}, c interface{}) error {
+	switch p.variant {
+	case "forecast":
+		writer := NewForecastJSONWriter(p.ndjson)
+		return writer.Write(f.(types.ForecastPrintData))
+	case "costAndUsage":
+		sortBy := f.(string)
+		writer := NewCostUsageJSONWriter(sortBy, p.ndjson)
+		return writer.Write(c.(types.CostAndUsageOutputType))
+	}
+	return nil
+}
+
+// NewGenericMarkdownPrinter creates a new Markdown table printer
+func NewGenericMarkdownPrinter(variant string) *GenericMarkdownPrinter {
+	return &GenericMarkdownPrinter{variant: variant}
+}
+
+// Write implements the legacy Printer interface for Markdown
+func (p *GenericMarkdownPrinter) Write(f interface{}, c interface{}) error {
+	switch p.variant {
+	case "costAndUsage":
+		sortBy := f.(string)
+		writer := NewCostUsageMarkdownWriter(sortBy)
+		return writer.Write(c.(types.CostAndUsageOutputType))
+	}
+	return nil
+}
+
+// NewGenericXLSXPrinter creates a new Excel workbook printer
+func NewGenericXLSXPrinter(variant string) *GenericXLSXPrinter {
+	return &GenericXLSXPrinter{variant: variant}
+}
+
+// Write implements the legacy Printer interface for XLSX
+func (p *GenericXLSXPrinter) Write(f interface{}, c interface{}) error {
+	switch p.variant {
+	case "costAndUsage":
+		sortBy := f.(string)
+		writer := NewCostUsageXLSXWriter(sortBy)
+		return writer.Write(c.(types.CostAndUsageOutputType))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package writer
+
+import (
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"github.com/cduggn/ccexplorer/internal/types"
+	"github.com/cduggn/ccexplorer/internal/utils"
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSheet = "Costs"
+
+// XLSXOutput represents a worksheet; amounts are numeric cells
+type XLSXOutput struct {
+	Headers []string
+	Rows    [][]any
+	// Footer is the totals row, if any
+	Footer []any
+	// Filename is the file written, or - for stdout
+	Filename string
+}
+
+// CostUsageToXLSXTransformer transforms cost and usage data to a worksheet
+// in the rows or pivot layout
+type CostUsageToXLSXTransformer struct {
+	sortFunc func(map[int]types.Service) []types.Service
+}
+
+// NewCostUsageToXLSXTransformer creates a new transformer for XLSX output
+func NewCostUsageToXLSXTransformer(sortBy string) *CostUsageToXLSXTransformer {
+	return &CostUsageToXLSXTransformer{sortFunc: utils.SortFunction(sortBy)}
+}
+
+// Transform implements the Transformer interface for XLSX output
+func (t *CostUsageToXLSXTransformer) Transform(input types.CostAndUsageOutputType) (*XLSXOutput, error) {
+	output := &XLSXOutput{
+		Filename: OutputPath(input.Output, costUsageFileNameFields(input, time.Now()), ".xlsx", false),
+	}
+
+	if input.Layout == LayoutPivot {
+		p := NewPivot(input)
+		output.Headers = p.Headers()
+		row := func(labels []string, amounts []float64, total float64) []any {
+			cells := make([]any, 0, len(output.Headers))
+			for _, l := range labels {
+				cells = append(cells, l)
+			}
+			for _, a := range amounts {
+				cells = append(cells, a)
+			}
+			cells = append(cells, total, xlsxPercent(p.Share(total)))
+			for i := 1; i < len(amounts); i++ {
+				cells = append(cells, xlsxPercent(Change(amounts, i)))
+			}
+			return cells
+		}
+		for _, r := range p.Rows {
+			output.Rows = append(output.Rows, row(r.Keys, r.Amounts, r.Total))
+		}
+		labels := make([]string, len(p.Labels))
+		if len(labels) > 0 {
+			labels[0] = "Total"
+		}
+		output.Footer = row(labels, p.PeriodTotals, p.Total)
+		return output, nil
+	}
+
+	output.Headers = []string{
+		"Dimension/Tag", "Dimension/Tag", "Metric",
+		"Granularity", "Start", "End", "Amount", "Unit",
+	}
+	for _, s := range t.sortFunc(input.Services) {
+		for _, m := range s.Metrics {
+			output.Rows = append(output.Rows, []any{
+				s.Keys[0], utils.ReturnIfPresent(s.Keys), m.Name,
+				input.Granularity, s.Start, s.End, m.NumericAmount, m.Unit,
+			})
+		}
+	}
+	return output, nil
+}
+
+// xlsxPercent returns a percentage rounded to one decimal, or an empty cell
+func xlsxPercent(value float64, ok bool) any {
+	if !ok {
+		return nil
+	}
+	return math.Round(value*10) / 10
+}
+
+// XLSXRenderer writes worksheets to a file or stdout
+type XLSXRenderer struct {
+	stdout io.Writer
+}
+
+// NewXLSXRenderer creates a new XLSX renderer
+func NewXLSXRenderer() *XLSXRenderer {
+	return &XLSXRenderer{stdout: os.Stdout}
+}
+
+// Render implements the Renderer interface for XLSX output
+func (r *XLSXRenderer) Render(data *XLSXOutput) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", xlsxSheet); err != nil {
+		return types.Error{Msg: "Error creating XLSX sheet: " + err.Error()}
+	}
+
+	rows := [][]any{make([]any, len(data.Headers))}
+	for i, h := range data.Headers {
+		rows[0][i] = h
+	}
+	rows = append(rows, data.Rows...)
+	if data.Footer != nil {
+		rows = append(rows, data.Footer)
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(xlsxSheet, cell, &row); err != nil {
+			return types.Error{Msg: "Error writing XLSX row: " + err.Error()}
+		}
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return types.Error{Msg: "Error writing XLSX: " + err.Error()}
+	}
+	last, _ := excelize.CoordinatesToCellName(max(len(data.Headers), 1), 1)
+	_ = f.SetCellStyle(xlsxSheet, "A1", last, bold)
+	if data.Footer != nil {
+		first, _ := excelize.CoordinatesToCellName(1, len(rows))
+		last, _ := excelize.CoordinatesToCellName(max(len(data.Headers), 1), len(rows))
+		_ = f.SetCellStyle(xlsxSheet, first, last, bold)
+	}
+	_ = f.SetPanes(xlsxSheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+
+	w, closeFile, err := createOutput(data.Filename, r.stdout)
+	if err != nil {
+		return types.Error{Msg: "Error creating XLSX file: " + err.Error()}
+	}
+	if err := f.Write(w); err != nil {
+		closeFile()
+		return types.Error{Msg: "Error writing XLSX: " + err.Error()}
+	}
+	if err := closeFile(); err != nil {
+		return types.Error{Msg: "Error writing XLSX: " + err.Error()}
+	}
+	return nil
+}